@@ -1,9 +1,14 @@
 package cmd
 
 import (
+	"bufio"
 	"fmt"
+	"os"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/thiemotorres/goc/internal/config"
 	"github.com/thiemotorres/goc/internal/data"
 )
 
@@ -41,31 +46,168 @@ func History(opts HistoryOptions) error {
 	}
 
 	fmt.Println("Recent Rides:")
-	fmt.Println("─────────────────────────────────────────────────────────────────")
-	fmt.Printf("%-20s  %-10s  %-10s  %-10s  %-12s\n",
-		"Date", "Duration", "Distance", "Avg Power", "Route")
-	fmt.Println("─────────────────────────────────────────────────────────────────")
+	fmt.Println("─────────────────────────────────────────────────────────────────────────────────────────────────────────────────")
+	fmt.Printf("%-17s  %-16s  %-8s  %-8s  %-9s  %-6s  %-4s  %-4s  %-7s  %-16s\n",
+		"ID", "Date", "Duration", "Distance", "Avg Power", "NP", "IF", "TSS", "Work", "Name")
+	fmt.Println("─────────────────────────────────────────────────────────────────────────────────────────────────────────────────")
 
 	for _, r := range rides {
 		date := r.StartTime.Format("2006-01-02 15:04")
 		duration := formatDurationShort(r.Duration)
 		distance := fmt.Sprintf("%.1f km", r.Distance/1000)
 		avgPower := fmt.Sprintf("%.0f W", r.AvgPower)
-		route := r.GPXName
-		if route == "" {
-			route = "Free ride"
+		np := formatMetric(r.NormalizedPower, "%.0f W")
+		intensity := formatMetric(r.IntensityFactor, "%.2f")
+		tss := formatMetric(r.TSS, "%.0f")
+		work := formatMetric(r.Work, "%.0f kJ")
+		name := rideName(r.Name, r.GPXName)
+		if len(name) > 16 {
+			name = name[:13] + "..."
 		}
-		if len(route) > 12 {
-			route = route[:9] + "..."
+
+		fmt.Printf("%-17s  %-16s  %-8s  %-8s  %-9s  %-6s  %-4s  %-4s  %-7s  %-16s\n",
+			r.ID, date, duration, distance, avgPower, np, intensity, tss, work, name)
+	}
+
+	return nil
+}
+
+// rideName is the name a ride is listed under: its own, else its route
+func rideName(name, gpxName string) string {
+	switch {
+	case name != "":
+		return name
+	case gpxName != "":
+		return gpxName
+	default:
+		return "Free ride"
+	}
+}
+
+// HistoryShow prints the details of one ride
+func HistoryShow(id string) error {
+	cfg, err := config.Load(config.DefaultConfigDir())
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+
+	store, err := data.NewStore(data.DefaultDataDir())
+	if err != nil {
+		return fmt.Errorf("open store: %w", err)
+	}
+	defer store.Close()
+
+	ride, err := store.LoadRide(id)
+	if err != nil {
+		return err
+	}
+	stats := ride.Stats()
+
+	fmt.Printf("Ride %s: %s\n", ride.ID, rideName(ride.Name, ride.GPXName))
+	fmt.Println("──────────────────────────────────────────")
+	fmt.Printf("Date:      %s\n", ride.StartTime.Format("2006-01-02 15:04"))
+	if ride.GPXName != "" {
+		fmt.Printf("Route:     %s\n", ride.GPXName)
+	}
+	fmt.Printf("Duration:  %s\n", formatDurationShort(stats.Duration))
+	fmt.Printf("Distance:  %.2f km\n", stats.Distance/1000)
+	fmt.Printf("Ascent:    %.0f m\n", stats.TotalAscent)
+	fmt.Printf("Power:     %.0f W avg, %.0f W max\n", stats.AvgPower, stats.MaxPower)
+	fmt.Printf("Cadence:   %.0f rpm avg\n", stats.AvgCadence)
+	fmt.Printf("Speed:     %.1f km/h avg, %.1f km/h max\n", stats.AvgSpeed, stats.MaxSpeed)
+	if ride.FTP > 0 {
+		fmt.Printf("FTP:       %.0f W\n", ride.FTP)
+	}
+
+	if len(ride.Metadata) > 0 {
+		keys := make([]string, 0, len(ride.Metadata))
+		for k := range ride.Metadata {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		fmt.Println("\nMetadata:")
+		for _, k := range keys {
+			fmt.Printf("  %s: %s\n", k, ride.Metadata[k])
 		}
+	}
 
-		fmt.Printf("%-20s  %-10s  %-10s  %-10s  %-12s\n",
-			date, duration, distance, avgPower, route)
+	if laps := ride.Laps(); len(laps) > 1 {
+		fmt.Println("\nLaps:")
+		for i, lap := range laps {
+			s := lap.Stats()
+			fmt.Printf("  %2d  %-8s  %4.0f W avg  %4.0f W max  %3.0f rpm\n",
+				i+1, formatDurationShort(lap.EndTime.Sub(lap.StartTime)), s.AvgPower, s.MaxPower, s.AvgCadence)
+		}
 	}
 
+	ftp := ride.FTP
+	if ftp == 0 {
+		ftp = cfg.Rider.EffectiveFTP()
+	}
+	zoneModel, _ := cfg.Rider.Zones() // checked when the config was loaded
+	printAnalytics(ride.PowerSeries(), ftp, zoneModel)
+
 	return nil
 }
 
+// HistoryRemove deletes a ride and its files, asking first unless force
+// is set
+func HistoryRemove(id string, force bool) error {
+	store, err := data.NewStore(data.DefaultDataDir())
+	if err != nil {
+		return fmt.Errorf("open store: %w", err)
+	}
+	defer store.Close()
+
+	ride, err := store.GetRide(id)
+	if err != nil {
+		return err
+	}
+
+	if !force {
+		fmt.Printf("Delete ride %s (%s, %s)? [y/N] ", ride.ID,
+			ride.StartTime.Format("2006-01-02 15:04"), rideName(ride.Name, ride.GPXName))
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+			fmt.Println("Cancelled.")
+			return nil
+		}
+	}
+
+	if err := store.DeleteRide(id); err != nil {
+		return fmt.Errorf("delete ride: %w", err)
+	}
+	fmt.Printf("Deleted ride %s\n", id)
+	return nil
+}
+
+// HistoryRename sets the name of a ride, clearing it when name is empty
+func HistoryRename(id, name string) error {
+	store, err := data.NewStore(data.DefaultDataDir())
+	if err != nil {
+		return fmt.Errorf("open store: %w", err)
+	}
+	defer store.Close()
+
+	if err := store.RenameRide(id, name); err != nil {
+		return fmt.Errorf("rename ride: %w", err)
+	}
+	if name == "" {
+		fmt.Printf("Cleared the name of ride %s\n", id)
+	} else {
+		fmt.Printf("Renamed ride %s to %q\n", id, name)
+	}
+	return nil
+}
+
+// formatMetric formats a ride metric, "-" when it was not recorded
+func formatMetric(v float64, format string) string {
+	if v == 0 {
+		return "-"
+	}
+	return fmt.Sprintf(format, v)
+}
+
 func formatDurationShort(d time.Duration) string {
 	h := int(d.Hours())
 	m := int(d.Minutes()) % 60
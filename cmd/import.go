@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/thiemotorres/goc/internal/data"
+)
+
+// ImportOptions configures FIT import
+type ImportOptions struct {
+	Paths []string
+}
+
+// Import adds FIT activity files to the ride history
+func Import(opts ImportOptions) error {
+	if len(opts.Paths) == 0 {
+		return errors.New("no files given")
+	}
+
+	store, err := data.NewStore(data.DefaultDataDir())
+	if err != nil {
+		return fmt.Errorf("open store: %w", err)
+	}
+	defer store.Close()
+
+	var imported, skipped, failed int
+	for _, path := range opts.Paths {
+		ride, err := data.ImportFIT(store, path)
+		switch {
+		case errors.Is(err, data.ErrDuplicateRide):
+			fmt.Printf("Skipped %s: ride on %s already exists\n", path, ride.StartTime.Format("2006-01-02 15:04"))
+			skipped++
+		case err != nil:
+			fmt.Printf("Failed %s: %v\n", path, err)
+			failed++
+		default:
+			stats := ride.Stats()
+			fmt.Printf("Imported %s: %s, %s, %.1f km\n",
+				path, ride.StartTime.Format("2006-01-02 15:04"),
+				formatDurationShort(stats.Duration), stats.Distance/1000)
+			imported++
+		}
+	}
+
+	fmt.Printf("\n%d imported, %d skipped, %d failed\n", imported, skipped, failed)
+
+	if failed > 0 {
+		return fmt.Errorf("%d file(s) could not be imported", failed)
+	}
+	return nil
+}
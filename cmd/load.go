@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/thiemotorres/goc/internal/analytics"
+	"github.com/thiemotorres/goc/internal/config"
+	"github.com/thiemotorres/goc/internal/data"
+)
+
+// LoadOptions configures the training load display
+type LoadOptions struct {
+	Days  int  // days to show
+	Chart bool // ASCII chart instead of a table
+}
+
+// projectionDays is how far ahead form is projected
+const projectionDays = 14
+
+// Load displays fitness (CTL), fatigue (ATL) and form (TSB) from the ride
+// history
+func Load(opts LoadOptions) error {
+	cfg, err := config.Load(config.DefaultConfigDir())
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+
+	store, err := data.NewStore(data.DefaultDataDir())
+	if err != nil {
+		return fmt.Errorf("open store: %w", err)
+	}
+	defer store.Close()
+
+	rides, err := store.ListRides()
+	if err != nil {
+		return fmt.Errorf("list rides: %w", err)
+	}
+	if len(rides) == 0 {
+		fmt.Println("No rides recorded yet.")
+		return nil
+	}
+
+	workloads := make([]analytics.Workload, len(rides))
+	for i, r := range rides {
+		workloads[i] = r.Workload(cfg.Rider.EffectiveFTP())
+	}
+	days := analytics.PMC(workloads, time.Now())
+	if len(days) == 0 {
+		// Every ride starts after today, as with a trainer clock set wrong
+		fmt.Println("No rides recorded up to today.")
+		return nil
+	}
+
+	limit := opts.Days
+	if limit <= 0 {
+		limit = 42
+	}
+	shown := days[max(len(days)-limit, 0):]
+
+	if opts.Chart {
+		printLoadChart(shown)
+	} else {
+		printLoadTable(shown)
+	}
+
+	// Form in two weeks if training continues as in the last week
+	load := analytics.AverageTSS(days, analytics.ATLDays)
+	projected := analytics.Project(days, projectionDays, load)
+	end := projected[len(projected)-1]
+	fmt.Printf("\nIn %d days at %.0f TSS/day: CTL %.0f, ATL %.0f, TSB %+.0f\n",
+		projectionDays, load, end.CTL, end.ATL, end.TSB)
+
+	return nil
+}
+
+func printLoadTable(days []analytics.Day) {
+	fmt.Println("Training Load:")
+	fmt.Println("──────────────────────────────────────────────")
+	fmt.Printf("%-12s  %6s  %6s  %6s  %6s\n", "Date", "TSS", "CTL", "ATL", "TSB")
+	fmt.Println("──────────────────────────────────────────────")
+
+	for _, d := range days {
+		tss := "-"
+		if d.TSS > 0 {
+			tss = fmt.Sprintf("%.0f", d.TSS)
+		}
+		fmt.Printf("%-12s  %6s  %6.1f  %6.1f  %+6.1f\n",
+			d.Date.Format("Mon Jan 02"), tss, d.CTL, d.ATL, d.TSB)
+	}
+}
+
+// loadChartHeight is the number of rows in the ASCII chart
+const loadChartHeight = 15
+
+// printLoadChart plots CTL (C), ATL (A) and TSB (T) with one column per
+// day. Where lines cross, fitness is drawn over fatigue over form.
+func printLoadChart(days []analytics.Day) {
+	lo, hi := 0.0, 0.0
+	for _, d := range days {
+		lo = min(lo, d.CTL, d.ATL, d.TSB)
+		hi = max(hi, d.CTL, d.ATL, d.TSB)
+	}
+	if hi == lo {
+		hi = lo + 1
+	}
+
+	row := func(v float64) int {
+		return int(math.Round((hi - v) / (hi - lo) * (loadChartHeight - 1)))
+	}
+
+	grid := make([][]rune, loadChartHeight)
+	for i := range grid {
+		grid[i] = []rune(strings.Repeat(" ", len(days)))
+	}
+	zero := row(0)
+	for x := range days {
+		grid[zero][x] = '─'
+	}
+	for x, d := range days {
+		grid[row(d.TSB)][x] = 'T'
+		grid[row(d.ATL)][x] = 'A'
+		grid[row(d.CTL)][x] = 'C'
+	}
+
+	fmt.Println("Training Load: C = fitness (CTL), A = fatigue (ATL), T = form (TSB)")
+	fmt.Println()
+	for i, line := range grid {
+		label := hi - float64(i)/(loadChartHeight-1)*(hi-lo)
+		if i == zero {
+			label = 0
+		}
+		fmt.Printf("%5.0f │%s\n", label, string(line))
+	}
+	fmt.Printf("      └%s\n", strings.Repeat("─", len(days)))
+	fmt.Printf("       %-*s%s\n", max(len(days)-6, 7),
+		days[0].Date.Format("Jan 02"), days[len(days)-1].Date.Format("Jan 02"))
+}
@@ -2,17 +2,21 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"syscall"
 	"time"
 
+	"github.com/thiemotorres/goc/internal/analytics"
 	"github.com/thiemotorres/goc/internal/bluetooth"
 	"github.com/thiemotorres/goc/internal/config"
 	"github.com/thiemotorres/goc/internal/data"
 	"github.com/thiemotorres/goc/internal/gpx"
 	"github.com/thiemotorres/goc/internal/simulation"
+	"github.com/thiemotorres/goc/internal/zones"
 )
 
 // RideOptions configures a ride session
@@ -20,6 +24,10 @@ type RideOptions struct {
 	GPXPath  string
 	ERGWatts int
 	Mock     bool // Use mock Bluetooth for development
+
+	// Override the configured power and cadence sources when set
+	PowerSource   string
+	CadenceSource string
 }
 
 // Ride starts a cycling session
@@ -30,12 +38,28 @@ func Ride(opts RideOptions) error {
 		return fmt.Errorf("load config: %w", err)
 	}
 
+	speedModel, err := simulation.ParseSpeedModel(cfg.Bike.SpeedModel)
+	if err != nil {
+		return fmt.Errorf("bike config: %w", err)
+	}
+	physics, err := cfg.Bike.Physics()
+	if err != nil {
+		return fmt.Errorf("bike config: %w", err)
+	}
+
 	// Create simulation engine
 	engine := simulation.NewEngine(simulation.EngineConfig{
 		Chainrings:         cfg.Bike.Chainrings,
 		Cassette:           cfg.Bike.Cassette,
 		WheelCircumference: cfg.Bike.WheelCircumference,
-		RiderWeight:        cfg.Bike.RiderWeight,
+		RiderWeight:        cfg.Rider.Weight,
+		ResistanceScaling:  cfg.Bike.ResistanceScaling,
+		GradientSmoothing:  cfg.Bike.GradientSmoothing,
+		TrainerSimulation:  cfg.Trainer.NativeSimulation,
+		SpeedModel:         speedModel,
+		Physics:            physics,
+		SynchroShift:       cfg.Shifter.SynchroShift,
+		PreventCrossChain:  cfg.Shifter.PreventCrossChain,
 	})
 
 	// Set mode
@@ -57,15 +81,21 @@ func Ride(opts RideOptions) error {
 	}
 
 	// Create Bluetooth manager
+	linkCh := make(chan bluetooth.ConnectionStatus, 10)
 	var btManager bluetooth.Manager
 	if opts.Mock {
 		btManager = bluetooth.NewMockManager()
 	} else {
 		btManager = bluetooth.NewFTMSManagerWithConfig(bluetooth.FTMSManagerConfig{
-			SavedAddress: cfg.Bluetooth.TrainerAddress,
+			SavedAddress:   cfg.Bluetooth.TrainerAddress,
+			SilenceTimeout: time.Duration(cfg.Trainer.SilenceTimeout * float64(time.Second)),
 			OnStatusChange: func(status bluetooth.ConnectionStatus) {
 				// Could update TUI status here
 				fmt.Printf("Bluetooth: %s\n", status)
+				select {
+				case linkCh <- status:
+				default:
+				}
 			},
 			OnDeviceSelection: func(devices []bluetooth.DeviceInfo) int {
 				fmt.Println("\nFound trainers:")
@@ -92,6 +122,55 @@ func Ride(opts RideOptions) error {
 	defer btManager.Disconnect()
 	fmt.Println("Connected!")
 
+	// Heart rate monitor is optional
+	var hrMonitor bluetooth.HeartRateMonitor
+	if opts.Mock {
+		hrMonitor = bluetooth.NewMockHeartRateMonitor()
+	} else if cfg.Bluetooth.HeartRateAddress != "" {
+		hrMonitor = bluetooth.NewHeartRateManager(cfg.Bluetooth.HeartRateAddress)
+	}
+	var hrCh <-chan bluetooth.HeartRateData
+	if hrMonitor != nil {
+		if err := hrMonitor.Connect(); err != nil {
+			fmt.Printf("Heart rate monitor: %v (continuing without)\n", err)
+		} else {
+			defer hrMonitor.Disconnect()
+			hrCh = hrMonitor.DataChannel()
+			fmt.Println("Heart rate monitor connected")
+		}
+	}
+
+	// Standalone sensors replace the trainer's power and cadence
+	if opts.PowerSource != "" {
+		cfg.Bluetooth.PowerSource = opts.PowerSource
+	}
+	if opts.CadenceSource != "" {
+		cfg.Bluetooth.CadenceSource = opts.CadenceSource
+	}
+	powerSrc, cadenceSrc, err := bluetooth.NewSourceSensors(
+		cfg.Bluetooth.PowerSource, cfg.Bluetooth.CadenceSource,
+		cfg.Bluetooth.PowerMeterAddress, cfg.Bluetooth.CadenceSensorAddress, opts.Mock)
+	if err != nil {
+		return err
+	}
+	var powerCh, cadenceCh <-chan bluetooth.SensorData
+	if powerSrc != nil {
+		fmt.Println("Connecting to power meter...")
+		if err := powerSrc.Connect(); err != nil {
+			return fmt.Errorf("connect power meter: %w", err)
+		}
+		defer powerSrc.Disconnect()
+		powerCh = powerSrc.DataChannel()
+	}
+	if cadenceSrc != nil && cadenceSrc != powerSrc {
+		fmt.Println("Connecting to cadence sensor...")
+		if err := cadenceSrc.Connect(); err != nil {
+			return fmt.Errorf("connect cadence sensor: %w", err)
+		}
+		defer cadenceSrc.Disconnect()
+		cadenceCh = cadenceSrc.DataChannel()
+	}
+
 	// Create data store
 	store, err := data.NewStore(data.DefaultDataDir())
 	if err != nil {
@@ -99,11 +178,29 @@ func Ride(opts RideOptions) error {
 	}
 	defer store.Close()
 
-	// Create ride recording
+	if unfinished, err := store.UnfinishedRides(); err == nil && len(unfinished) > 0 {
+		fmt.Printf("%d unfinished ride(s) found, run goc without a command to recover them\n", len(unfinished))
+	}
+
+	// Create ride recording, journaled until it is saved
 	ride := data.NewRide()
+	ride.FTP = cfg.Rider.FTP
 	if route != nil {
 		ride.GPXName = route.Name
 	}
+	session := map[string]string{"type": "free"}
+	switch {
+	case opts.GPXPath != "":
+		gpxPath, _ := filepath.Abs(opts.GPXPath)
+		session = map[string]string{"type": "route", "route": gpxPath}
+	case opts.ERGWatts > 0:
+		session["type"] = "erg"
+	}
+	journal, err := store.StartJournal(ride, session)
+	if err != nil {
+		return fmt.Errorf("start ride journal: %w", err)
+	}
+	var journalErr error
 
 	// Console mode - TUI will be added back with Bubble Tea
 	fmt.Println("Starting ride in console mode...")
@@ -130,14 +227,26 @@ func Ride(opts RideOptions) error {
 		totalCadence float64
 		totalSpeed   float64
 		pointCount   int
+
+		lastTrainerErr string
+		gapStart       time.Time // start of the current dropout, if any
+		heartRate      bluetooth.HeartRateData
+		hrAt           time.Time // time of the last heart rate reading
+		sensorPower    float64
+		powerAt        time.Time // time of the last power meter reading
+		sensorCadence  float64
+		cadenceAt      time.Time // time of the last cadence reading
 	)
 
 	// Ticker for periodic status output
 	statusTicker := time.NewTicker(5 * time.Second)
 	defer statusTicker.Stop()
 
-	// Main loop goroutine
+	// Main loop goroutine, the only one to touch the ride and journal
+	// until it is done
+	loopDone := make(chan struct{})
 	go func() {
+		defer close(loopDone)
 		for {
 			select {
 			case <-ctx.Done():
@@ -155,7 +264,21 @@ func Ride(opts RideOptions) error {
 				}
 
 				// Update simulation
-				state := engine.Update(trainerData.Cadence, trainerData.Power, gradient)
+				// Use the configured sources; a quiet sensor reads as zero
+				power, cadence := trainerData.Power, trainerData.Cadence
+				if powerSrc != nil {
+					power = 0
+					if now.Sub(powerAt) <= 3*time.Second {
+						power = sensorPower
+					}
+				}
+				if cadenceSrc != nil {
+					cadence = 0
+					if now.Sub(cadenceAt) <= 3*time.Second {
+						cadence = sensorCadence
+					}
+				}
+				state := engine.Update(cadence, power, gradient)
 
 				// Update position
 				if !paused {
@@ -170,7 +293,16 @@ func Ride(opts RideOptions) error {
 					ele = route.ElevationAt(currentDist)
 				}
 
-				ride.AddPoint(data.RidePoint{
+				// Prefer a recent reading from the heart rate monitor, then
+				// the trainer's built-in HR receiver
+				var hr int
+				if !hrAt.IsZero() && now.Sub(hrAt) <= 5*time.Second && heartRate.Contact {
+					hr = heartRate.HeartRate
+				} else if trainerData.Has(bluetooth.FieldHeartRate) {
+					hr = trainerData.HeartRate
+				}
+
+				point := data.RidePoint{
 					Timestamp:  now,
 					Power:      state.Power,
 					Cadence:    state.Cadence,
@@ -180,8 +312,14 @@ func Ride(opts RideOptions) error {
 					Elevation:  ele,
 					Distance:   currentDist,
 					Gradient:   gradient,
+					HeartRate:  hr,
 					GearString: state.GearString,
-				})
+				}
+				ride.AddPoint(point)
+				if err := journal.AddPoint(point); err != nil && journalErr == nil {
+					journalErr = err
+					fmt.Printf("\nWarning: recording: %v\n", err)
+				}
 
 				// Update averages
 				if !paused {
@@ -195,11 +333,101 @@ func Ride(opts RideOptions) error {
 				_ = state // values used in status ticker
 
 				// Send resistance to trainer
-				if state.Mode == simulation.ModeSIM || state.Mode == simulation.ModeFREE {
-					btManager.SetResistance(state.Resistance)
-				} else if state.Mode == simulation.ModeERG {
-					btManager.SetTargetPower(state.TargetPower)
+				var err error
+				switch {
+				case state.TrainerSimulation:
+					err = btManager.SetSimulationParameters(bluetooth.SimulationParameters{
+						Grade: state.EffectiveGrade,
+						Crr:   engine.Physics().Crr,
+						CW:    engine.Physics().CW(),
+					})
+					if errors.Is(err, bluetooth.ErrNotSupported) {
+						// Fall back to resistance levels for the rest of the ride
+						engine.SetTrainerSimulation(false)
+						err = btManager.SetResistance(state.Resistance)
+					}
+				case state.Mode == simulation.ModeSIM || state.Mode == simulation.ModeFREE:
+					err = btManager.SetResistance(state.Resistance)
+				case state.Mode == simulation.ModeERG:
+					err = btManager.SetTargetPower(state.TargetPower)
+					if errors.Is(err, bluetooth.ErrNotSupported) {
+						// Trainer has no ERG mode, ride on manual resistance
+						engine.SetMode(simulation.ModeFREE)
+						err = nil
+					}
+				}
+
+				// Report trainer errors once, not on every update
+				if err != nil && err.Error() != lastTrainerErr {
+					fmt.Printf("\nTrainer: %v\n", err)
+				}
+				lastTrainerErr = ""
+				if err != nil {
+					lastTrainerErr = err.Error()
+				}
+
+			case heartRate = <-hrCh:
+				hrAt = time.Now()
+
+			case d := <-powerCh:
+				if d.Has(bluetooth.FieldPower) {
+					sensorPower = d.Power
+					powerAt = time.Now()
+				}
+				if cadenceSrc == powerSrc && d.Has(bluetooth.FieldCadence) {
+					sensorCadence = d.Cadence
+					cadenceAt = time.Now()
+				}
+
+			case d := <-cadenceCh:
+				if d.Has(bluetooth.FieldCadence) {
+					sensorCadence = d.Cadence
+					cadenceAt = time.Now()
+				}
+
+			case status := <-linkCh:
+				// Keep the ride going across a dropout and mark the gap
+				now := time.Now()
+				switch status {
+				case bluetooth.StatusReconnecting:
+					if gapStart.IsZero() {
+						gapStart = now
+					}
+				case bluetooth.StatusConnected:
+					if !gapStart.IsZero() {
+						ride.MarkGap(gapStart, now)
+						journal.MarkGap(gapStart, now)
+						gapStart = time.Time{}
+						lastUpdate = now
+					}
+				}
+
+			case status := <-btManager.StatusChannel():
+				// Follow pauses from the trainer's own buttons
+				switch status.Event {
+				case bluetooth.EventStoppedByUser, bluetooth.EventPausedByUser, bluetooth.EventStoppedBySafetyKey:
+					if !paused {
+						paused = true
+						ride.Pause()
+						if err := journal.Pause(); err != nil && journalErr == nil {
+							journalErr = err
+							fmt.Printf("\nWarning: recording: %v\n", err)
+						}
+					}
+				case bluetooth.EventStartedByUser:
+					if paused {
+						paused = false
+						ride.Resume()
+						if err := journal.Resume(); err != nil && journalErr == nil {
+							journalErr = err
+							fmt.Printf("\nWarning: recording: %v\n", err)
+						}
+					}
+				case bluetooth.EventControlLost, bluetooth.EventReset:
+				default:
+					continue // target changes echo our own commands
 				}
+				fmt.Printf("\nTrainer: %s\n", status.Event)
 
 			case event := <-btManager.ShiftChannel():
 				switch event {
@@ -207,6 +435,10 @@ func Ride(opts RideOptions) error {
 					engine.ShiftUp()
 				case bluetooth.ShiftDown:
 					engine.ShiftDown()
+				case bluetooth.FrontShiftUp:
+					engine.FrontShiftUp()
+				case bluetooth.FrontShiftDown:
+					engine.FrontShiftDown()
 				}
 
 			case <-statusTicker.C:
@@ -223,23 +455,54 @@ func Ride(opts RideOptions) error {
 		}
 	}()
 
-	// Wait for context cancellation
+	// Wait for context cancellation and the loop to stop recording
 	<-ctx.Done()
+	<-loopDone
 	fmt.Println() // New line after status
 
 	// Save ride
 	ride.Finish()
-	if len(ride.Points) > 0 {
+	if len(ride.Points) == 0 {
+		journal.Discard()
+	} else {
 		fmt.Println("\nSaving ride...")
 		if err := store.SaveRide(ride); err != nil {
+			// Keep the journal so the ride can be recovered
+			journal.Close()
 			return fmt.Errorf("save ride: %w", err)
 		}
+		journal.Discard()
 		fmt.Printf("Ride saved: %s\n", store.GetFITPath(ride.ID))
+		zoneModel, _ := cfg.Rider.Zones() // checked when the config was loaded
+		printAnalytics(ride.PowerSeries(), cfg.Rider.EffectiveFTP(), zoneModel)
 	}
 
 	return nil
 }
 
+// printAnalytics prints the training metrics, best efforts and time in
+// zone of a ride
+func printAnalytics(watts []float64, ftp float64, model zones.Model) {
+	s := analytics.Analyze(watts, ftp)
+
+	fmt.Printf("\nNP %.0f W | IF %.2f | TSS %.0f | VI %.2f | %.0f kJ\n",
+		s.NormalizedPower, s.IntensityFactor, s.TSS, s.VariabilityIndex, s.Work)
+
+	fmt.Print("Best:")
+	for _, e := range s.PowerCurve {
+		if e.Power > 0 {
+			fmt.Printf(" %s %.0f W", e.Label(), e.Power)
+		}
+	}
+	fmt.Println()
+
+	for i, d := range analytics.TimeInZone(watts, ftp, model) {
+		if d > 0 {
+			fmt.Printf("  Z%d %-16s %s\n", i+1, model.Zones[i].Name, formatDuration(d))
+		}
+	}
+}
+
 func formatDuration(d time.Duration) string {
 	h := int(d.Hours())
 	m := int(d.Minutes()) % 60
@@ -0,0 +1,169 @@
+// Package analytics computes training metrics from 1 Hz power data
+package analytics
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/thiemotorres/goc/internal/zones"
+)
+
+// npWindow is the rolling average window for normalized power, in seconds
+const npWindow = 30
+
+// CurveDurations are the efforts reported in the mean-maximal power curve
+var CurveDurations = []time.Duration{
+	5 * time.Second,
+	time.Minute,
+	5 * time.Minute,
+	20 * time.Minute,
+	60 * time.Minute,
+}
+
+// Effort is the best average power held for a duration
+type Effort struct {
+	Duration time.Duration
+	Power    float64 // 0 if the ride is shorter than Duration
+}
+
+// Label names the effort duration, e.g. 5s or 20min
+func (e Effort) Label() string {
+	if e.Duration < time.Minute {
+		return fmt.Sprintf("%ds", int(e.Duration.Seconds()))
+	}
+	return fmt.Sprintf("%dmin", int(e.Duration.Minutes()))
+}
+
+// Summary holds the metrics of a ride. IF and TSS are 0 without an FTP.
+type Summary struct {
+	Duration         time.Duration // seconds of power data
+	AvgPower         float64
+	MaxPower         float64
+	NormalizedPower  float64
+	IntensityFactor  float64
+	TSS              float64
+	VariabilityIndex float64
+	Work             float64 // kJ
+	PowerCurve       []Effort
+}
+
+// Analyze computes the ride metrics from power sampled every second
+func Analyze(watts []float64, ftp float64) Summary {
+	s := Summary{
+		Duration:   time.Duration(len(watts)) * time.Second,
+		PowerCurve: PowerCurve(watts, CurveDurations),
+	}
+	if len(watts) == 0 {
+		return s
+	}
+
+	var sum float64
+	for _, w := range watts {
+		sum += w
+		s.MaxPower = max(s.MaxPower, w)
+	}
+	s.AvgPower = sum / float64(len(watts))
+	s.Work = sum / 1000
+
+	s.NormalizedPower = NormalizedPower(watts)
+	s.VariabilityIndex = VariabilityIndex(s.NormalizedPower, s.AvgPower)
+	s.IntensityFactor = IntensityFactor(s.NormalizedPower, ftp)
+	s.TSS = TSS(s.Duration, s.NormalizedPower, ftp)
+	return s
+}
+
+// NormalizedPower is the fourth-power mean of the 30 s rolling average
+// power. Rides shorter than the window return their average power.
+func NormalizedPower(watts []float64) float64 {
+	if len(watts) == 0 {
+		return 0
+	}
+	if len(watts) < npWindow {
+		var sum float64
+		for _, w := range watts {
+			sum += w
+		}
+		return sum / float64(len(watts))
+	}
+
+	var sum, sum4 float64
+	var n int
+	for i, w := range watts {
+		sum += w
+		if i >= npWindow {
+			sum -= watts[i-npWindow]
+		}
+		if i >= npWindow-1 {
+			sum4 += math.Pow(sum/npWindow, 4)
+			n++
+		}
+	}
+	return math.Pow(sum4/float64(n), 0.25)
+}
+
+// IntensityFactor is normalized power relative to FTP, 0 without an FTP
+func IntensityFactor(np, ftp float64) float64 {
+	if ftp <= 0 {
+		return 0
+	}
+	return np / ftp
+}
+
+// TSS is the Training Stress Score: an hour at FTP scores 100. It is 0
+// without an FTP.
+func TSS(duration time.Duration, np, ftp float64) float64 {
+	intensity := IntensityFactor(np, ftp)
+	return duration.Hours() * intensity * intensity * 100
+}
+
+// VariabilityIndex is normalized power over average power, 1 for a
+// perfectly steady ride
+func VariabilityIndex(np, avg float64) float64 {
+	if avg <= 0 {
+		return 0
+	}
+	return np / avg
+}
+
+// BestEffort returns the highest average power over any d long stretch,
+// 0 if there is less data than d
+func BestEffort(watts []float64, d time.Duration) float64 {
+	n := int(d / time.Second)
+	if n <= 0 || n > len(watts) {
+		return 0
+	}
+
+	var sum float64
+	for _, w := range watts[:n] {
+		sum += w
+	}
+	best := sum
+	for i := n; i < len(watts); i++ {
+		sum += watts[i] - watts[i-n]
+		best = max(best, sum)
+	}
+	return best / float64(n)
+}
+
+// PowerCurve returns the best effort for each duration
+func PowerCurve(watts []float64, durations []time.Duration) []Effort {
+	curve := make([]Effort, len(durations))
+	for i, d := range durations {
+		curve[i] = Effort{Duration: d, Power: BestEffort(watts, d)}
+	}
+	return curve
+}
+
+// TimeInZone returns the time spent in each zone of the model, nil
+// without an FTP
+func TimeInZone(watts []float64, ftp float64, model zones.Model) []time.Duration {
+	if ftp <= 0 || len(model.Zones) == 0 {
+		return nil
+	}
+	times := make([]time.Duration, len(model.Zones))
+	for _, w := range watts {
+		times[model.Zone(w, ftp)] += time.Second
+	}
+	return times
+}
@@ -0,0 +1,92 @@
+package analytics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thiemotorres/goc/internal/zones"
+)
+
+// constant returns n seconds at the same power
+func constant(n int, w float64) []float64 {
+	watts := make([]float64, n)
+	for i := range watts {
+		watts[i] = w
+	}
+	return watts
+}
+
+func TestAnalyze_Steady(t *testing.T) {
+	// An hour at FTP: NP equals average power, IF 1 and 100 TSS
+	s := Analyze(constant(3600, 250), 250)
+
+	assert.Equal(t, time.Hour, s.Duration)
+	assert.InDelta(t, 250, s.AvgPower, 0.001)
+	assert.InDelta(t, 250, s.NormalizedPower, 0.001)
+	assert.InDelta(t, 1, s.IntensityFactor, 0.001)
+	assert.InDelta(t, 100, s.TSS, 0.01)
+	assert.InDelta(t, 1, s.VariabilityIndex, 0.001)
+	assert.InDelta(t, 900, s.Work, 0.001)
+}
+
+func TestAnalyze_Intervals(t *testing.T) {
+	// 5 min on, 5 min off: NP is well above the average
+	var watts []float64
+	for range 6 {
+		watts = append(watts, constant(300, 350)...)
+		watts = append(watts, constant(300, 150)...)
+	}
+	s := Analyze(watts, 250)
+
+	assert.InDelta(t, 250, s.AvgPower, 0.001)
+	assert.Greater(t, s.NormalizedPower, 280.0)
+	assert.Greater(t, s.VariabilityIndex, 1.1)
+	assert.Greater(t, s.TSS, 100.0)
+	assert.Equal(t, 350.0, s.MaxPower)
+}
+
+func TestAnalyze_NoFTP(t *testing.T) {
+	s := Analyze(constant(600, 200), 0)
+	assert.InDelta(t, 200, s.NormalizedPower, 0.001)
+	assert.Equal(t, 0.0, s.IntensityFactor)
+	assert.Equal(t, 0.0, s.TSS)
+
+	assert.Equal(t, Summary{PowerCurve: PowerCurve(nil, CurveDurations)}, Analyze(nil, 250))
+}
+
+func TestNormalizedPower_Short(t *testing.T) {
+	assert.InDelta(t, 150, NormalizedPower([]float64{100, 200}), 0.001)
+}
+
+func TestPowerCurve(t *testing.T) {
+	watts := constant(600, 200)
+	for i := 100; i < 105; i++ {
+		watts[i] = 800
+	}
+	for i := 300; i < 360; i++ {
+		watts[i] = 400
+	}
+
+	curve := PowerCurve(watts, CurveDurations)
+	require.Len(t, curve, 5)
+	assert.Equal(t, 800.0, curve[0].Power)
+	assert.Equal(t, 400.0, curve[1].Power)
+	assert.InDelta(t, (240*200+60*400+5*600)/300.0, curve[2].Power, 0.001)
+	assert.Equal(t, 0.0, curve[3].Power) // ride shorter than 20 min
+	assert.Equal(t, 0.0, curve[4].Power)
+
+	assert.Equal(t, "5s", curve[0].Label())
+	assert.Equal(t, "20min", curve[3].Label())
+}
+
+func TestTimeInZone(t *testing.T) {
+	watts := append(constant(60, 100), constant(120, 260)...)
+	times := TimeInZone(watts, 250, zones.Coggan)
+
+	require.Len(t, times, 7)
+	assert.Equal(t, time.Minute, times[0])
+	assert.Equal(t, 2*time.Minute, times[3])
+	assert.Nil(t, TimeInZone(watts, 0, zones.Coggan))
+}
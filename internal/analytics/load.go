@@ -0,0 +1,106 @@
+package analytics
+
+import "time"
+
+// Time constants of the performance management chart, in days
+const (
+	CTLDays = 42 // chronic training load, fitness
+	ATLDays = 7  // acute training load, fatigue
+)
+
+// Workload is the training stress of one session
+type Workload struct {
+	Time time.Time
+	TSS  float64
+}
+
+// Day is one day of the performance management chart. TSB is the form
+// going into the day: yesterday's CTL minus yesterday's ATL.
+type Day struct {
+	Date      time.Time // midnight, local time
+	TSS       float64
+	CTL       float64
+	ATL       float64
+	TSB       float64
+	Projected bool // beyond the last recorded day
+}
+
+// day returns midnight of t's day in local time
+func day(t time.Time) time.Time {
+	y, m, d := t.Local().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.Local)
+}
+
+// DailyTSS sums the workloads by local calendar day
+func DailyTSS(workloads []Workload) map[time.Time]float64 {
+	daily := make(map[time.Time]float64)
+	for _, w := range workloads {
+		daily[day(w.Time)] += w.TSS
+	}
+	return daily
+}
+
+// PMC computes fitness, fatigue and form for every day from the first
+// workload up to and including until. Loads start from zero.
+func PMC(workloads []Workload, until time.Time) []Day {
+	if len(workloads) == 0 {
+		return nil
+	}
+	daily := DailyTSS(workloads)
+
+	first := day(workloads[0].Time)
+	for _, w := range workloads[1:] {
+		if d := day(w.Time); d.Before(first) {
+			first = d
+		}
+	}
+
+	var days []Day
+	var ctl, atl float64
+	last := day(until)
+	for d := first; !d.After(last); d = d.AddDate(0, 0, 1) {
+		next := step(Day{CTL: ctl, ATL: atl}, d, daily[d])
+		ctl, atl = next.CTL, next.ATL
+		days = append(days, next)
+	}
+	return days
+}
+
+// step returns the day after prev given the day's TSS
+func step(prev Day, date time.Time, tss float64) Day {
+	return Day{
+		Date: date,
+		TSS:  tss,
+		CTL:  prev.CTL + (tss-prev.CTL)/CTLDays,
+		ATL:  prev.ATL + (tss-prev.ATL)/ATLDays,
+		TSB:  prev.CTL - prev.ATL,
+	}
+}
+
+// AverageTSS returns the mean daily TSS over the last n days
+func AverageTSS(days []Day, n int) float64 {
+	if len(days) == 0 || n <= 0 {
+		return 0
+	}
+	n = min(n, len(days))
+	var sum float64
+	for _, d := range days[len(days)-n:] {
+		sum += d.TSS
+	}
+	return sum / float64(n)
+}
+
+// Project continues the chart for n days with the same TSS every day
+func Project(days []Day, n int, dailyTSS float64) []Day {
+	if len(days) == 0 {
+		return nil
+	}
+	prev := days[len(days)-1]
+	projected := make([]Day, 0, n)
+	for range n {
+		prev = step(prev, prev.Date.AddDate(0, 0, 1), dailyTSS)
+		prev.Projected = true
+		projected = append(projected, prev)
+	}
+	return projected
+}
@@ -0,0 +1,65 @@
+package analytics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPMC(t *testing.T) {
+	start := time.Date(2024, 3, 1, 18, 0, 0, 0, time.Local)
+	workloads := []Workload{
+		{Time: start, TSS: 70},
+		{Time: start.Add(2 * time.Hour), TSS: 30}, // same day
+		{Time: start.AddDate(0, 0, 2), TSS: 140},
+	}
+
+	days := PMC(workloads, start.AddDate(0, 0, 4))
+	require.Len(t, days, 5)
+
+	assert.Equal(t, time.Date(2024, 3, 1, 0, 0, 0, 0, time.Local), days[0].Date)
+	assert.Equal(t, 100.0, days[0].TSS)
+	assert.InDelta(t, 100.0/42, days[0].CTL, 1e-9)
+	assert.InDelta(t, 100.0/7, days[0].ATL, 1e-9)
+	assert.Equal(t, 0.0, days[0].TSB)
+
+	// Rest day: loads decay and form is yesterday's balance
+	assert.Equal(t, 0.0, days[1].TSS)
+	assert.Less(t, days[1].ATL, days[0].ATL)
+	assert.InDelta(t, days[0].CTL-days[0].ATL, days[1].TSB, 1e-9)
+
+	assert.Equal(t, 140.0, days[2].TSS)
+	assert.Nil(t, PMC(nil, start))
+}
+
+func TestPMC_SteadyState(t *testing.T) {
+	// A long block at the same daily load brings CTL and ATL to that load
+	start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.Local)
+	var workloads []Workload
+	for i := range 365 {
+		workloads = append(workloads, Workload{Time: start.AddDate(0, 0, i), TSS: 60})
+	}
+
+	days := PMC(workloads, start.AddDate(0, 0, 364))
+	last := days[len(days)-1]
+	assert.InDelta(t, 60, last.CTL, 0.1)
+	assert.InDelta(t, 60, last.ATL, 0.1)
+	assert.InDelta(t, 0, last.TSB, 0.1)
+	assert.InDelta(t, 60, AverageTSS(days, 7), 1e-9)
+}
+
+func TestProject(t *testing.T) {
+	days := []Day{{Date: time.Date(2024, 3, 1, 0, 0, 0, 0, time.Local), CTL: 50, ATL: 80}}
+
+	// Resting lets fatigue drop faster than fitness, so form rises
+	projected := Project(days, 14, 0)
+	require.Len(t, projected, 14)
+	assert.True(t, projected[0].Projected)
+	assert.Equal(t, time.Date(2024, 3, 15, 0, 0, 0, 0, time.Local), projected[13].Date)
+	assert.Greater(t, projected[13].TSB, projected[0].TSB)
+	assert.Greater(t, projected[13].TSB, 0.0)
+
+	assert.Nil(t, Project(nil, 14, 50))
+}
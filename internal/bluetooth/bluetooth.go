@@ -1,17 +1,265 @@
 package bluetooth
 
-// TrainerData represents data received from trainer
+import (
+	"errors"
+	"math"
+)
+
+// ErrNotSupported is returned for commands the trainer does not support
+var ErrNotSupported = errors.New("not supported by trainer")
+
+// TrainerField identifies an optional field in TrainerData
+type TrainerField uint32
+
+const (
+	FieldSpeed TrainerField = 1 << iota
+	FieldAvgSpeed
+	FieldCadence
+	FieldAvgCadence
+	FieldTotalDistance
+	FieldResistanceLevel
+	FieldPower
+	FieldAvgPower
+	FieldExpendedEnergy
+	FieldHeartRate
+	FieldMET
+	FieldElapsedTime
+	FieldRemainingTime
+)
+
+// TrainerData represents data received from trainer.
+// Present marks which fields the trainer reported.
 type TrainerData struct {
-	Power   float64
-	Cadence float64
+	Present TrainerField
+
+	Power           float64 // W
+	Cadence         float64 // rpm
+	Speed           float64 // km/h
+	AvgSpeed        float64 // km/h
+	AvgCadence      float64 // rpm
+	TotalDistance   float64 // meters
+	ResistanceLevel float64 // unitless, trainer specific
+	AvgPower        float64 // W
+	TotalEnergy     int     // kcal
+	EnergyPerHour   int     // kcal/h
+	EnergyPerMinute int     // kcal/min
+	HeartRate       int     // bpm
+	MET             float64 // metabolic equivalent
+	ElapsedTime     int     // seconds
+	RemainingTime   int     // seconds
+
+	// MoreData is set when the trainer split the record across several
+	// notifications and further packets follow
+	MoreData bool
+}
+
+// Has reports whether the trainer reported field f
+func (d TrainerData) Has(f TrainerField) bool {
+	return d.Present&f != 0
+}
+
+// Merge returns d with every field present in other copied over.
+// MoreData is taken from other.
+func (d TrainerData) Merge(other TrainerData) TrainerData {
+	if other.Has(FieldSpeed) {
+		d.Speed = other.Speed
+	}
+	if other.Has(FieldAvgSpeed) {
+		d.AvgSpeed = other.AvgSpeed
+	}
+	if other.Has(FieldCadence) {
+		d.Cadence = other.Cadence
+	}
+	if other.Has(FieldAvgCadence) {
+		d.AvgCadence = other.AvgCadence
+	}
+	if other.Has(FieldTotalDistance) {
+		d.TotalDistance = other.TotalDistance
+	}
+	if other.Has(FieldResistanceLevel) {
+		d.ResistanceLevel = other.ResistanceLevel
+	}
+	if other.Has(FieldPower) {
+		d.Power = other.Power
+	}
+	if other.Has(FieldAvgPower) {
+		d.AvgPower = other.AvgPower
+	}
+	if other.Has(FieldExpendedEnergy) {
+		d.TotalEnergy = other.TotalEnergy
+		d.EnergyPerHour = other.EnergyPerHour
+		d.EnergyPerMinute = other.EnergyPerMinute
+	}
+	if other.Has(FieldHeartRate) {
+		d.HeartRate = other.HeartRate
+	}
+	if other.Has(FieldMET) {
+		d.MET = other.MET
+	}
+	if other.Has(FieldElapsedTime) {
+		d.ElapsedTime = other.ElapsedTime
+	}
+	if other.Has(FieldRemainingTime) {
+		d.RemainingTime = other.RemainingTime
+	}
+	d.Present |= other.Present
+	d.MoreData = other.MoreData
+	return d
+}
+
+// SimulationParameters are the inputs of the FTMS Set Indoor Bike
+// Simulation Parameters command
+type SimulationParameters struct {
+	WindSpeed float64 // m/s, positive is headwind
+	Grade     float64 // percent
+	Crr       float64 // rolling resistance coefficient
+	CW        float64 // wind resistance coefficient in kg/m (0.5 × air density × CdA)
+}
+
+// Target Setting Features bits of the Fitness Machine Feature characteristic
+const (
+	TargetResistance           uint32 = 1 << 2
+	TargetPower                uint32 = 1 << 3
+	TargetIndoorBikeSimulation uint32 = 1 << 13
+)
+
+// ValueRange is a supported setting range reported by the trainer
+type ValueRange struct {
+	Min       float64
+	Max       float64
+	Increment float64
+}
+
+// IsSet reports whether the trainer reported the range
+func (r ValueRange) IsSet() bool {
+	return r.Max > r.Min
+}
+
+// Clamp limits v to the range and rounds it to the nearest step
+func (r ValueRange) Clamp(v float64) float64 {
+	if !r.IsSet() {
+		return v
+	}
+	if r.Increment > 0 {
+		v = r.Min + math.Round((v-r.Min)/r.Increment)*r.Increment
+	}
+	return math.Max(r.Min, math.Min(r.Max, v))
+}
+
+// TrainerCapabilities describes what the connected trainer supports.
+// When Known is false the trainer did not report its features and all
+// basic control commands are assumed to work.
+type TrainerCapabilities struct {
+	Known           bool
+	MachineFeatures uint32     // Fitness Machine Features bit field
+	TargetFeatures  uint32     // Target Setting Features bit field
+	Resistance      ValueRange // unitless, 0.1 resolution
+	Power           ValueRange // W
+}
+
+// SupportsResistance reports whether resistance levels can be set
+func (c TrainerCapabilities) SupportsResistance() bool {
+	return !c.Known || c.TargetFeatures&TargetResistance != 0
+}
+
+// SupportsERG reports whether a target power can be set
+func (c TrainerCapabilities) SupportsERG() bool {
+	return !c.Known || c.TargetFeatures&TargetPower != 0
+}
+
+// SupportsSimulation reports whether the trainer runs its own grade
+// simulation. Only trusted when advertised.
+func (c TrainerCapabilities) SupportsSimulation() bool {
+	return c.Known && c.TargetFeatures&TargetIndoorBikeSimulation != 0
+}
+
+// ClampPower limits a target power to the trainer's power range
+func (c TrainerCapabilities) ClampPower(watts float64) float64 {
+	return c.Power.Clamp(watts)
+}
+
+// ClampResistance limits a resistance level (0-100) to the trainer's
+// resistance range. 100% corresponds to 20.0 in trainer units.
+func (c TrainerCapabilities) ClampResistance(level float64) float64 {
+	level = math.Max(0, math.Min(100, level))
+	if !c.Resistance.IsSet() {
+		return level
+	}
+	return c.Resistance.Clamp(level/5) * 5
+}
+
+// MachineEvent identifies a Fitness Machine Status event
+type MachineEvent int
+
+const (
+	EventUnknown MachineEvent = iota
+	EventReset
+	EventStoppedByUser
+	EventPausedByUser
+	EventStoppedBySafetyKey
+	EventStartedByUser
+	EventTargetResistanceChanged
+	EventTargetPowerChanged
+	EventSimulationChanged
+	EventSpinDown
+	EventControlLost
+)
+
+func (e MachineEvent) String() string {
+	switch e {
+	case EventReset:
+		return "Reset"
+	case EventStoppedByUser:
+		return "Stopped by user"
+	case EventPausedByUser:
+		return "Paused by user"
+	case EventStoppedBySafetyKey:
+		return "Stopped by safety key"
+	case EventStartedByUser:
+		return "Started by user"
+	case EventTargetResistanceChanged:
+		return "Target resistance changed"
+	case EventTargetPowerChanged:
+		return "Target power changed"
+	case EventSimulationChanged:
+		return "Simulation parameters changed"
+	case EventSpinDown:
+		return "Spin down"
+	case EventControlLost:
+		return "Control permission lost"
+	default:
+		return "Unknown"
+	}
+}
+
+// Spin down status values of EventSpinDown
+const (
+	SpinDownRequested    = 1
+	SpinDownSuccess      = 2
+	SpinDownError        = 3
+	SpinDownStopPedaling = 4
+)
+
+// MachineStatus is an event reported by the trainer on the Fitness
+// Machine Status characteristic
+type MachineStatus struct {
+	Event  MachineEvent
+	OpCode byte // raw status op code
+
+	// Value holds the event parameter: target resistance (unitless),
+	// target power (W) or spin down status
+	Value      float64
+	Simulation SimulationParameters // for EventSimulationChanged
 }
 
 // ShiftEvent represents a shift button press
 type ShiftEvent int
 
 const (
-	ShiftUp ShiftEvent = iota
-	ShiftDown
+	ShiftUp        ShiftEvent = iota // rear, harder
+	ShiftDown                        // rear, easier
+	FrontShiftUp                     // larger chainring
+	FrontShiftDown                   // smaller chainring
 )
 
 // Manager defines the interface for Bluetooth communication
@@ -31,11 +279,25 @@ type Manager interface {
 	// ShiftChannel returns channel for shift events
 	ShiftChannel() <-chan ShiftEvent
 
-	// SetResistance sets trainer resistance (0-100)
+	// StatusChannel returns channel for trainer status events
+	StatusChannel() <-chan MachineStatus
+
+	// SetResistance sets trainer resistance (0-100). The target setters
+	// return without waiting for the trainer; a command it rejects is
+	// reported by the following calls.
 	SetResistance(level float64) error
 
 	// SetTargetPower sets ERG mode target power
 	SetTargetPower(watts float64) error
+
+	// SetSimulationParameters hands grade and resistance coefficients to the
+	// trainer's own simulation. Returns ErrNotSupported if the trainer
+	// lacks the feature.
+	SetSimulationParameters(params SimulationParameters) error
+
+	// Capabilities returns the features and setting ranges reported by
+	// the trainer. Valid after Connect.
+	Capabilities() TrainerCapabilities
 }
 
 // ConnectionStatus represents BLE connection state
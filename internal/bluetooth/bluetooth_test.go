@@ -44,5 +44,86 @@ func TestMockManager_SetResistance(t *testing.T) {
 	err = mgr.SetTargetPower(200)
 	require.NoError(t, err)
 
+	err = mgr.SetSimulationParameters(SimulationParameters{Grade: 4})
+	require.NoError(t, err)
+
 	mgr.Disconnect()
 }
+
+func TestValueRange_Clamp(t *testing.T) {
+	r := ValueRange{Min: 25, Max: 1000, Increment: 5}
+
+	assert.Equal(t, 25.0, r.Clamp(0))
+	assert.Equal(t, 1000.0, r.Clamp(1500))
+	assert.Equal(t, 200.0, r.Clamp(201))
+	assert.Equal(t, 205.0, r.Clamp(203))
+
+	// Unset range passes values through
+	assert.Equal(t, 1500.0, ValueRange{}.Clamp(1500))
+}
+
+func TestTrainerCapabilities(t *testing.T) {
+	// Unknown features: basic control is assumed, simulation is not
+	var unknown TrainerCapabilities
+	assert.True(t, unknown.SupportsERG())
+	assert.True(t, unknown.SupportsResistance())
+	assert.False(t, unknown.SupportsSimulation())
+	assert.Equal(t, 300.0, unknown.ClampPower(300))
+	assert.Equal(t, 100.0, unknown.ClampResistance(150))
+
+	caps := TrainerCapabilities{
+		Known:          true,
+		TargetFeatures: TargetResistance,
+		Resistance:     ValueRange{Min: 0, Max: 10, Increment: 1},
+		Power:          ValueRange{Min: 0, Max: 800, Increment: 1},
+	}
+	assert.False(t, caps.SupportsERG())
+	assert.True(t, caps.SupportsResistance())
+	assert.False(t, caps.SupportsSimulation())
+	assert.Equal(t, 800.0, caps.ClampPower(1200))
+
+	// 100% maps to 20.0 trainer units, this trainer tops out at 10.0
+	assert.Equal(t, 50.0, caps.ClampResistance(80))
+	assert.Equal(t, 10.0, caps.ClampResistance(12)) // 2.4 rounds to 2.0
+}
+
+func TestMockManager_Capabilities(t *testing.T) {
+	caps := NewMockManager().Capabilities()
+	assert.True(t, caps.Known)
+	assert.True(t, caps.SupportsERG())
+	assert.True(t, caps.SupportsSimulation())
+	assert.True(t, caps.Power.IsSet())
+}
+
+func TestMockManager_StatusChannel(t *testing.T) {
+	mgr := NewMockManager()
+	require.NoError(t, mgr.Connect())
+	defer mgr.Disconnect()
+
+	mgr.SimulateStatus(MachineStatus{Event: EventPausedByUser})
+
+	select {
+	case status := <-mgr.StatusChannel():
+		assert.Equal(t, EventPausedByUser, status.Event)
+		assert.Equal(t, "Paused by user", status.Event.String())
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for status")
+	}
+}
+
+func TestMockHeartRateMonitor(t *testing.T) {
+	hr := NewMockHeartRateMonitor()
+	require.NoError(t, hr.Connect())
+	assert.True(t, hr.IsConnected())
+
+	select {
+	case data := <-hr.DataChannel():
+		assert.Greater(t, data.HeartRate, 0)
+		assert.True(t, data.Contact)
+	case <-time.After(2 * time.Second):
+		t.Fatal("timeout waiting for heart rate")
+	}
+
+	hr.Disconnect()
+	assert.False(t, hr.IsConnected())
+}
@@ -0,0 +1,261 @@
+package bluetooth
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Control Point response code and result codes
+const (
+	opResponseCode = 0x80
+
+	resultSuccess             = 0x01
+	resultNotSupported        = 0x02
+	resultInvalidParameter    = 0x03
+	resultOperationFailed     = 0x04
+	resultControlNotPermitted = 0x05
+)
+
+// Errors returned for rejected Control Point commands. ErrNotSupported is
+// used for the Op Code Not Supported result.
+var (
+	ErrInvalidParameter    = errors.New("invalid parameter")
+	ErrOperationFailed     = errors.New("operation failed")
+	ErrControlNotPermitted = errors.New("control not permitted")
+	ErrNoResponse          = errors.New("no response from trainer")
+)
+
+// controlPointTimeout is how long to wait for a command response
+const controlPointTimeout = 2 * time.Second
+
+// CommandError is returned when a Control Point command fails.
+// Err is one of the sentinel errors above.
+type CommandError struct {
+	OpCode byte
+	Err    error
+}
+
+func (e *CommandError) Error() string {
+	return opName(e.OpCode) + ": " + e.Err.Error()
+}
+
+func (e *CommandError) Unwrap() error {
+	return e.Err
+}
+
+func opName(op byte) string {
+	switch op {
+	case opRequestControl:
+		return "request control"
+	case opReset:
+		return "reset"
+	case opSetTargetResistance:
+		return "set target resistance"
+	case opSetTargetPower:
+		return "set target power"
+	case opStartOrResume:
+		return "start or resume"
+	case opStopOrPause:
+		return "stop or pause"
+	case opSetIndoorBikeSimulation:
+		return "set indoor bike simulation"
+	default:
+		return fmt.Sprintf("op code 0x%02x", op)
+	}
+}
+
+// resultError maps a response result code to an error, nil for success
+func resultError(result byte) error {
+	switch result {
+	case resultSuccess:
+		return nil
+	case resultNotSupported:
+		return ErrNotSupported
+	case resultInvalidParameter:
+		return ErrInvalidParameter
+	case resultOperationFailed:
+		return ErrOperationFailed
+	case resultControlNotPermitted:
+		return ErrControlNotPermitted
+	default:
+		return fmt.Errorf("unknown result code 0x%02x", result)
+	}
+}
+
+// ParseControlPointResponse parses a Control Point response indication
+// into the request op code and its result code
+func ParseControlPointResponse(data []byte) (op byte, result byte, err error) {
+	if len(data) < 3 {
+		return 0, 0, errors.New("data too short for control point response")
+	}
+	if data[0] != opResponseCode {
+		return 0, 0, fmt.Errorf("not a control point response: 0x%02x", data[0])
+	}
+	return data[1], data[2], nil
+}
+
+type controlPointResponse struct {
+	op     byte
+	result byte
+}
+
+// commandQueue sends Control Point commands one at a time and waits for
+// the response indication matching each command's op code
+type commandQueue struct {
+	mu        sync.Mutex // held for the duration of a command
+	write     func([]byte) error
+	timeout   time.Duration
+	responses chan controlPointResponse
+}
+
+func newCommandQueue(write func([]byte) error, timeout time.Duration) *commandQueue {
+	return &commandQueue{
+		write:     write,
+		timeout:   timeout,
+		responses: make(chan controlPointResponse, 4),
+	}
+}
+
+// HandleIndication feeds a Control Point indication to the queue
+func (q *commandQueue) HandleIndication(buf []byte) {
+	op, result, err := ParseControlPointResponse(buf)
+	if err != nil {
+		return
+	}
+	select {
+	case q.responses <- controlPointResponse{op: op, result: result}:
+	default:
+		// Nobody waiting, drop
+	}
+}
+
+// Send writes cmd and waits for its response. Returns a *CommandError if
+// the trainer rejects the command or does not answer in time.
+func (q *commandQueue) Send(cmd []byte) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	// Discard late responses to earlier commands
+	for len(q.responses) > 0 {
+		<-q.responses
+	}
+
+	op := cmd[0]
+	if err := q.write(cmd); err != nil {
+		return fmt.Errorf("%s: %w", opName(op), err)
+	}
+
+	timer := time.NewTimer(q.timeout)
+	defer timer.Stop()
+
+	for {
+		select {
+		case resp := <-q.responses:
+			if resp.op != op {
+				continue
+			}
+			if err := resultError(resp.result); err != nil {
+				return &CommandError{OpCode: op, Err: err}
+			}
+			return nil
+		case <-timer.C:
+			return &CommandError{OpCode: op, Err: ErrNoResponse}
+		}
+	}
+}
+
+// targetSender sends target commands from its own goroutine, so setting a
+// target never waits on the trainer. Targets set while a command is in
+// flight are coalesced; only the latest is sent.
+type targetSender struct {
+	send func([]byte) error
+
+	mu       sync.Mutex
+	pending  []byte // next command to send
+	inflight []byte // command being sent
+	last     []byte // last command sent successfully
+	err      error  // result of the last command sent
+	wake     chan struct{}
+}
+
+func newTargetSender(send func([]byte) error) *targetSender {
+	return &targetSender{send: send, wake: make(chan struct{}, 1)}
+}
+
+// Set queues cmd unless it repeats the target already sent or queued.
+// Returns the error of the last command sent, nil once one succeeds.
+func (t *targetSender) Set(cmd []byte) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !bytes.Equal(cmd, t.latest()) {
+		t.pending = cmd
+		t.signal()
+	}
+	return t.err
+}
+
+// latest returns the newest target queued, being sent or sent
+func (t *targetSender) latest() []byte {
+	switch {
+	case t.pending != nil:
+		return t.pending
+	case t.inflight != nil:
+		return t.inflight
+	default:
+		return t.last
+	}
+}
+
+// Resend sends the latest target again, for when the trainer has dropped it
+func (t *targetSender) Resend() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.pending == nil {
+		t.pending = t.latest()
+	}
+	t.last = nil
+	if t.pending != nil {
+		t.signal()
+	}
+}
+
+func (t *targetSender) signal() {
+	select {
+	case t.wake <- struct{}{}:
+	default:
+		// Already woken
+	}
+}
+
+// run sends queued commands until stop is closed
+func (t *targetSender) run(stop <-chan struct{}) {
+	for {
+		select {
+		case <-stop:
+			return
+		case <-t.wake:
+		}
+
+		t.mu.Lock()
+		cmd := t.pending
+		t.pending, t.inflight = nil, cmd
+		t.mu.Unlock()
+		if cmd == nil {
+			continue
+		}
+
+		err := t.send(cmd)
+
+		t.mu.Lock()
+		t.inflight, t.err = nil, err
+		if err == nil {
+			t.last = cmd
+		}
+		t.mu.Unlock()
+	}
+}
@@ -0,0 +1,178 @@
+package bluetooth
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseControlPointResponse(t *testing.T) {
+	op, result, err := ParseControlPointResponse([]byte{0x80, 0x05, 0x01})
+	require.NoError(t, err)
+	assert.Equal(t, byte(opSetTargetPower), op)
+	assert.Equal(t, byte(resultSuccess), result)
+
+	_, _, err = ParseControlPointResponse([]byte{0x80, 0x05})
+	assert.Error(t, err)
+
+	_, _, err = ParseControlPointResponse([]byte{0x05, 0x05, 0x01})
+	assert.Error(t, err)
+}
+
+// respondingQueue returns a queue whose writes are answered with the
+// given result code for the written op code
+func respondingQueue(result byte) (*commandQueue, *[][]byte) {
+	var written [][]byte
+	var q *commandQueue
+	q = newCommandQueue(func(cmd []byte) error {
+		written = append(written, cmd)
+		go q.HandleIndication([]byte{opResponseCode, cmd[0], result})
+		return nil
+	}, time.Second)
+	return q, &written
+}
+
+func TestCommandQueue_Success(t *testing.T) {
+	q, written := respondingQueue(resultSuccess)
+
+	require.NoError(t, q.Send(EncodeSetTargetPower(200)))
+	require.NoError(t, q.Send(EncodeSetTargetResistance(40)))
+	assert.Len(t, *written, 2)
+}
+
+func TestCommandQueue_Rejected(t *testing.T) {
+	tests := []struct {
+		result byte
+		want   error
+	}{
+		{resultNotSupported, ErrNotSupported},
+		{resultInvalidParameter, ErrInvalidParameter},
+		{resultOperationFailed, ErrOperationFailed},
+		{resultControlNotPermitted, ErrControlNotPermitted},
+	}
+
+	for _, tt := range tests {
+		q, _ := respondingQueue(tt.result)
+		err := q.Send(EncodeRequestControl())
+		assert.ErrorIs(t, err, tt.want)
+
+		var cmdErr *CommandError
+		require.ErrorAs(t, err, &cmdErr)
+		assert.Equal(t, byte(opRequestControl), cmdErr.OpCode)
+	}
+}
+
+func TestCommandQueue_IgnoresOtherOpCodes(t *testing.T) {
+	var q *commandQueue
+	q = newCommandQueue(func(cmd []byte) error {
+		go func() {
+			// A late response to an earlier command arrives first
+			q.HandleIndication([]byte{opResponseCode, opRequestControl, resultControlNotPermitted})
+			q.HandleIndication([]byte{opResponseCode, cmd[0], resultSuccess})
+		}()
+		return nil
+	}, time.Second)
+
+	assert.NoError(t, q.Send(EncodeSetTargetPower(150)))
+}
+
+func TestCommandQueue_Timeout(t *testing.T) {
+	q := newCommandQueue(func([]byte) error { return nil }, 20*time.Millisecond)
+
+	err := q.Send(EncodeSetTargetPower(150))
+	assert.ErrorIs(t, err, ErrNoResponse)
+}
+
+func TestCommandQueue_WriteError(t *testing.T) {
+	writeErr := errors.New("link lost")
+	q := newCommandQueue(func([]byte) error { return writeErr }, time.Second)
+
+	err := q.Send(EncodeRequestControl())
+	assert.ErrorIs(t, err, writeErr)
+}
+
+func TestTargetSender_DoesNotBlock(t *testing.T) {
+	release := make(chan struct{})
+	sent := make(chan []byte, 10)
+	ts := newTargetSender(func(cmd []byte) error {
+		<-release
+		sent <- cmd
+		return nil
+	})
+	stop := make(chan struct{})
+	defer close(stop)
+	go ts.run(stop)
+
+	// The first command is in flight while the trainer is silent
+	require.NoError(t, ts.Set(EncodeSetTargetPower(100)))
+	require.Eventually(t, func() bool {
+		ts.mu.Lock()
+		defer ts.mu.Unlock()
+		return ts.pending == nil
+	}, time.Second, time.Millisecond)
+
+	// Later targets return at once and only the latest is sent
+	done := make(chan struct{})
+	go func() {
+		for w := 110.0; w <= 150; w += 10 {
+			ts.Set(EncodeSetTargetPower(w))
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Set blocked on the trainer")
+	}
+
+	close(release)
+	assert.Equal(t, EncodeSetTargetPower(100), <-sent)
+	assert.Equal(t, EncodeSetTargetPower(150), <-sent)
+	select {
+	case cmd := <-sent:
+		t.Fatalf("unexpected command %v", cmd)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+func TestTargetSender_ErrorsAndResend(t *testing.T) {
+	sent := make(chan []byte, 10)
+	results := make(chan error)
+	ts := newTargetSender(func(cmd []byte) error {
+		sent <- cmd
+		return <-results
+	})
+	stop := make(chan struct{})
+	defer close(stop)
+	go ts.run(stop)
+
+	// A rejection is reported by the following calls
+	require.NoError(t, ts.Set(EncodeSetTargetPower(200)))
+	assert.Equal(t, EncodeSetTargetPower(200), <-sent)
+	results <- &CommandError{OpCode: opSetTargetPower, Err: ErrNotSupported}
+	require.Eventually(t, func() bool {
+		return errors.Is(ts.Set(EncodeSetTargetPower(200)), ErrNotSupported)
+	}, time.Second, time.Millisecond)
+
+	// The rejected target is retried and acknowledged
+	assert.Equal(t, EncodeSetTargetPower(200), <-sent)
+	results <- nil
+	require.Eventually(t, func() bool {
+		return ts.Set(EncodeSetTargetPower(200)) == nil
+	}, time.Second, time.Millisecond)
+
+	// Repeating the acknowledged target sends nothing
+	select {
+	case cmd := <-sent:
+		t.Fatalf("unexpected command %v", cmd)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	// Resend repeats it after the trainer dropped it
+	ts.Resend()
+	assert.Equal(t, EncodeSetTargetPower(200), <-sent)
+	results <- nil
+}
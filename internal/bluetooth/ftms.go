@@ -2,6 +2,7 @@ package bluetooth
 
 import (
 	"errors"
+	"strings"
 	"sync"
 	"time"
 
@@ -14,6 +15,16 @@ const (
 	IndoorBikeDataUUID             = "00002ad2-0000-1000-8000-00805f9b34fb"
 	FitnessMachineControlPointUUID = "00002ad9-0000-1000-8000-00805f9b34fb"
 	FitnessMachineStatusUUID       = "00002ada-0000-1000-8000-00805f9b34fb"
+	FitnessMachineFeatureUUID      = "00002acc-0000-1000-8000-00805f9b34fb"
+	SupportedResistanceRangeUUID   = "00002ad6-0000-1000-8000-00805f9b34fb"
+	SupportedPowerRangeUUID        = "00002ad8-0000-1000-8000-00805f9b34fb"
+)
+
+// Link loss detection and reconnect timing
+const (
+	DefaultSilenceTimeout = 8 * time.Second
+	reconnectMinBackoff   = 1 * time.Second
+	reconnectMaxBackoff   = 30 * time.Second
 )
 
 // FTMSManagerConfig configures the FTMS manager
@@ -22,6 +33,11 @@ type FTMSManagerConfig struct {
 	OnDeviceSelection func([]DeviceInfo) int // returns selected index, -1 to cancel
 	SavedAddress      string
 	OnSaveDevice      func(address string) // called after successful connection
+
+	// SilenceTimeout is how long the trainer may go without sending data,
+	// once it has sent some, before the link is taken as lost. 0 only
+	// relies on disconnect events.
+	SilenceTimeout time.Duration
 }
 
 // FTMSManager implements Manager using real Bluetooth
@@ -32,27 +48,35 @@ type FTMSManager struct {
 	connected      bool
 	status         ConnectionStatus
 	device         bluetooth.Device
-	controlPoint   bluetooth.DeviceCharacteristic
+	reconnecting   bool
+	lastData       time.Time // last Indoor Bike Data record, zero until one arrives
+	commands       *commandQueue
+	targets        *targetSender
 	deviceAddress  string
+	capabilities   TrainerCapabilities
 
-	dataCh  chan TrainerData
-	shiftCh chan ShiftEvent
-	stopCh  chan struct{}
+	dataCh   chan TrainerData
+	shiftCh  chan ShiftEvent
+	statusCh chan MachineStatus
+	stopCh   chan struct{}
 }
 
 // NewFTMSManager creates a new FTMS Bluetooth manager
 func NewFTMSManager() *FTMSManager {
-	return NewFTMSManagerWithConfig(FTMSManagerConfig{})
+	return NewFTMSManagerWithConfig(FTMSManagerConfig{SilenceTimeout: DefaultSilenceTimeout})
 }
 
 // NewFTMSManagerWithConfig creates a new FTMS manager with config
 func NewFTMSManagerWithConfig(config FTMSManagerConfig) *FTMSManager {
-	return &FTMSManager{
+	m := &FTMSManager{
 		config:  config,
-		dataCh:  make(chan TrainerData, 10),
-		shiftCh: make(chan ShiftEvent, 10),
-		stopCh:  make(chan struct{}),
+		dataCh:   make(chan TrainerData, 10),
+		shiftCh:  make(chan ShiftEvent, 10),
+		statusCh: make(chan MachineStatus, 10),
+		stopCh:   make(chan struct{}),
 	}
+	m.targets = newTargetSender(m.sendCommand)
+	return m
 }
 
 func (m *FTMSManager) setStatus(s ConnectionStatus) {
@@ -101,27 +125,74 @@ func (m *FTMSManager) Connect() error {
 		targetAddress = devices[selectedIdx].Address
 	}
 
-	// Connect to device
-	var addr bluetooth.Address
-	addr.Set(targetAddress)
-
-	device, err := adapter.Connect(addr, bluetooth.ConnectionParams{})
+	device, err := dial(targetAddress)
 	if err != nil {
 		// If saved address failed, clear and retry with scan
 		if m.config.SavedAddress != "" {
 			m.config.SavedAddress = ""
 			return m.Connect()
 		}
-		return errors.New("failed to connect: " + err.Error())
+		return err
 	}
 
+	m.mu.Lock()
 	m.device = device
 	m.deviceAddress = targetAddress
+	m.mu.Unlock()
+
+	if err := m.setup(device); err != nil {
+		device.Disconnect()
+		return err
+	}
+
+	m.mu.Lock()
+	m.connected = true
+	m.mu.Unlock()
+
+	m.setStatus(StatusConnected)
+
+	// Save device for next time
+	if m.config.OnSaveDevice != nil {
+		m.config.OnSaveDevice(targetAddress)
+	}
+
+	// Watch for link loss. The adapter has one connect handler for the
+	// whole process and this is the only place that sets it; sensors share
+	// the adapter, so events for other devices are ignored.
+	adapter.SetConnectHandler(func(device bluetooth.Device, connected bool) {
+		m.mu.Lock()
+		ours := strings.EqualFold(device.Address.String(), m.deviceAddress)
+		m.mu.Unlock()
+		if !connected && ours {
+			m.linkLost()
+		}
+	})
+	if m.config.SilenceTimeout > 0 {
+		go m.monitorConnection()
+	}
+	go m.targets.run(m.stopCh)
+
+	return nil
+}
+
+// dial opens a connection to the device at address
+func dial(address string) (bluetooth.Device, error) {
+	var addr bluetooth.Address
+	addr.Set(address)
+
+	device, err := adapter.Connect(addr, bluetooth.ConnectionParams{})
+	if err != nil {
+		return bluetooth.Device{}, errors.New("failed to connect: " + err.Error())
+	}
+	return device, nil
+}
 
+// setup discovers the FTMS characteristics of a connected device,
+// subscribes to them and requests control. Used on connect and reconnect.
+func (m *FTMSManager) setup(device bluetooth.Device) error {
 	// Discover services
 	services, err := device.DiscoverServices(nil)
 	if err != nil {
-		device.Disconnect()
 		return errors.New("failed to discover services: " + err.Error())
 	}
 
@@ -136,38 +207,62 @@ func (m *FTMSManager) Connect() error {
 		}
 	}
 	if !found {
-		device.Disconnect()
 		return errors.New("FTMS service not found")
 	}
 
-	// Discover characteristics
-	chars, err := ftmsService.DiscoverCharacteristics([]bluetooth.UUID{
-		mustParseUUID(IndoorBikeDataUUID),
-		mustParseUUID(FitnessMachineControlPointUUID),
-	})
+	// Discover characteristics. Only Indoor Bike Data is required,
+	// so discover all and pick what we need.
+	chars, err := ftmsService.DiscoverCharacteristics(nil)
 	if err != nil {
-		device.Disconnect()
 		return errors.New("failed to discover characteristics: " + err.Error())
 	}
 
 	var indoorBikeData, controlPoint bluetooth.DeviceCharacteristic
+	var hasBikeData, hasControlPoint bool
+	byUUID := make(map[string]bluetooth.DeviceCharacteristic)
 	for _, c := range chars {
-		uuid := c.UUID().String()
-		if uuid == IndoorBikeDataUUID {
+		switch c.UUID().String() {
+		case IndoorBikeDataUUID:
 			indoorBikeData = c
-		} else if uuid == FitnessMachineControlPointUUID {
+			hasBikeData = true
+		case FitnessMachineControlPointUUID:
 			controlPoint = c
+			hasControlPoint = true
+		default:
+			byUUID[c.UUID().String()] = c
 		}
 	}
+	if !hasBikeData {
+		return errors.New("indoor bike data characteristic not found")
+	}
+	if !hasControlPoint {
+		return errors.New("control point characteristic not found")
+	}
 
-	m.controlPoint = controlPoint
+	caps := readCapabilities(byUUID)
+	m.mu.Lock()
+	m.capabilities = caps
+	m.mu.Unlock()
 
-	// Subscribe to Indoor Bike Data notifications
+	// Subscribe to Indoor Bike Data notifications. Records split across
+	// several packets are merged until the final packet arrives.
+	var pending TrainerData
 	err = indoorBikeData.EnableNotifications(func(buf []byte) {
-		data, err := ParseIndoorBikeData(buf)
+		part, err := ParseIndoorBikeData(buf)
 		if err != nil {
 			return
 		}
+		pending = pending.Merge(part)
+		if pending.MoreData {
+			return
+		}
+		data := pending
+		pending = TrainerData{}
+
+		m.mu.Lock()
+		m.lastData = time.Now()
+		m.mu.Unlock()
+
 		select {
 		case m.dataCh <- data:
 		default:
@@ -175,36 +270,93 @@ func (m *FTMSManager) Connect() error {
 		}
 	})
 	if err != nil {
-		device.Disconnect()
 		return errors.New("failed to enable notifications: " + err.Error())
 	}
 
-	// Request control
-	_, err = controlPoint.WriteWithoutResponse(EncodeRequestControl())
+	// Status events are optional, trainers without them still work
+	if status, ok := byUUID[FitnessMachineStatusUUID]; ok {
+		status.EnableNotifications(m.handleStatus)
+	}
+
+	// Command responses arrive as Control Point indications
+	commands := newCommandQueue(func(cmd []byte) error {
+		return writeCommand(controlPoint, cmd)
+	}, controlPointTimeout)
+	err = controlPoint.EnableNotifications(commands.HandleIndication)
 	if err != nil {
-		// Non-fatal, some trainers don't require this
+		return errors.New("failed to enable control point indications: " + err.Error())
+	}
+
+	// Request control. Some trainers never indicate a response; only an
+	// explicit rejection is a refusal.
+	if err := commands.Send(EncodeRequestControl()); err != nil && !errors.Is(err, ErrNoResponse) {
+		return errors.New("trainer refused control: " + err.Error())
 	}
 
 	m.mu.Lock()
-	m.connected = true
+	m.commands = commands
+	m.lastData = time.Time{}
 	m.mu.Unlock()
 
-	m.setStatus(StatusConnected)
+	return nil
+}
 
-	// Save device for next time
-	if m.config.OnSaveDevice != nil {
-		m.config.OnSaveDevice(targetAddress)
+func (m *FTMSManager) handleStatus(buf []byte) {
+	status, err := ParseMachineStatus(buf)
+	if err != nil {
+		return
+	}
+
+	// The trainer dropped our targets, resend them
+	if status.Event == EventReset || status.Event == EventControlLost {
+		m.targets.Resend()
 	}
 
-	// Start disconnect monitor
-	go m.monitorConnection()
+	select {
+	case m.statusCh <- status:
+	default:
+		// Channel full, drop
+	}
+}
 
-	return nil
+// readCapabilities reads the feature and supported range characteristics.
+// Missing or unreadable characteristics leave the matching fields unset.
+func readCapabilities(chars map[string]bluetooth.DeviceCharacteristic) TrainerCapabilities {
+	var caps TrainerCapabilities
+	buf := make([]byte, 20)
+
+	if c, ok := chars[FitnessMachineFeatureUUID]; ok {
+		if n, err := c.Read(buf); err == nil {
+			if machine, target, err := ParseFitnessMachineFeature(buf[:n]); err == nil {
+				caps.Known = true
+				caps.MachineFeatures = machine
+				caps.TargetFeatures = target
+			}
+		}
+	}
+	if c, ok := chars[SupportedResistanceRangeUUID]; ok {
+		if n, err := c.Read(buf); err == nil {
+			if r, err := ParseSupportedRange(buf[:n], 0.1); err == nil {
+				caps.Resistance = r
+			}
+		}
+	}
+	if c, ok := chars[SupportedPowerRangeUUID]; ok {
+		if n, err := c.Read(buf); err == nil {
+			if r, err := ParseSupportedRange(buf[:n], 1); err == nil {
+				caps.Power = r
+			}
+		}
+	}
+
+	return caps
 }
 
+// monitorConnection watches for notification silence, which is how link
+// loss shows up on platforms without disconnect callbacks. It is armed
+// once data has arrived, so trainers that only send while ridden aren't
+// reconnected before the rider starts.
 func (m *FTMSManager) monitorConnection() {
-	// tinygo bluetooth doesn't have disconnect callbacks yet
-	// Poll connection status
 	ticker := time.NewTicker(1 * time.Second)
 	defer ticker.Stop()
 
@@ -213,21 +365,91 @@ func (m *FTMSManager) monitorConnection() {
 		case <-m.stopCh:
 			return
 		case <-ticker.C:
-			// Check if we can still communicate
-			// If not, trigger reconnection
+			m.mu.Lock()
+			silent := m.connected && !m.lastData.IsZero() &&
+				time.Since(m.lastData) > m.config.SilenceTimeout
+			m.mu.Unlock()
+			if silent {
+				m.linkLost()
+			}
+		}
+	}
+}
+
+// linkLost starts reconnecting unless already doing so
+func (m *FTMSManager) linkLost() {
+	m.mu.Lock()
+	if !m.connected || m.reconnecting {
+		m.mu.Unlock()
+		return
+	}
+	m.connected = false
+	m.reconnecting = true
+	m.mu.Unlock()
+
+	m.setStatus(StatusReconnecting)
+	go m.reconnect()
+}
+
+// reconnect retries the saved device with exponential backoff until it
+// succeeds or Disconnect is called, then re-applies the last target
+func (m *FTMSManager) reconnect() {
+	m.mu.Lock()
+	old, address := m.device, m.deviceAddress
+	m.mu.Unlock()
+	old.Disconnect()
+
+	backoff := reconnectMinBackoff
+	for {
+		select {
+		case <-m.stopCh:
+			return
+		case <-time.After(backoff):
+		}
+
+		device, err := dial(address)
+		if err == nil {
+			if err = m.setup(device); err != nil {
+				device.Disconnect()
+			}
+		}
+		if err != nil {
+			backoff = min(backoff*2, reconnectMaxBackoff)
+			continue
 		}
+
+		m.mu.Lock()
+		select {
+		case <-m.stopCh:
+			// Disconnect was called while connecting
+			m.mu.Unlock()
+			device.Disconnect()
+			return
+		default:
+		}
+		m.device = device
+		m.connected = true
+		m.reconnecting = false
+		m.mu.Unlock()
+
+		m.targets.Resend()
+
+		m.setStatus(StatusConnected)
+		return
 	}
 }
 
 func (m *FTMSManager) Disconnect() {
 	m.mu.Lock()
-	wasConnected := m.connected
+	wasActive := m.connected || m.reconnecting
 	m.connected = false
+	m.reconnecting = false
+	device := m.device
 	m.mu.Unlock()
 
-	if wasConnected {
+	if wasActive {
 		close(m.stopCh)
-		m.device.Disconnect()
+		device.Disconnect()
 	}
 
 	m.setStatus(StatusDisconnected)
@@ -247,26 +469,77 @@ func (m *FTMSManager) ShiftChannel() <-chan ShiftEvent {
 	return m.shiftCh
 }
 
+func (m *FTMSManager) StatusChannel() <-chan MachineStatus {
+	return m.statusCh
+}
+
+// Capabilities returns the features read from the trainer on connect
+func (m *FTMSManager) Capabilities() TrainerCapabilities {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.capabilities
+}
+
 func (m *FTMSManager) SetResistance(level float64) error {
 	if !m.IsConnected() {
 		return errors.New("not connected")
 	}
-	_, err := m.controlPoint.WriteWithoutResponse(EncodeSetTargetResistance(level))
-	return err
+	caps := m.Capabilities()
+	if !caps.SupportsResistance() {
+		return ErrNotSupported
+	}
+	return m.sendTarget(EncodeSetTargetResistance(caps.ClampResistance(level)))
 }
 
 func (m *FTMSManager) SetTargetPower(watts float64) error {
 	if !m.IsConnected() {
 		return errors.New("not connected")
 	}
-	_, err := m.controlPoint.WriteWithoutResponse(EncodeSetTargetPower(watts))
+	caps := m.Capabilities()
+	if !caps.SupportsERG() {
+		return ErrNotSupported
+	}
+	return m.sendTarget(EncodeSetTargetPower(caps.ClampPower(watts)))
+}
+
+func (m *FTMSManager) SetSimulationParameters(params SimulationParameters) error {
+	if !m.IsConnected() {
+		return errors.New("not connected")
+	}
+	if !m.Capabilities().SupportsSimulation() {
+		return ErrNotSupported
+	}
+	return m.sendTarget(EncodeSetSimulationParameters(params))
+}
+
+// sendTarget queues a target setting command, sent in the background.
+// Returns the error of the last target command sent.
+func (m *FTMSManager) sendTarget(cmd []byte) error {
+	return m.targets.Set(cmd)
+}
+
+// sendCommand sends a target command and waits for its response. A
+// trainer that doesn't indicate one is taken to have applied it.
+func (m *FTMSManager) sendCommand(cmd []byte) error {
+	m.mu.Lock()
+	commands := m.commands
+	m.mu.Unlock()
+
+	err := commands.Send(cmd)
+	if errors.Is(err, ErrNoResponse) {
+		return nil
+	}
 	return err
 }
 
-func mustParseUUID(s string) bluetooth.UUID {
-	uuid, err := bluetooth.ParseUUID(s)
-	if err != nil {
-		panic("invalid UUID: " + s)
+// writeCommand writes to the control point with a write request where the
+// platform offers one. BlueZ picks a request itself when the
+// characteristic supports it.
+func writeCommand(c bluetooth.DeviceCharacteristic, cmd []byte) error {
+	if w, ok := any(c).(interface{ Write([]byte) (int, error) }); ok {
+		_, err := w.Write(cmd)
+		return err
 	}
-	return uuid
+	_, err := c.WriteWithoutResponse(cmd)
+	return err
 }
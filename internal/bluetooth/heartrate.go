@@ -0,0 +1,124 @@
+package bluetooth
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"tinygo.org/x/bluetooth"
+)
+
+// Heart Rate Service UUIDs
+const (
+	HeartRateServiceUUID     = "0000180d-0000-1000-8000-00805f9b34fb"
+	HeartRateMeasurementUUID = "00002a37-0000-1000-8000-00805f9b34fb"
+)
+
+// HeartRateData is a reading from a heart rate monitor
+type HeartRateData struct {
+	HeartRate        int  // bpm
+	Contact          bool // false when the strap reports no skin contact
+	ContactSupported bool
+	EnergyExpended   int // kJ since the monitor was reset, 0 if not reported
+	RRIntervals      []time.Duration
+}
+
+// HeartRateMonitor provides heart rate from a separate sensor
+type HeartRateMonitor interface {
+	// Connect connects to the monitor
+	Connect() error
+
+	// Disconnect closes the connection
+	Disconnect()
+
+	// IsConnected returns true if the monitor is connected
+	IsConnected() bool
+
+	// DataChannel returns channel for heart rate readings
+	DataChannel() <-chan HeartRateData
+}
+
+// HeartRateManager implements HeartRateMonitor using the BLE Heart Rate Service
+type HeartRateManager struct {
+	address string
+
+	mu        sync.Mutex
+	connected bool
+	device    bluetooth.Device
+
+	dataCh chan HeartRateData
+}
+
+// NewHeartRateManager creates a manager for the monitor at address
+func NewHeartRateManager(address string) *HeartRateManager {
+	return &HeartRateManager{
+		address: address,
+		dataCh:  make(chan HeartRateData, 10),
+	}
+}
+
+// ScanHeartRateMonitors discovers heart rate monitors for the given duration
+func ScanHeartRateMonitors(timeout time.Duration) ([]DeviceInfo, error) {
+	return NewScanner().ScanService(HeartRateServiceUUID, "Unknown Monitor", timeout)
+}
+
+func (m *HeartRateManager) Connect() error {
+	if m.address == "" {
+		return errors.New("no heart rate monitor configured")
+	}
+
+	device, chars, err := connectSensor(m.address, HeartRateServiceUUID)
+	if err != nil {
+		return errors.New("heart rate monitor: " + err.Error())
+	}
+
+	measurement, ok := chars[HeartRateMeasurementUUID]
+	if !ok {
+		device.Disconnect()
+		return errors.New("heart rate measurement characteristic not found")
+	}
+
+	err = measurement.EnableNotifications(func(buf []byte) {
+		data, err := ParseHeartRateMeasurement(buf)
+		if err != nil {
+			return
+		}
+		select {
+		case m.dataCh <- data:
+		default:
+			// Channel full, drop
+		}
+	})
+	if err != nil {
+		device.Disconnect()
+		return errors.New("failed to enable notifications: " + err.Error())
+	}
+
+	m.mu.Lock()
+	m.device = device
+	m.connected = true
+	m.mu.Unlock()
+
+	return nil
+}
+
+func (m *HeartRateManager) Disconnect() {
+	m.mu.Lock()
+	wasConnected := m.connected
+	m.connected = false
+	m.mu.Unlock()
+
+	if wasConnected {
+		m.device.Disconnect()
+	}
+}
+
+func (m *HeartRateManager) IsConnected() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.connected
+}
+
+func (m *HeartRateManager) DataChannel() <-chan HeartRateData {
+	return m.dataCh
+}
@@ -10,9 +10,11 @@ type MockManager struct {
 	connected   bool
 	dataCh      chan TrainerData
 	shiftCh     chan ShiftEvent
+	statusCh    chan MachineStatus
 	stopCh      chan struct{}
 	resistance  float64
 	targetPower float64
+	grade       float64
 }
 
 // NewMockManager creates a mock Bluetooth manager
@@ -20,6 +22,7 @@ func NewMockManager() *MockManager {
 	return &MockManager{
 		dataCh:     make(chan TrainerData, 10),
 		shiftCh:    make(chan ShiftEvent, 10),
+		statusCh:   make(chan MachineStatus, 10),
 		stopCh:     make(chan struct{}),
 		resistance: 20,
 	}
@@ -50,6 +53,10 @@ func (m *MockManager) ShiftChannel() <-chan ShiftEvent {
 	return m.shiftCh
 }
 
+func (m *MockManager) StatusChannel() <-chan MachineStatus {
+	return m.statusCh
+}
+
 func (m *MockManager) SetResistance(level float64) error {
 	m.resistance = level
 	return nil
@@ -60,6 +67,20 @@ func (m *MockManager) SetTargetPower(watts float64) error {
 	return nil
 }
 
+func (m *MockManager) SetSimulationParameters(params SimulationParameters) error {
+	m.grade = params.Grade
+	return nil
+}
+
+func (m *MockManager) Capabilities() TrainerCapabilities {
+	return TrainerCapabilities{
+		Known:          true,
+		TargetFeatures: TargetResistance | TargetPower | TargetIndoorBikeSimulation,
+		Resistance:     ValueRange{Min: 0, Max: 20, Increment: 0.1},
+		Power:          ValueRange{Min: 0, Max: 2000, Increment: 1},
+	}
+}
+
 // SimulateShift simulates a shift button press (for testing)
 func (m *MockManager) SimulateShift(event ShiftEvent) {
 	if m.connected {
@@ -67,6 +88,13 @@ func (m *MockManager) SimulateShift(event ShiftEvent) {
 	}
 }
 
+// SimulateStatus simulates a trainer status event (for testing)
+func (m *MockManager) SimulateStatus(status MachineStatus) {
+	if m.connected {
+		m.statusCh <- status
+	}
+}
+
 func (m *MockManager) generateData() {
 	ticker := time.NewTicker(250 * time.Millisecond)
 	defer ticker.Stop()
@@ -80,7 +108,7 @@ func (m *MockManager) generateData() {
 			return
 		case <-ticker.C:
 			// Generate realistic-ish data with some variation
-			power := basePower + (m.resistance-20)*2 + (rand.Float64()-0.5)*20
+			power := basePower + (m.resistance-20)*2 + m.grade*10 + (rand.Float64()-0.5)*20
 			cadence := baseCadence + (rand.Float64()-0.5)*10
 
 			if m.targetPower > 0 {
@@ -89,7 +117,126 @@ func (m *MockManager) generateData() {
 			}
 
 			select {
-			case m.dataCh <- TrainerData{Power: power, Cadence: cadence}:
+			case m.dataCh <- TrainerData{
+				Present: FieldPower | FieldCadence,
+				Power:   power,
+				Cadence: cadence,
+			}:
+			default:
+				// Channel full, skip
+			}
+		}
+	}
+}
+
+// MockHeartRateMonitor simulates a heart rate strap for development
+type MockHeartRateMonitor struct {
+	connected bool
+	dataCh    chan HeartRateData
+	stopCh    chan struct{}
+}
+
+// NewMockHeartRateMonitor creates a mock heart rate monitor
+func NewMockHeartRateMonitor() *MockHeartRateMonitor {
+	return &MockHeartRateMonitor{
+		dataCh: make(chan HeartRateData, 10),
+		stopCh: make(chan struct{}),
+	}
+}
+
+func (m *MockHeartRateMonitor) Connect() error {
+	m.connected = true
+	go m.generateData()
+	return nil
+}
+
+func (m *MockHeartRateMonitor) Disconnect() {
+	if m.connected {
+		close(m.stopCh)
+		m.connected = false
+	}
+}
+
+func (m *MockHeartRateMonitor) IsConnected() bool {
+	return m.connected
+}
+
+func (m *MockHeartRateMonitor) DataChannel() <-chan HeartRateData {
+	return m.dataCh
+}
+
+func (m *MockHeartRateMonitor) generateData() {
+	ticker := time.NewTicker(1 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.stopCh:
+			return
+		case <-ticker.C:
+			select {
+			case m.dataCh <- HeartRateData{
+				HeartRate: 130 + rand.Intn(10),
+				Contact:   true,
+			}:
+			default:
+				// Channel full, skip
+			}
+		}
+	}
+}
+
+// MockSensor simulates a power meter with crank data for development
+type MockSensor struct {
+	connected bool
+	dataCh    chan SensorData
+	stopCh    chan struct{}
+}
+
+// NewMockSensor creates a mock power and cadence sensor
+func NewMockSensor() *MockSensor {
+	return &MockSensor{
+		dataCh: make(chan SensorData, 10),
+		stopCh: make(chan struct{}),
+	}
+}
+
+func (m *MockSensor) Connect() error {
+	m.connected = true
+	go m.generateData()
+	return nil
+}
+
+func (m *MockSensor) Disconnect() {
+	if m.connected {
+		close(m.stopCh)
+		m.connected = false
+	}
+}
+
+func (m *MockSensor) IsConnected() bool {
+	return m.connected
+}
+
+func (m *MockSensor) DataChannel() <-chan SensorData {
+	return m.dataCh
+}
+
+func (m *MockSensor) generateData() {
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.stopCh:
+			return
+		case <-ticker.C:
+			select {
+			case m.dataCh <- SensorData{
+				Present: FieldPower | FieldCadence,
+				Power:   160 + (rand.Float64()-0.5)*20,
+				Cadence: 88 + (rand.Float64()-0.5)*6,
+			}:
 			default:
 				// Channel full, skip
 			}
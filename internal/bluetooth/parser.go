@@ -3,79 +3,530 @@ package bluetooth
 import (
 	"encoding/binary"
 	"errors"
+	"math"
+	"time"
 )
 
 // FTMS Indoor Bike Data flags
 const (
-	flagMoreData           uint16 = 1 << 0
-	flagAverageSpeed       uint16 = 1 << 1
-	flagInstCadence        uint16 = 1 << 2
-	flagAvgCadence         uint16 = 1 << 3
-	flagTotalDistance      uint16 = 1 << 4
-	flagResistanceLevel    uint16 = 1 << 5
-	flagInstPower          uint16 = 1 << 6
-	flagAvgPower           uint16 = 1 << 7
-	flagExpendedEnergy     uint16 = 1 << 8
-	flagHeartRate          uint16 = 1 << 9
-	flagMetabolicEquiv     uint16 = 1 << 10
-	flagElapsedTime        uint16 = 1 << 11
-	flagRemainingTime      uint16 = 1 << 12
+	flagMoreData        uint16 = 1 << 0
+	flagAverageSpeed    uint16 = 1 << 1
+	flagInstCadence     uint16 = 1 << 2
+	flagAvgCadence      uint16 = 1 << 3
+	flagTotalDistance   uint16 = 1 << 4
+	flagResistanceLevel uint16 = 1 << 5
+	flagInstPower       uint16 = 1 << 6
+	flagAvgPower        uint16 = 1 << 7
+	flagExpendedEnergy  uint16 = 1 << 8
+	flagHeartRate       uint16 = 1 << 9
+	flagMetabolicEquiv  uint16 = 1 << 10
+	flagElapsedTime     uint16 = 1 << 11
+	flagRemainingTime   uint16 = 1 << 12
 )
 
-// ParseIndoorBikeData parses FTMS Indoor Bike Data characteristic
+// fieldReader reads little-endian FTMS fields sequentially
+type fieldReader struct {
+	data   []byte
+	offset int
+}
+
+func (r *fieldReader) take(n int, name string) ([]byte, error) {
+	if len(r.data) < r.offset+n {
+		return nil, errors.New("data too short for " + name)
+	}
+	b := r.data[r.offset : r.offset+n]
+	r.offset += n
+	return b, nil
+}
+
+func (r *fieldReader) uint8(name string) (uint8, error) {
+	b, err := r.take(1, name)
+	if err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
+
+func (r *fieldReader) uint16(name string) (uint16, error) {
+	b, err := r.take(2, name)
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint16(b), nil
+}
+
+func (r *fieldReader) sint16(name string) (int16, error) {
+	v, err := r.uint16(name)
+	return int16(v), err
+}
+
+func (r *fieldReader) uint24(name string) (uint32, error) {
+	b, err := r.take(3, name)
+	if err != nil {
+		return 0, err
+	}
+	return uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16, nil
+}
+
+// ParseIndoorBikeData parses FTMS Indoor Bike Data characteristic.
+// A single notification may carry only part of a record; MoreData is set
+// on all but the last packet and the parts can be combined with Merge.
 func ParseIndoorBikeData(data []byte) (TrainerData, error) {
 	if len(data) < 2 {
 		return TrainerData{}, errors.New("data too short for flags")
 	}
 
 	flags := binary.LittleEndian.Uint16(data[0:2])
-	offset := 2
+	r := &fieldReader{data: data, offset: 2}
 
 	var result TrainerData
 
-	// Instantaneous Speed is always present (uint16, 0.01 km/h resolution)
-	if len(data) < offset+2 {
-		return TrainerData{}, errors.New("data too short for speed")
+	// Instantaneous Speed (uint16, 0.01 km/h resolution). The More Data
+	// bit is inverted: the field is present only when the bit is clear.
+	if flags&flagMoreData == 0 {
+		v, err := r.uint16("speed")
+		if err != nil {
+			return TrainerData{}, err
+		}
+		result.Speed = float64(v) * 0.01
+		result.Present |= FieldSpeed
+	} else {
+		result.MoreData = true
 	}
-	// speed := float64(binary.LittleEndian.Uint16(data[offset:offset+2])) * 0.01
-	offset += 2
 
-	// Average Speed (optional)
+	// Average Speed (uint16, 0.01 km/h resolution)
 	if flags&flagAverageSpeed != 0 {
-		offset += 2
+		v, err := r.uint16("average speed")
+		if err != nil {
+			return TrainerData{}, err
+		}
+		result.AvgSpeed = float64(v) * 0.01
+		result.Present |= FieldAvgSpeed
 	}
 
-	// Instantaneous Cadence (optional, uint16, 0.5 rpm resolution)
+	// Instantaneous Cadence (uint16, 0.5 rpm resolution)
 	if flags&flagInstCadence != 0 {
-		if len(data) < offset+2 {
-			return TrainerData{}, errors.New("data too short for cadence")
+		v, err := r.uint16("cadence")
+		if err != nil {
+			return TrainerData{}, err
 		}
-		result.Cadence = float64(binary.LittleEndian.Uint16(data[offset:offset+2])) * 0.5
-		offset += 2
+		result.Cadence = float64(v) * 0.5
+		result.Present |= FieldCadence
 	}
 
-	// Average Cadence (optional)
+	// Average Cadence (uint16, 0.5 rpm resolution)
 	if flags&flagAvgCadence != 0 {
-		offset += 2
+		v, err := r.uint16("average cadence")
+		if err != nil {
+			return TrainerData{}, err
+		}
+		result.AvgCadence = float64(v) * 0.5
+		result.Present |= FieldAvgCadence
 	}
 
-	// Total Distance (optional, uint24)
+	// Total Distance (uint24, 1 m resolution)
 	if flags&flagTotalDistance != 0 {
-		offset += 3
+		v, err := r.uint24("total distance")
+		if err != nil {
+			return TrainerData{}, err
+		}
+		result.TotalDistance = float64(v)
+		result.Present |= FieldTotalDistance
 	}
 
-	// Resistance Level (optional)
+	// Resistance Level (sint16, unitless)
 	if flags&flagResistanceLevel != 0 {
-		offset += 2
+		v, err := r.sint16("resistance level")
+		if err != nil {
+			return TrainerData{}, err
+		}
+		result.ResistanceLevel = float64(v)
+		result.Present |= FieldResistanceLevel
 	}
 
-	// Instantaneous Power (optional, sint16, 1W resolution)
+	// Instantaneous Power (sint16, 1 W resolution)
 	if flags&flagInstPower != 0 {
-		if len(data) < offset+2 {
-			return TrainerData{}, errors.New("data too short for power")
+		v, err := r.sint16("power")
+		if err != nil {
+			return TrainerData{}, err
+		}
+		result.Power = float64(v)
+		result.Present |= FieldPower
+	}
+
+	// Average Power (sint16, 1 W resolution)
+	if flags&flagAvgPower != 0 {
+		v, err := r.sint16("average power")
+		if err != nil {
+			return TrainerData{}, err
+		}
+		result.AvgPower = float64(v)
+		result.Present |= FieldAvgPower
+	}
+
+	// Expended Energy: total (uint16 kcal), per hour (uint16 kcal),
+	// per minute (uint8 kcal)
+	if flags&flagExpendedEnergy != 0 {
+		total, err := r.uint16("total energy")
+		if err != nil {
+			return TrainerData{}, err
+		}
+		perHour, err := r.uint16("energy per hour")
+		if err != nil {
+			return TrainerData{}, err
+		}
+		perMinute, err := r.uint8("energy per minute")
+		if err != nil {
+			return TrainerData{}, err
+		}
+		result.TotalEnergy = int(total)
+		result.EnergyPerHour = int(perHour)
+		result.EnergyPerMinute = int(perMinute)
+		result.Present |= FieldExpendedEnergy
+	}
+
+	// Heart Rate (uint8, bpm)
+	if flags&flagHeartRate != 0 {
+		v, err := r.uint8("heart rate")
+		if err != nil {
+			return TrainerData{}, err
+		}
+		result.HeartRate = int(v)
+		result.Present |= FieldHeartRate
+	}
+
+	// Metabolic Equivalent (uint8, 0.1 resolution)
+	if flags&flagMetabolicEquiv != 0 {
+		v, err := r.uint8("metabolic equivalent")
+		if err != nil {
+			return TrainerData{}, err
+		}
+		result.MET = float64(v) * 0.1
+		result.Present |= FieldMET
+	}
+
+	// Elapsed Time (uint16, seconds)
+	if flags&flagElapsedTime != 0 {
+		v, err := r.uint16("elapsed time")
+		if err != nil {
+			return TrainerData{}, err
 		}
-		result.Power = float64(int16(binary.LittleEndian.Uint16(data[offset : offset+2])))
-		offset += 2
+		result.ElapsedTime = int(v)
+		result.Present |= FieldElapsedTime
+	}
+
+	// Remaining Time (uint16, seconds)
+	if flags&flagRemainingTime != 0 {
+		v, err := r.uint16("remaining time")
+		if err != nil {
+			return TrainerData{}, err
+		}
+		result.RemainingTime = int(v)
+		result.Present |= FieldRemainingTime
+	}
+
+	return result, nil
+}
+
+// ParseFitnessMachineFeature parses the Fitness Machine Feature
+// characteristic into its feature and target setting bit fields
+func ParseFitnessMachineFeature(data []byte) (machine, target uint32, err error) {
+	if len(data) < 8 {
+		return 0, 0, errors.New("data too short for fitness machine feature")
+	}
+	return binary.LittleEndian.Uint32(data[0:4]), binary.LittleEndian.Uint32(data[4:8]), nil
+}
+
+// ParseSupportedRange parses a Supported ... Range characteristic: minimum
+// and maximum as sint16, increment as uint16, all in units of resolution
+func ParseSupportedRange(data []byte, resolution float64) (ValueRange, error) {
+	r := &fieldReader{data: data}
+	min, err := r.sint16("minimum")
+	if err != nil {
+		return ValueRange{}, err
+	}
+	max, err := r.sint16("maximum")
+	if err != nil {
+		return ValueRange{}, err
+	}
+	inc, err := r.uint16("increment")
+	if err != nil {
+		return ValueRange{}, err
+	}
+	if max < min {
+		return ValueRange{}, errors.New("invalid range")
+	}
+	return ValueRange{
+		Min:       float64(min) * resolution,
+		Max:       float64(max) * resolution,
+		Increment: float64(inc) * resolution,
+	}, nil
+}
+
+// Fitness Machine Status op codes
+const (
+	statusReset                   = 0x01
+	statusStoppedOrPausedByUser   = 0x02
+	statusStoppedBySafetyKey      = 0x03
+	statusStartedOrResumedByUser  = 0x04
+	statusTargetResistanceChanged = 0x07
+	statusTargetPowerChanged      = 0x08
+	statusSimulationChanged       = 0x12
+	statusSpinDown                = 0x14
+	statusControlPermissionLost   = 0xFF
+)
+
+// ParseMachineStatus parses a Fitness Machine Status notification.
+// Op codes without a matching event are returned as EventUnknown.
+func ParseMachineStatus(data []byte) (MachineStatus, error) {
+	if len(data) < 1 {
+		return MachineStatus{}, errors.New("data too short for status op code")
+	}
+
+	status := MachineStatus{OpCode: data[0]}
+	r := &fieldReader{data: data, offset: 1}
+
+	switch data[0] {
+	case statusReset:
+		status.Event = EventReset
+
+	case statusStoppedOrPausedByUser:
+		v, err := r.uint8("stop or pause parameter")
+		if err != nil {
+			return MachineStatus{}, err
+		}
+		status.Event = EventStoppedByUser
+		if v == 0x02 {
+			status.Event = EventPausedByUser
+		}
+
+	case statusStoppedBySafetyKey:
+		status.Event = EventStoppedBySafetyKey
+
+	case statusStartedOrResumedByUser:
+		status.Event = EventStartedByUser
+
+	case statusTargetResistanceChanged:
+		v, err := r.uint8("target resistance")
+		if err != nil {
+			return MachineStatus{}, err
+		}
+		status.Event = EventTargetResistanceChanged
+		status.Value = float64(v) * 0.1
+
+	case statusTargetPowerChanged:
+		v, err := r.sint16("target power")
+		if err != nil {
+			return MachineStatus{}, err
+		}
+		status.Event = EventTargetPowerChanged
+		status.Value = float64(v)
+
+	case statusSimulationChanged:
+		wind, err := r.sint16("wind speed")
+		if err != nil {
+			return MachineStatus{}, err
+		}
+		grade, err := r.sint16("grade")
+		if err != nil {
+			return MachineStatus{}, err
+		}
+		crr, err := r.uint8("crr")
+		if err != nil {
+			return MachineStatus{}, err
+		}
+		cw, err := r.uint8("cw")
+		if err != nil {
+			return MachineStatus{}, err
+		}
+		status.Event = EventSimulationChanged
+		status.Simulation = SimulationParameters{
+			WindSpeed: float64(wind) * 0.001,
+			Grade:     float64(grade) * 0.01,
+			Crr:       float64(crr) * 0.0001,
+			CW:        float64(cw) * 0.01,
+		}
+
+	case statusSpinDown:
+		v, err := r.uint8("spin down status")
+		if err != nil {
+			return MachineStatus{}, err
+		}
+		status.Event = EventSpinDown
+		status.Value = float64(v)
+
+	case statusControlPermissionLost:
+		status.Event = EventControlLost
+	}
+
+	return status, nil
+}
+
+// Heart Rate Measurement flags
+const (
+	hrFlagUint16         = 1 << 0
+	hrFlagContact        = 1 << 1
+	hrFlagContactSupport = 1 << 2
+	hrFlagEnergy         = 1 << 3
+	hrFlagRRIntervals    = 1 << 4
+)
+
+// ParseHeartRateMeasurement parses the Heart Rate Measurement
+// characteristic of the Heart Rate Service
+func ParseHeartRateMeasurement(data []byte) (HeartRateData, error) {
+	r := &fieldReader{data: data}
+
+	flags, err := r.uint8("flags")
+	if err != nil {
+		return HeartRateData{}, err
+	}
+
+	var result HeartRateData
+
+	if flags&hrFlagUint16 != 0 {
+		v, err := r.uint16("heart rate")
+		if err != nil {
+			return HeartRateData{}, err
+		}
+		result.HeartRate = int(v)
+	} else {
+		v, err := r.uint8("heart rate")
+		if err != nil {
+			return HeartRateData{}, err
+		}
+		result.HeartRate = int(v)
+	}
+
+	// Without contact detection support, assume contact
+	result.ContactSupported = flags&hrFlagContactSupport != 0
+	result.Contact = !result.ContactSupported || flags&hrFlagContact != 0
+
+	if flags&hrFlagEnergy != 0 {
+		v, err := r.uint16("energy expended")
+		if err != nil {
+			return HeartRateData{}, err
+		}
+		result.EnergyExpended = int(v)
+	}
+
+	// RR intervals fill the rest of the packet (uint16, 1/1024 s)
+	if flags&hrFlagRRIntervals != 0 {
+		for r.offset+2 <= len(data) {
+			v, _ := r.uint16("rr interval")
+			result.RRIntervals = append(result.RRIntervals, time.Duration(v)*time.Second/1024)
+		}
+	}
+
+	return result, nil
+}
+
+// Cycling Power Measurement flags
+const (
+	cpsFlagPedalBalance uint16 = 1 << 0
+	cpsFlagAccumTorque  uint16 = 1 << 2
+	cpsFlagWheelRevs    uint16 = 1 << 4
+	cpsFlagCrankRevs    uint16 = 1 << 5
+)
+
+// ParseCyclingPowerMeasurement parses the Cycling Power Measurement
+// characteristic. Only power and revolution data are kept.
+func ParseCyclingPowerMeasurement(data []byte) (CyclingPowerData, error) {
+	r := &fieldReader{data: data}
+
+	flags, err := r.uint16("flags")
+	if err != nil {
+		return CyclingPowerData{}, err
+	}
+
+	power, err := r.sint16("instantaneous power")
+	if err != nil {
+		return CyclingPowerData{}, err
+	}
+	result := CyclingPowerData{Power: float64(power)}
+
+	if flags&cpsFlagPedalBalance != 0 {
+		if _, err := r.take(1, "pedal power balance"); err != nil {
+			return CyclingPowerData{}, err
+		}
+	}
+	if flags&cpsFlagAccumTorque != 0 {
+		if _, err := r.take(2, "accumulated torque"); err != nil {
+			return CyclingPowerData{}, err
+		}
+	}
+
+	// Wheel revolutions (uint32), last wheel event time (uint16, 1/2048 s)
+	if flags&cpsFlagWheelRevs != 0 {
+		b, err := r.take(4, "wheel revolutions")
+		if err != nil {
+			return CyclingPowerData{}, err
+		}
+		t, err := r.uint16("last wheel event time")
+		if err != nil {
+			return CyclingPowerData{}, err
+		}
+		result.Wheel = RevolutionData{Present: true, Revs: binary.LittleEndian.Uint32(b), EventTime: t}
+	}
+
+	// Crank revolutions (uint16), last crank event time (uint16, 1/1024 s)
+	if flags&cpsFlagCrankRevs != 0 {
+		revs, err := r.uint16("crank revolutions")
+		if err != nil {
+			return CyclingPowerData{}, err
+		}
+		t, err := r.uint16("last crank event time")
+		if err != nil {
+			return CyclingPowerData{}, err
+		}
+		result.Crank = RevolutionData{Present: true, Revs: uint32(revs), EventTime: t}
+	}
+
+	// Extreme magnitudes, dead spot angles and energy follow; not used
+	return result, nil
+}
+
+// CSC Measurement flags
+const (
+	cscFlagWheelRevs = 1 << 0
+	cscFlagCrankRevs = 1 << 1
+)
+
+// ParseCSCMeasurement parses the CSC Measurement characteristic of the
+// Cycling Speed and Cadence service
+func ParseCSCMeasurement(data []byte) (CSCData, error) {
+	r := &fieldReader{data: data}
+
+	flags, err := r.uint8("flags")
+	if err != nil {
+		return CSCData{}, err
+	}
+
+	var result CSCData
+
+	// Wheel revolutions (uint32), last wheel event time (uint16, 1/1024 s)
+	if flags&cscFlagWheelRevs != 0 {
+		b, err := r.take(4, "wheel revolutions")
+		if err != nil {
+			return CSCData{}, err
+		}
+		t, err := r.uint16("last wheel event time")
+		if err != nil {
+			return CSCData{}, err
+		}
+		result.Wheel = RevolutionData{Present: true, Revs: binary.LittleEndian.Uint32(b), EventTime: t}
+	}
+
+	// Crank revolutions (uint16), last crank event time (uint16, 1/1024 s)
+	if flags&cscFlagCrankRevs != 0 {
+		revs, err := r.uint16("crank revolutions")
+		if err != nil {
+			return CSCData{}, err
+		}
+		t, err := r.uint16("last crank event time")
+		if err != nil {
+			return CSCData{}, err
+		}
+		result.Crank = RevolutionData{Present: true, Revs: uint32(revs), EventTime: t}
 	}
 
 	return result, nil
@@ -83,12 +534,13 @@ func ParseIndoorBikeData(data []byte) (TrainerData, error) {
 
 // Control Point opcodes
 const (
-	opRequestControl       = 0x00
-	opReset                = 0x01
-	opSetTargetResistance  = 0x04
-	opSetTargetPower       = 0x05
-	opStartOrResume        = 0x07
-	opStopOrPause          = 0x08
+	opRequestControl          = 0x00
+	opReset                   = 0x01
+	opSetTargetResistance     = 0x04
+	opSetTargetPower          = 0x05
+	opStartOrResume           = 0x07
+	opStopOrPause             = 0x08
+	opSetIndoorBikeSimulation = 0x11
 )
 
 // EncodeRequestControl creates a Request Control command
@@ -118,3 +570,23 @@ func EncodeSetTargetPower(watts float64) []byte {
 		byte((w >> 8) & 0xFF),
 	}
 }
+
+// EncodeSetSimulationParameters creates a Set Indoor Bike Simulation
+// Parameters command. Values are clamped to the field ranges.
+func EncodeSetSimulationParameters(p SimulationParameters) []byte {
+	wind := int16(clamp(p.WindSpeed*1000, math.MinInt16, math.MaxInt16)) // 0.001 m/s
+	grade := int16(clamp(p.Grade*100, math.MinInt16, math.MaxInt16))     // 0.01 %
+	crr := uint8(clamp(p.Crr*10000, 0, math.MaxUint8))                   // 0.0001
+	cw := uint8(clamp(p.CW*100, 0, math.MaxUint8))                       // 0.01 kg/m
+	return []byte{
+		opSetIndoorBikeSimulation,
+		byte(wind), byte(uint16(wind) >> 8),
+		byte(grade), byte(uint16(grade) >> 8),
+		crr,
+		cw,
+	}
+}
+
+func clamp(v, min, max float64) float64 {
+	return math.Round(math.Max(min, math.Min(max, v)))
+}
@@ -2,8 +2,10 @@ package bluetooth
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestParseIndoorBikeData_PowerAndCadence(t *testing.T) {
@@ -47,6 +49,215 @@ func TestParseIndoorBikeData_TooShort(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestParseIndoorBikeData_Fields(t *testing.T) {
+	tests := []struct {
+		name    string
+		flags   uint16
+		payload []byte
+		field   TrainerField
+		check   func(t *testing.T, d TrainerData)
+	}{
+		{
+			name:    "average speed",
+			flags:   flagAverageSpeed,
+			payload: []byte{0xC4, 0x09}, // 2500 -> 25.00 km/h
+			field:   FieldAvgSpeed,
+			check:   func(t *testing.T, d TrainerData) { assert.InDelta(t, 25.0, d.AvgSpeed, 0.001) },
+		},
+		{
+			name:    "instantaneous cadence",
+			flags:   flagInstCadence,
+			payload: []byte{0xAB, 0x00}, // 171 -> 85.5 rpm
+			field:   FieldCadence,
+			check:   func(t *testing.T, d TrainerData) { assert.InDelta(t, 85.5, d.Cadence, 0.001) },
+		},
+		{
+			name:    "average cadence",
+			flags:   flagAvgCadence,
+			payload: []byte{0xA0, 0x00}, // 160 -> 80 rpm
+			field:   FieldAvgCadence,
+			check:   func(t *testing.T, d TrainerData) { assert.InDelta(t, 80.0, d.AvgCadence, 0.001) },
+		},
+		{
+			name:    "total distance",
+			flags:   flagTotalDistance,
+			payload: []byte{0x40, 0xE2, 0x01}, // 123456 m
+			field:   FieldTotalDistance,
+			check:   func(t *testing.T, d TrainerData) { assert.Equal(t, 123456.0, d.TotalDistance) },
+		},
+		{
+			name:    "resistance level",
+			flags:   flagResistanceLevel,
+			payload: []byte{0xF6, 0xFF}, // -10
+			field:   FieldResistanceLevel,
+			check:   func(t *testing.T, d TrainerData) { assert.Equal(t, -10.0, d.ResistanceLevel) },
+		},
+		{
+			name:    "instantaneous power",
+			flags:   flagInstPower,
+			payload: []byte{0x2C, 0x01}, // 300 W
+			field:   FieldPower,
+			check:   func(t *testing.T, d TrainerData) { assert.Equal(t, 300.0, d.Power) },
+		},
+		{
+			name:    "average power",
+			flags:   flagAvgPower,
+			payload: []byte{0xFA, 0x00}, // 250 W
+			field:   FieldAvgPower,
+			check:   func(t *testing.T, d TrainerData) { assert.Equal(t, 250.0, d.AvgPower) },
+		},
+		{
+			name:    "expended energy",
+			flags:   flagExpendedEnergy,
+			payload: []byte{0x64, 0x00, 0x58, 0x02, 0x0A}, // 100 kcal, 600 kcal/h, 10 kcal/min
+			field:   FieldExpendedEnergy,
+			check: func(t *testing.T, d TrainerData) {
+				assert.Equal(t, 100, d.TotalEnergy)
+				assert.Equal(t, 600, d.EnergyPerHour)
+				assert.Equal(t, 10, d.EnergyPerMinute)
+			},
+		},
+		{
+			name:    "heart rate",
+			flags:   flagHeartRate,
+			payload: []byte{0x8C}, // 140 bpm
+			field:   FieldHeartRate,
+			check:   func(t *testing.T, d TrainerData) { assert.Equal(t, 140, d.HeartRate) },
+		},
+		{
+			name:    "metabolic equivalent",
+			flags:   flagMetabolicEquiv,
+			payload: []byte{0x55}, // 8.5
+			field:   FieldMET,
+			check:   func(t *testing.T, d TrainerData) { assert.InDelta(t, 8.5, d.MET, 0.001) },
+		},
+		{
+			name:    "elapsed time",
+			flags:   flagElapsedTime,
+			payload: []byte{0x10, 0x0E}, // 3600 s
+			field:   FieldElapsedTime,
+			check:   func(t *testing.T, d TrainerData) { assert.Equal(t, 3600, d.ElapsedTime) },
+		},
+		{
+			name:    "remaining time",
+			flags:   flagRemainingTime,
+			payload: []byte{0x2C, 0x01}, // 300 s
+			field:   FieldRemainingTime,
+			check:   func(t *testing.T, d TrainerData) { assert.Equal(t, 300, d.RemainingTime) },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Speed is present (More Data clear) and precedes all optional fields
+			data := []byte{byte(tt.flags), byte(tt.flags >> 8), 0xE8, 0x03}
+			data = append(data, tt.payload...)
+
+			result, err := ParseIndoorBikeData(data)
+			require.NoError(t, err)
+			assert.InDelta(t, 10.0, result.Speed, 0.001)
+			assert.Equal(t, FieldSpeed|tt.field, result.Present)
+			tt.check(t, result)
+
+			// Truncating the field must be reported, not misread
+			_, err = ParseIndoorBikeData(data[:len(data)-1])
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestParseIndoorBikeData_AllFields(t *testing.T) {
+	data := []byte{
+		0xFE, 0x1F, // all optional fields, More Data clear
+		0xE8, 0x03, // speed 10.00 km/h
+		0xD0, 0x07, // avg speed 20.00 km/h
+		0xB4, 0x00, // cadence 90 rpm
+		0xA0, 0x00, // avg cadence 80 rpm
+		0xE8, 0x03, 0x00, // distance 1000 m
+		0x14, 0x00, // resistance 20
+		0xC8, 0x00, // power 200 W
+		0x96, 0x00, // avg power 150 W
+		0x32, 0x00, 0x2C, 0x01, 0x05, // energy 50 kcal, 300 kcal/h, 5 kcal/min
+		0x78,       // HR 120
+		0x40,       // MET 6.4
+		0x3C, 0x00, // elapsed 60 s
+		0x78, 0x00, // remaining 120 s
+	}
+
+	result, err := ParseIndoorBikeData(data)
+	require.NoError(t, err)
+
+	assert.False(t, result.MoreData)
+	assert.InDelta(t, 10.0, result.Speed, 0.001)
+	assert.InDelta(t, 20.0, result.AvgSpeed, 0.001)
+	assert.InDelta(t, 90.0, result.Cadence, 0.001)
+	assert.InDelta(t, 80.0, result.AvgCadence, 0.001)
+	assert.Equal(t, 1000.0, result.TotalDistance)
+	assert.Equal(t, 20.0, result.ResistanceLevel)
+	assert.Equal(t, 200.0, result.Power)
+	assert.Equal(t, 150.0, result.AvgPower)
+	assert.Equal(t, 50, result.TotalEnergy)
+	assert.Equal(t, 300, result.EnergyPerHour)
+	assert.Equal(t, 5, result.EnergyPerMinute)
+	assert.Equal(t, 120, result.HeartRate)
+	assert.InDelta(t, 6.4, result.MET, 0.001)
+	assert.Equal(t, 60, result.ElapsedTime)
+	assert.Equal(t, 120, result.RemainingTime)
+
+	for f := FieldSpeed; f <= FieldRemainingTime; f <<= 1 {
+		assert.True(t, result.Has(f), "field %d", f)
+	}
+}
+
+func TestParseIndoorBikeData_MoreDataOmitsSpeed(t *testing.T) {
+	// More Data set: speed is absent, the next field starts right after the flags
+	data := []byte{
+		0x41, 0x00, // More Data + power
+		0xC8, 0x00, // power 200 W
+	}
+
+	result, err := ParseIndoorBikeData(data)
+	require.NoError(t, err)
+
+	assert.True(t, result.MoreData)
+	assert.False(t, result.Has(FieldSpeed))
+	assert.Equal(t, 200.0, result.Power)
+}
+
+func TestParseIndoorBikeData_MultiPacket(t *testing.T) {
+	packets := [][]byte{
+		{0x45, 0x00, 0xB4, 0x00, 0xC8, 0x00},       // More Data, cadence 90, power 200
+		{0x01, 0x02, 0x8C},                         // More Data, HR 140
+		{0x10, 0x00, 0xC4, 0x09, 0x10, 0x27, 0x00}, // speed 25 km/h, distance 10000 m
+	}
+
+	var merged TrainerData
+	for i, p := range packets {
+		part, err := ParseIndoorBikeData(p)
+		require.NoError(t, err)
+		merged = merged.Merge(part)
+		assert.Equal(t, i < len(packets)-1, merged.MoreData, "packet %d", i)
+	}
+
+	assert.Equal(t, 200.0, merged.Power)
+	assert.InDelta(t, 90.0, merged.Cadence, 0.001)
+	assert.Equal(t, 140, merged.HeartRate)
+	assert.InDelta(t, 25.0, merged.Speed, 0.001)
+	assert.Equal(t, 10000.0, merged.TotalDistance)
+	assert.Equal(t, FieldSpeed|FieldCadence|FieldPower|FieldHeartRate|FieldTotalDistance, merged.Present)
+}
+
+func TestTrainerData_MergeKeepsAbsentFields(t *testing.T) {
+	base := TrainerData{Present: FieldPower | FieldCadence, Power: 200, Cadence: 90}
+	update := TrainerData{Present: FieldPower, Power: 210}
+
+	merged := base.Merge(update)
+
+	assert.Equal(t, 210.0, merged.Power)
+	assert.Equal(t, 90.0, merged.Cadence)
+	assert.True(t, merged.Has(FieldCadence))
+}
+
 func TestEncodeRequestControl(t *testing.T) {
 	data := EncodeRequestControl()
 	assert.Equal(t, []byte{0x00}, data)
@@ -64,3 +275,204 @@ func TestEncodeSetTargetPower(t *testing.T) {
 	data := EncodeSetTargetPower(200)
 	assert.Equal(t, []byte{0x05, 0xC8, 0x00}, data)
 }
+
+func TestEncodeSetSimulationParameters(t *testing.T) {
+	data := EncodeSetSimulationParameters(SimulationParameters{
+		WindSpeed: -1.5, // tailwind
+		Grade:     5.25,
+		Crr:       0.004,
+		CW:        0.51,
+	})
+
+	assert.Equal(t, []byte{
+		0x11,       // opcode
+		0x24, 0xFA, // wind -1500 (0.001 m/s)
+		0x0D, 0x02, // grade 525 (0.01 %)
+		0x28, // Crr 40 (0.0001)
+		0x33, // CW 51 (0.01 kg/m)
+	}, data)
+}
+
+func TestEncodeSetSimulationParameters_Clamped(t *testing.T) {
+	data := EncodeSetSimulationParameters(SimulationParameters{
+		Grade: -400, // beyond sint16 at 0.01 % resolution
+		Crr:   1,
+		CW:    -1,
+	})
+
+	assert.Equal(t, byte(0x00), data[3])
+	assert.Equal(t, byte(0x80), data[4]) // -32768
+	assert.Equal(t, byte(0xFF), data[5])
+	assert.Equal(t, byte(0x00), data[6])
+}
+
+func TestParseFitnessMachineFeature(t *testing.T) {
+	// Cadence + power measurement; resistance, power and simulation targets
+	data := []byte{0x02, 0x40, 0x00, 0x00, 0x0C, 0x20, 0x00, 0x00}
+
+	machine, target, err := ParseFitnessMachineFeature(data)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(0x4002), machine)
+	assert.Equal(t, TargetResistance|TargetPower|TargetIndoorBikeSimulation, target)
+
+	_, _, err = ParseFitnessMachineFeature(data[:7])
+	assert.Error(t, err)
+}
+
+func TestParseSupportedRange(t *testing.T) {
+	// Power: 0-2000 W in 5 W steps
+	r, err := ParseSupportedRange([]byte{0x00, 0x00, 0xD0, 0x07, 0x05, 0x00}, 1)
+	require.NoError(t, err)
+	assert.Equal(t, ValueRange{Min: 0, Max: 2000, Increment: 5}, r)
+
+	// Resistance: -10.0 to 20.0 in 1.0 steps
+	r, err = ParseSupportedRange([]byte{0x9C, 0xFF, 0xC8, 0x00, 0x0A, 0x00}, 0.1)
+	require.NoError(t, err)
+	assert.InDelta(t, -10.0, r.Min, 1e-9)
+	assert.InDelta(t, 20.0, r.Max, 1e-9)
+	assert.InDelta(t, 1.0, r.Increment, 1e-9)
+
+	_, err = ParseSupportedRange([]byte{0x00, 0x00, 0xD0, 0x07}, 1)
+	assert.Error(t, err)
+
+	// Maximum below minimum
+	_, err = ParseSupportedRange([]byte{0x0A, 0x00, 0x05, 0x00, 0x01, 0x00}, 1)
+	assert.Error(t, err)
+}
+
+func TestParseMachineStatus(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  []byte
+		event MachineEvent
+		value float64
+	}{
+		{"reset", []byte{0x01}, EventReset, 0},
+		{"stopped by user", []byte{0x02, 0x01}, EventStoppedByUser, 0},
+		{"paused by user", []byte{0x02, 0x02}, EventPausedByUser, 0},
+		{"safety key", []byte{0x03}, EventStoppedBySafetyKey, 0},
+		{"started by user", []byte{0x04}, EventStartedByUser, 0},
+		{"target resistance", []byte{0x07, 0x64}, EventTargetResistanceChanged, 10},
+		{"target power", []byte{0x08, 0xFA, 0x00}, EventTargetPowerChanged, 250},
+		{"spin down", []byte{0x14, 0x02}, EventSpinDown, SpinDownSuccess},
+		{"control lost", []byte{0xFF}, EventControlLost, 0},
+		{"unhandled", []byte{0x05, 0x10, 0x0E}, EventUnknown, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, err := ParseMachineStatus(tt.data)
+			require.NoError(t, err)
+			assert.Equal(t, tt.event, status.Event)
+			assert.Equal(t, tt.data[0], status.OpCode)
+			assert.InDelta(t, tt.value, status.Value, 1e-9)
+		})
+	}
+}
+
+func TestParseMachineStatus_Simulation(t *testing.T) {
+	status, err := ParseMachineStatus([]byte{0x12, 0xE8, 0x03, 0x2C, 0x01, 0x28, 0x33})
+	require.NoError(t, err)
+	assert.Equal(t, EventSimulationChanged, status.Event)
+	assert.InDelta(t, 1.0, status.Simulation.WindSpeed, 1e-9)
+	assert.InDelta(t, 3.0, status.Simulation.Grade, 1e-9)
+	assert.InDelta(t, 0.004, status.Simulation.Crr, 1e-9)
+	assert.InDelta(t, 0.51, status.Simulation.CW, 1e-9)
+}
+
+func TestParseMachineStatus_Truncated(t *testing.T) {
+	for _, data := range [][]byte{
+		{},
+		{0x02},
+		{0x08, 0xFA},
+		{0x12, 0xE8, 0x03, 0x2C, 0x01, 0x28},
+	} {
+		_, err := ParseMachineStatus(data)
+		assert.Error(t, err, "%x", data)
+	}
+}
+
+func TestParseHeartRateMeasurement(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		hr      int
+		contact bool
+		energy  int
+		rr      []time.Duration
+	}{
+		{"uint8", []byte{0x00, 0x48}, 72, true, 0, nil},
+		{"uint16", []byte{0x01, 0x2C, 0x01}, 300, true, 0, nil},
+		{"no contact", []byte{0x04, 0x50}, 80, false, 0, nil},
+		{"contact", []byte{0x06, 0x50}, 80, true, 0, nil},
+		{"energy", []byte{0x08, 0x8C, 0xE8, 0x03}, 140, true, 1000, nil},
+		{"rr intervals", []byte{0x10, 0x8C, 0x00, 0x04, 0x00, 0x02}, 140, true, 0,
+			[]time.Duration{time.Second, 500 * time.Millisecond}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := ParseHeartRateMeasurement(tt.data)
+			require.NoError(t, err)
+			assert.Equal(t, tt.hr, data.HeartRate)
+			assert.Equal(t, tt.contact, data.Contact)
+			assert.Equal(t, tt.energy, data.EnergyExpended)
+			assert.Equal(t, tt.rr, data.RRIntervals)
+		})
+	}
+}
+
+func TestParseHeartRateMeasurement_Truncated(t *testing.T) {
+	for _, data := range [][]byte{{}, {0x00}, {0x01, 0x2C}, {0x08, 0x8C, 0xE8}} {
+		_, err := ParseHeartRateMeasurement(data)
+		assert.Error(t, err, "%x", data)
+	}
+}
+
+func TestParseCyclingPowerMeasurement(t *testing.T) {
+	// Flags: pedal balance, wheel and crank revolutions; power 250 W
+	data := []byte{
+		0x31, 0x00,
+		0xFA, 0x00,
+		0x32,                   // pedal balance, skipped
+		0x10, 0x27, 0x00, 0x00, // wheel revs 10000
+		0x00, 0x08, // wheel event time 2048
+		0x2A, 0x00, // crank revs 42
+		0x00, 0x04, // crank event time 1024
+	}
+
+	m, err := ParseCyclingPowerMeasurement(data)
+	require.NoError(t, err)
+	assert.Equal(t, 250.0, m.Power)
+	assert.Equal(t, RevolutionData{Present: true, Revs: 10000, EventTime: 2048}, m.Wheel)
+	assert.Equal(t, RevolutionData{Present: true, Revs: 42, EventTime: 1024}, m.Crank)
+
+	// Power only
+	m, err = ParseCyclingPowerMeasurement([]byte{0x00, 0x00, 0x96, 0x00})
+	require.NoError(t, err)
+	assert.Equal(t, 150.0, m.Power)
+	assert.False(t, m.Crank.Present)
+
+	for _, data := range [][]byte{{0x00}, {0x00, 0x00, 0x96}, {0x20, 0x00, 0x96, 0x00, 0x2A, 0x00}} {
+		_, err := ParseCyclingPowerMeasurement(data)
+		assert.Error(t, err, "%x", data)
+	}
+}
+
+func TestParseCSCMeasurement(t *testing.T) {
+	m, err := ParseCSCMeasurement([]byte{0x03, 0x10, 0x27, 0x00, 0x00, 0x00, 0x08, 0x2A, 0x00, 0x00, 0x04})
+	require.NoError(t, err)
+	assert.Equal(t, RevolutionData{Present: true, Revs: 10000, EventTime: 2048}, m.Wheel)
+	assert.Equal(t, RevolutionData{Present: true, Revs: 42, EventTime: 1024}, m.Crank)
+
+	// Crank only
+	m, err = ParseCSCMeasurement([]byte{0x02, 0x2A, 0x00, 0x00, 0x04})
+	require.NoError(t, err)
+	assert.False(t, m.Wheel.Present)
+	assert.Equal(t, uint32(42), m.Crank.Revs)
+
+	for _, data := range [][]byte{{}, {0x02, 0x2A, 0x00}, {0x01, 0x10, 0x27}} {
+		_, err := ParseCSCMeasurement(data)
+		assert.Error(t, err, "%x", data)
+	}
+}
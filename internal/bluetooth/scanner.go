@@ -25,6 +25,12 @@ func NewScanner() *Scanner {
 
 // Scan discovers FTMS devices for the given duration
 func (s *Scanner) Scan(timeout time.Duration) ([]DeviceInfo, error) {
+	return s.ScanService(FTMSServiceUUID, "Unknown Trainer", timeout)
+}
+
+// ScanService discovers devices advertising the given service UUID.
+// Devices without a name are listed as unnamed.
+func (s *Scanner) ScanService(service, unnamed string, timeout time.Duration) ([]DeviceInfo, error) {
 	if err := adapter.Enable(); err != nil {
 		return nil, errors.New("failed to enable Bluetooth adapter: " + err.Error())
 	}
@@ -36,16 +42,16 @@ func (s *Scanner) Scan(timeout time.Duration) ([]DeviceInfo, error) {
 
 	go func() {
 		err := adapter.Scan(func(adapter *bluetooth.Adapter, result bluetooth.ScanResult) {
-			// Check if device advertises FTMS service
-			hasFTMS := false
+			// Check if device advertises the service
+			hasService := false
 			for _, uuid := range result.AdvertisementPayload.ServiceUUIDs() {
-				if uuid.String() == FTMSServiceUUID {
-					hasFTMS = true
+				if uuid.String() == service {
+					hasService = true
 					break
 				}
 			}
 
-			if !hasFTMS {
+			if !hasService {
 				return
 			}
 
@@ -57,7 +63,7 @@ func (s *Scanner) Scan(timeout time.Duration) ([]DeviceInfo, error) {
 
 			name := result.LocalName()
 			if name == "" {
-				name = "Unknown Trainer"
+				name = unnamed
 			}
 
 			s.devices = append(s.devices, DeviceInfo{
@@ -0,0 +1,331 @@
+package bluetooth
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"tinygo.org/x/bluetooth"
+)
+
+// Cycling Power and Cycling Speed and Cadence UUIDs
+const (
+	CyclingPowerServiceUUID     = "00001818-0000-1000-8000-00805f9b34fb"
+	CyclingPowerMeasurementUUID = "00002a63-0000-1000-8000-00805f9b34fb"
+	CSCServiceUUID              = "00001816-0000-1000-8000-00805f9b34fb"
+	CSCMeasurementUUID          = "00002a5b-0000-1000-8000-00805f9b34fb"
+)
+
+// Power and cadence sources for a ride
+const (
+	SourceTrainer       = "trainer"
+	SourcePowerMeter    = "power_meter"
+	SourceCadenceSensor = "cadence_sensor"
+)
+
+// RevolutionData is a cumulative revolution count with the time of the
+// last revolution event
+type RevolutionData struct {
+	Present   bool
+	Revs      uint32
+	EventTime uint16 // 1/1024 s, 1/2048 s for Cycling Power wheel data
+}
+
+// CyclingPowerData is a Cycling Power Measurement
+type CyclingPowerData struct {
+	Power float64 // W
+	Crank RevolutionData
+	Wheel RevolutionData
+}
+
+// CSCData is a CSC Measurement
+type CSCData struct {
+	Crank RevolutionData
+	Wheel RevolutionData
+}
+
+// SensorData is a reading from a standalone power meter or cadence
+// sensor. Present marks FieldPower and FieldCadence.
+type SensorData struct {
+	Present TrainerField
+	Power   float64 // W
+	Cadence float64 // rpm
+}
+
+// Has reports whether the sensor reported field f
+func (d SensorData) Has(f TrainerField) bool {
+	return d.Present&f != 0
+}
+
+// Sensor provides power and/or cadence from a device other than the trainer
+type Sensor interface {
+	// Connect connects to the sensor
+	Connect() error
+
+	// Disconnect closes the connection
+	Disconnect()
+
+	// IsConnected returns true if the sensor is connected
+	IsConnected() bool
+
+	// DataChannel returns channel for sensor readings
+	DataChannel() <-chan SensorData
+}
+
+// revolutionStopTimeout is how long without a new revolution before the
+// rate drops to zero
+const revolutionStopTimeout = 3 * time.Second
+
+// revolutionRate derives revolutions per minute from cumulative counts
+// and event times. Both counters roll over.
+type revolutionRate struct {
+	revBits        uint // width of the revolution counter
+	ticksPerSecond float64
+
+	started   bool
+	last      RevolutionData
+	lastEvent time.Time // when the count last changed
+	rpm       float64
+}
+
+func newRevolutionRate(revBits uint, ticksPerSecond float64) *revolutionRate {
+	return &revolutionRate{revBits: revBits, ticksPerSecond: ticksPerSecond}
+}
+
+// Update feeds a measurement and returns the current rate in rpm
+func (r *revolutionRate) Update(d RevolutionData, now time.Time) float64 {
+	if !r.started {
+		r.started = true
+		r.last = d
+		r.lastEvent = now
+		return 0
+	}
+
+	// Shifting a uint32 by 32 gives 0, so a 32 bit mask is all ones
+	mask := uint32(1)<<r.revBits - 1
+	revs := (d.Revs - r.last.Revs) & mask
+	ticks := d.EventTime - r.last.EventTime
+
+	switch {
+	case revs > 0 && ticks > 0:
+		r.rpm = float64(revs) / (float64(ticks) / r.ticksPerSecond) * 60
+		r.last = d
+		r.lastEvent = now
+	case now.Sub(r.lastEvent) > revolutionStopTimeout:
+		r.rpm = 0
+	}
+	return r.rpm
+}
+
+// SensorManager implements Sensor for a BLE power meter or cadence sensor
+type SensorManager struct {
+	address     string
+	service     string
+	measurement string
+	parse       func(buf []byte, now time.Time) (SensorData, error)
+
+	mu        sync.Mutex
+	connected bool
+	device    bluetooth.Device
+
+	dataCh chan SensorData
+}
+
+// NewPowerMeterManager creates a manager for the Cycling Power Service
+// device at address. Cadence is derived from crank data when reported.
+func NewPowerMeterManager(address string) *SensorManager {
+	cadence := newRevolutionRate(16, 1024)
+	return &SensorManager{
+		address:     address,
+		service:     CyclingPowerServiceUUID,
+		measurement: CyclingPowerMeasurementUUID,
+		parse: func(buf []byte, now time.Time) (SensorData, error) {
+			m, err := ParseCyclingPowerMeasurement(buf)
+			if err != nil {
+				return SensorData{}, err
+			}
+			d := SensorData{Present: FieldPower, Power: m.Power}
+			if m.Crank.Present {
+				d.Present |= FieldCadence
+				d.Cadence = cadence.Update(m.Crank, now)
+			}
+			return d, nil
+		},
+		dataCh: make(chan SensorData, 10),
+	}
+}
+
+// NewCadenceSensorManager creates a manager for the Cycling Speed and
+// Cadence device at address
+func NewCadenceSensorManager(address string) *SensorManager {
+	cadence := newRevolutionRate(16, 1024)
+	return &SensorManager{
+		address:     address,
+		service:     CSCServiceUUID,
+		measurement: CSCMeasurementUUID,
+		parse: func(buf []byte, now time.Time) (SensorData, error) {
+			m, err := ParseCSCMeasurement(buf)
+			if err != nil {
+				return SensorData{}, err
+			}
+			if !m.Crank.Present {
+				return SensorData{}, nil // speed-only sensor
+			}
+			return SensorData{Present: FieldCadence, Cadence: cadence.Update(m.Crank, now)}, nil
+		},
+		dataCh: make(chan SensorData, 10),
+	}
+}
+
+// ScanPowerMeters discovers Cycling Power devices for the given duration
+func ScanPowerMeters(timeout time.Duration) ([]DeviceInfo, error) {
+	return NewScanner().ScanService(CyclingPowerServiceUUID, "Unknown Power Meter", timeout)
+}
+
+// ScanCadenceSensors discovers Cycling Speed and Cadence devices for the
+// given duration
+func ScanCadenceSensors(timeout time.Duration) ([]DeviceInfo, error) {
+	return NewScanner().ScanService(CSCServiceUUID, "Unknown Sensor", timeout)
+}
+
+func (m *SensorManager) Connect() error {
+	if m.address == "" {
+		return errors.New("no sensor configured")
+	}
+
+	device, chars, err := connectSensor(m.address, m.service)
+	if err != nil {
+		return err
+	}
+
+	measurement, ok := chars[m.measurement]
+	if !ok {
+		device.Disconnect()
+		return errors.New("measurement characteristic not found")
+	}
+
+	err = measurement.EnableNotifications(func(buf []byte) {
+		data, err := m.parse(buf, time.Now())
+		if err != nil || data.Present == 0 {
+			return
+		}
+		select {
+		case m.dataCh <- data:
+		default:
+			// Channel full, drop
+		}
+	})
+	if err != nil {
+		device.Disconnect()
+		return errors.New("failed to enable notifications: " + err.Error())
+	}
+
+	m.mu.Lock()
+	m.device = device
+	m.connected = true
+	m.mu.Unlock()
+
+	return nil
+}
+
+func (m *SensorManager) Disconnect() {
+	m.mu.Lock()
+	wasConnected := m.connected
+	m.connected = false
+	m.mu.Unlock()
+
+	if wasConnected {
+		m.device.Disconnect()
+	}
+}
+
+func (m *SensorManager) IsConnected() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.connected
+}
+
+func (m *SensorManager) DataChannel() <-chan SensorData {
+	return m.dataCh
+}
+
+// NewSourceSensors returns the sensors providing power and cadence for a
+// ride, nil where the trainer provides the value. A power meter that also
+// supplies cadence is shared. With mock set, mock sensors are returned.
+func NewSourceSensors(powerSource, cadenceSource, powerMeterAddress, cadenceSensorAddress string, mock bool) (power, cadence Sensor, err error) {
+	var powerMeter, cadenceSensor Sensor
+	if mock {
+		powerMeter = NewMockSensor()
+		cadenceSensor = powerMeter
+	} else {
+		powerMeter = NewPowerMeterManager(powerMeterAddress)
+		cadenceSensor = NewCadenceSensorManager(cadenceSensorAddress)
+	}
+
+	switch powerSource {
+	case SourceTrainer, "":
+	case SourcePowerMeter:
+		if !mock && powerMeterAddress == "" {
+			return nil, nil, errors.New("power source is the power meter, but no power meter is set")
+		}
+		power = powerMeter
+	default:
+		return nil, nil, errors.New("invalid power source: " + powerSource)
+	}
+
+	switch cadenceSource {
+	case SourceTrainer, "":
+	case SourcePowerMeter:
+		if !mock && powerMeterAddress == "" {
+			return nil, nil, errors.New("cadence source is the power meter, but no power meter is set")
+		}
+		cadence = powerMeter
+	case SourceCadenceSensor:
+		if !mock && cadenceSensorAddress == "" {
+			return nil, nil, errors.New("cadence source is the cadence sensor, but no cadence sensor is set")
+		}
+		cadence = cadenceSensor
+	default:
+		return nil, nil, errors.New("invalid cadence source: " + cadenceSource)
+	}
+
+	return power, cadence, nil
+}
+
+// connectSensor connects to the device at address and returns the
+// characteristics of the given service keyed by UUID
+func connectSensor(address, service string) (bluetooth.Device, map[string]bluetooth.DeviceCharacteristic, error) {
+	if err := adapter.Enable(); err != nil {
+		return bluetooth.Device{}, nil, errors.New("failed to enable Bluetooth: " + err.Error())
+	}
+
+	device, err := dial(address)
+	if err != nil {
+		return bluetooth.Device{}, nil, err
+	}
+
+	services, err := device.DiscoverServices(nil)
+	if err != nil {
+		device.Disconnect()
+		return bluetooth.Device{}, nil, errors.New("failed to discover services: " + err.Error())
+	}
+
+	for _, svc := range services {
+		if svc.UUID().String() != service {
+			continue
+		}
+		chars, err := svc.DiscoverCharacteristics(nil)
+		if err != nil {
+			device.Disconnect()
+			return bluetooth.Device{}, nil, errors.New("failed to discover characteristics: " + err.Error())
+		}
+		byUUID := make(map[string]bluetooth.DeviceCharacteristic)
+		for _, c := range chars {
+			byUUID[c.UUID().String()] = c
+		}
+		return device, byUUID, nil
+	}
+
+	device.Disconnect()
+	return bluetooth.Device{}, nil, errors.New("service not found")
+}
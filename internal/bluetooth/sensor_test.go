@@ -0,0 +1,71 @@
+package bluetooth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRevolutionRate(t *testing.T) {
+	r := newRevolutionRate(16, 1024)
+	now := time.Now()
+
+	assert.Equal(t, 0.0, r.Update(RevolutionData{Revs: 10, EventTime: 1000}, now))
+
+	// 3 revolutions in 2 seconds
+	now = now.Add(2 * time.Second)
+	assert.InDelta(t, 90.0, r.Update(RevolutionData{Revs: 13, EventTime: 1000 + 2048}, now), 0.01)
+
+	// Repeated measurement keeps the rate
+	now = now.Add(time.Second)
+	assert.InDelta(t, 90.0, r.Update(RevolutionData{Revs: 13, EventTime: 1000 + 2048}, now), 0.01)
+}
+
+func TestRevolutionRate_Rollover(t *testing.T) {
+	r := newRevolutionRate(16, 1024)
+	now := time.Now()
+
+	r.Update(RevolutionData{Revs: 65535, EventTime: 65000}, now)
+
+	// Both counters wrap: 2 revolutions in 1 second
+	now = now.Add(time.Second)
+	rpm := r.Update(RevolutionData{Revs: 1, EventTime: 65000 + 1024 - 65536}, now)
+	assert.InDelta(t, 120.0, rpm, 0.01)
+}
+
+func TestRevolutionRate_Stopped(t *testing.T) {
+	r := newRevolutionRate(16, 1024)
+	now := time.Now()
+
+	r.Update(RevolutionData{Revs: 10, EventTime: 0}, now)
+	now = now.Add(time.Second)
+	require.InDelta(t, 60.0, r.Update(RevolutionData{Revs: 11, EventTime: 1024}, now), 0.01)
+
+	now = now.Add(revolutionStopTimeout + time.Second)
+	assert.Equal(t, 0.0, r.Update(RevolutionData{Revs: 11, EventTime: 1024}, now))
+}
+
+func TestNewSourceSensors(t *testing.T) {
+	power, cadence, err := NewSourceSensors(SourceTrainer, SourceTrainer, "", "", false)
+	require.NoError(t, err)
+	assert.Nil(t, power)
+	assert.Nil(t, cadence)
+
+	// A power meter supplying both is shared
+	power, cadence, err = NewSourceSensors(SourcePowerMeter, SourcePowerMeter, "AA:BB", "", false)
+	require.NoError(t, err)
+	assert.Same(t, power, cadence)
+
+	power, cadence, err = NewSourceSensors(SourceTrainer, SourceCadenceSensor, "", "CC:DD", false)
+	require.NoError(t, err)
+	assert.Nil(t, power)
+	assert.NotNil(t, cadence)
+
+	_, _, err = NewSourceSensors(SourcePowerMeter, SourceTrainer, "", "", false)
+	assert.Error(t, err)
+
+	_, _, err = NewSourceSensors(SourceCadenceSensor, SourceTrainer, "", "CC:DD", false)
+	assert.Error(t, err)
+}
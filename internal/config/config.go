@@ -4,8 +4,12 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 
 	"github.com/spf13/viper"
+	"github.com/thiemotorres/goc/internal/simulation"
+	"github.com/thiemotorres/goc/internal/workout"
+	"github.com/thiemotorres/goc/internal/zones"
 )
 
 // Config holds application configuration
@@ -13,15 +17,25 @@ type Config struct {
 	Trainer   TrainerConfig   `mapstructure:"trainer"`
 	Shifter   ShifterConfig   `mapstructure:"shifter"`
 	Bike      BikeConfig      `mapstructure:"bike"`
+	Rider     RiderConfig     `mapstructure:"rider"`
 	Bluetooth BluetoothConfig `mapstructure:"bluetooth"`
 	Routes    RoutesConfig    `mapstructure:"routes"`
+	Workouts  WorkoutsConfig  `mapstructure:"workouts"`
 	Display   DisplayConfig   `mapstructure:"display"`
 	Controls  ControlsConfig  `mapstructure:"controls"`
 }
 
 // BluetoothConfig holds Bluetooth connection settings
 type BluetoothConfig struct {
-	TrainerAddress string `mapstructure:"trainer_address"`
+	TrainerAddress       string `mapstructure:"trainer_address"`
+	HeartRateAddress     string `mapstructure:"heart_rate_address"`
+	PowerMeterAddress    string `mapstructure:"power_meter_address"`
+	CadenceSensorAddress string `mapstructure:"cadence_sensor_address"`
+
+	// Device providing power and cadence: "trainer", "power_meter" or
+	// "cadence_sensor" (cadence only)
+	PowerSource   string `mapstructure:"power_source"`
+	CadenceSource string `mapstructure:"cadence_source"`
 }
 
 // RoutesConfig holds route file settings
@@ -29,22 +43,164 @@ type RoutesConfig struct {
 	Folder string `mapstructure:"folder"`
 }
 
+// WorkoutsConfig holds workout file settings
+type WorkoutsConfig struct {
+	Folder string `mapstructure:"folder"`
+}
+
 type TrainerConfig struct {
-	DeviceID string `mapstructure:"device_id"`
+	DeviceID         string  `mapstructure:"device_id"`
+	NativeSimulation bool    `mapstructure:"native_simulation"`
+	SilenceTimeout   float64 `mapstructure:"silence_timeout"` // s without data before reconnecting, 0 = never
 }
 
 type ShifterConfig struct {
-	DeviceID string `mapstructure:"device_id"`
+	DeviceID          string `mapstructure:"device_id"`
+	SynchroShift      int    `mapstructure:"synchro_shift"` // rear cogs moved on a front shift, 0 = off
+	PreventCrossChain bool   `mapstructure:"prevent_cross_chain"`
 }
 
 type BikeConfig struct {
-	Preset             string  `mapstructure:"preset"`
+	Preset             string  `mapstructure:"preset"` // drivetrain last applied, see Drivetrains
 	Chainrings         []int   `mapstructure:"chainrings"`
 	Cassette           []int   `mapstructure:"cassette"`
 	WheelCircumference float64 `mapstructure:"wheel_circumference"`
-	RiderWeight        float64 `mapstructure:"rider_weight"`
 	ResistanceScaling  float64 `mapstructure:"resistance_scaling"`
 	GradientSmoothing  float64 `mapstructure:"gradient_smoothing"`
+	SpeedModel         string  `mapstructure:"speed_model"` // "power" or "cadence"
+
+	// Force model: CdA and Crr override the position and tyre presets when set
+	Position     string  `mapstructure:"position"` // tops, hoods, drops, tt
+	CdA          float64 `mapstructure:"cda"`
+	Tyres        string  `mapstructure:"tyres"` // race, road, gravel, mtb
+	Crr          float64 `mapstructure:"crr"`
+	BikeWeight   float64 `mapstructure:"bike_weight"`
+	FlywheelMass float64 `mapstructure:"flywheel_mass"` // kg, momentum of the spinning wheels
+	Altitude     float64 `mapstructure:"altitude"`      // m, sets air density
+	Temperature  float64 `mapstructure:"temperature"`   // °C, sets air density
+
+	Presets []DrivetrainPreset `mapstructure:"presets"` // custom drivetrains
+}
+
+// DrivetrainPreset is a custom drivetrain defined in config.toml as a
+// [[bike.presets]] table. WheelCircumference overrides WheelSize when set.
+type DrivetrainPreset struct {
+	Name               string  `mapstructure:"name"`
+	Chainrings         []int   `mapstructure:"chainrings"`
+	Cassette           []int   `mapstructure:"cassette"`
+	WheelSize          string  `mapstructure:"wheel_size"`
+	WheelCircumference float64 `mapstructure:"wheel_circumference"`
+}
+
+// Drivetrains returns the built-in drivetrain presets followed by the
+// custom ones. A custom preset replaces a built-in one with the same name.
+func (b BikeConfig) Drivetrains() []simulation.Drivetrain {
+	drivetrains := append([]simulation.Drivetrain(nil), simulation.Drivetrains...)
+	for _, p := range b.Presets {
+		d := simulation.Drivetrain{
+			Name:               p.Name,
+			Chainrings:         p.Chainrings,
+			Cassette:           p.Cassette,
+			WheelSize:          p.WheelSize,
+			WheelCircumference: p.WheelCircumference,
+		}
+		replaced := false
+		for i := range drivetrains {
+			if drivetrains[i].Name == d.Name {
+				drivetrains[i] = d
+				replaced = true
+			}
+		}
+		if !replaced {
+			drivetrains = append(drivetrains, d)
+		}
+	}
+	return drivetrains
+}
+
+// ApplyPreset sets the chainrings, cassette and wheel circumference from
+// the named drivetrain
+func (b *BikeConfig) ApplyPreset(name string) error {
+	d, err := simulation.LookupDrivetrain(b.Drivetrains(), name)
+	if err != nil {
+		return err
+	}
+	circumference, err := d.Circumference()
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	b.Preset = d.Name
+	b.Chainrings = append([]int(nil), d.Chainrings...)
+	b.Cassette = append([]int(nil), d.Cassette...)
+	b.WheelCircumference = circumference
+	return nil
+}
+
+// Physics returns the force model parameters for this bike and rider
+func (b BikeConfig) Physics() (simulation.Physics, error) {
+	p := simulation.Physics{
+		CdA:        b.CdA,
+		Crr:        b.Crr,
+		BikeMass:   b.BikeWeight,
+		AirDensity: simulation.AirDensity(b.Altitude, b.Temperature),
+
+		FlywheelMass: b.FlywheelMass,
+	}
+
+	if p.CdA == 0 {
+		position := b.Position
+		if position == "" {
+			position = "hoods"
+		}
+		cda, err := simulation.LookupPreset(simulation.PositionPresets, position)
+		if err != nil {
+			return p, fmt.Errorf("position: %w", err)
+		}
+		p.CdA = cda
+	}
+	if p.Crr == 0 {
+		tyres := b.Tyres
+		if tyres == "" {
+			tyres = "road"
+		}
+		crr, err := simulation.LookupPreset(simulation.TyrePresets, tyres)
+		if err != nil {
+			return p, fmt.Errorf("tyres: %w", err)
+		}
+		p.Crr = crr
+	}
+	if p.BikeMass == 0 {
+		p.BikeMass = simulation.DefaultBikeMass
+	}
+	if p.FlywheelMass == 0 {
+		p.FlywheelMass = simulation.DefaultFlywheelMass
+	}
+
+	return p, nil
+}
+
+// RiderConfig is the rider profile
+type RiderConfig struct {
+	Weight      float64   `mapstructure:"weight"`       // kg
+	FTP         float64   `mapstructure:"ftp"`          // W, 0 = unknown
+	MaxHR       int       `mapstructure:"max_hr"`       // 0 = unknown
+	ThresholdHR int       `mapstructure:"threshold_hr"` // 0 = unknown
+	ZoneModel   string    `mapstructure:"zone_model"`   // coggan or custom
+	CustomZones []float64 `mapstructure:"custom_zones"` // upper zone bounds, % of FTP
+}
+
+// EffectiveFTP returns the FTP, or workout.DefaultFTP when it is unknown
+func (r RiderConfig) EffectiveFTP() float64 {
+	if r.FTP <= 0 {
+		return workout.DefaultFTP
+	}
+	return r.FTP
+}
+
+// Zones returns the power zone model
+func (r RiderConfig) Zones() (zones.Model, error) {
+	return zones.Lookup(r.ZoneModel, r.CustomZones)
 }
 
 type DisplayConfig struct {
@@ -56,6 +212,8 @@ type DisplayConfig struct {
 type ControlsConfig struct {
 	ShiftUp        string `mapstructure:"shift_up"`
 	ShiftDown      string `mapstructure:"shift_down"`
+	FrontShiftUp   string `mapstructure:"front_shift_up"`
+	FrontShiftDown string `mapstructure:"front_shift_down"`
 	ResistanceUp   string `mapstructure:"resistance_up"`
 	ResistanceDown string `mapstructure:"resistance_down"`
 	Pause          string `mapstructure:"pause"`
@@ -75,27 +233,127 @@ func Load(configDir string) (*Config, error) {
 	// Try to read config file (ignore if not found)
 	_ = v.ReadInConfig()
 
+	// The rider's weight was bike.rider_weight before the rider profile
+	if v.InConfig("bike.rider_weight") && !v.InConfig("rider.weight") {
+		v.Set("rider.weight", v.Get("bike.rider_weight"))
+	}
+
 	var cfg Config
 	if err := v.Unmarshal(&cfg); err != nil {
 		return nil, err
 	}
 
+	// A preset that no longer exists, such as a removed custom one, leaves
+	// the drivetrain as it is, without a preset
+	if _, err := simulation.LookupDrivetrain(cfg.Bike.Drivetrains(), cfg.Bike.Preset); err != nil {
+		cfg.Bike.Preset = ""
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+
 	return &cfg, nil
 }
 
+// Validate checks the bike and rider settings
+func (c *Config) Validate() error {
+	b := c.Bike
+	switch {
+	case len(b.Chainrings) == 0:
+		return fmt.Errorf("bike.chainrings: at least one chainring required")
+	case len(b.Cassette) == 0:
+		return fmt.Errorf("bike.cassette: at least one cog required")
+	case b.WheelCircumference <= 0 || b.WheelCircumference > 3.5:
+		return fmt.Errorf("bike.wheel_circumference: %.3f m out of range (0-3.5)", b.WheelCircumference)
+	case b.GradientSmoothing < 0 || b.GradientSmoothing >= 1:
+		return fmt.Errorf("bike.gradient_smoothing: %.2f out of range (0-1)", b.GradientSmoothing)
+	case b.Altitude < -500 || b.Altitude > 6000:
+		return fmt.Errorf("bike.altitude: %.0f m out of range (-500-6000)", b.Altitude)
+	case c.Shifter.SynchroShift < 0 || c.Shifter.SynchroShift >= len(b.Cassette) && len(b.Cassette) > 0:
+		return fmt.Errorf("shifter.synchro_shift: %d cogs out of range (0-%d)", c.Shifter.SynchroShift, len(b.Cassette)-1)
+	case b.Temperature < -30 || b.Temperature > 50:
+		return fmt.Errorf("bike.temperature: %.0f °C out of range (-30-50)", b.Temperature)
+	}
+
+	if c.Trainer.SilenceTimeout < 0 {
+		return fmt.Errorf("trainer.silence_timeout: %.0f s must not be negative", c.Trainer.SilenceTimeout)
+	}
+
+	r := c.Rider
+	switch {
+	case r.Weight <= 0 || r.Weight > 250:
+		return fmt.Errorf("rider.weight: %.1f kg out of range (0-250)", r.Weight)
+	case r.FTP < 0 || r.FTP > 1000:
+		return fmt.Errorf("rider.ftp: %.0f W out of range (0-1000)", r.FTP)
+	case r.MaxHR != 0 && (r.MaxHR < 100 || r.MaxHR > 250):
+		return fmt.Errorf("rider.max_hr: %d bpm out of range (100-250)", r.MaxHR)
+	case r.ThresholdHR < 0 || r.MaxHR != 0 && r.ThresholdHR > r.MaxHR:
+		return fmt.Errorf("rider.threshold_hr: %d bpm above max_hr", r.ThresholdHR)
+	}
+	if _, err := r.Zones(); err != nil {
+		return fmt.Errorf("rider.zone_model: %w", err)
+	}
+
+	for _, d := range b.Drivetrains() {
+		if err := d.Validate(); err != nil {
+			return fmt.Errorf("bike.presets: %w", err)
+		}
+	}
+
+	if _, err := simulation.ParseSpeedModel(b.SpeedModel); err != nil {
+		return fmt.Errorf("bike.speed_model: %w", err)
+	}
+
+	p, err := b.Physics()
+	if err != nil {
+		return fmt.Errorf("bike.%w", err)
+	}
+	if err := p.Validate(); err != nil {
+		return fmt.Errorf("bike: %w", err)
+	}
+
+	return nil
+}
+
 func setDefaults(v *viper.Viper) {
 	// Routes defaults
 	home, _ := os.UserHomeDir()
 	v.SetDefault("routes.folder", filepath.Join(home, ".config", "goc", "routes"))
 
+	// Workouts defaults
+	v.SetDefault("workouts.folder", filepath.Join(home, ".config", "goc", "workouts"))
+
+	// Trainer defaults
+	v.SetDefault("trainer.native_simulation", true)
+	v.SetDefault("trainer.silence_timeout", 8.0)
+
+	// Bluetooth defaults
+	v.SetDefault("bluetooth.power_source", "trainer")
+	v.SetDefault("bluetooth.cadence_source", "trainer")
+
 	// Bike defaults
-	v.SetDefault("bike.preset", "road-2x11")
-	v.SetDefault("bike.chainrings", []int{50, 34})
-	v.SetDefault("bike.cassette", []int{11, 12, 13, 14, 15, 17, 19, 21, 24, 28})
-	v.SetDefault("bike.wheel_circumference", 2.1)
-	v.SetDefault("bike.rider_weight", 75.0)
+	drivetrain, _ := simulation.LookupDrivetrain(simulation.Drivetrains, simulation.DefaultDrivetrain)
+	circumference, _ := drivetrain.Circumference()
+	v.SetDefault("bike.preset", drivetrain.Name)
+	v.SetDefault("bike.chainrings", slices.Clone(drivetrain.Chainrings))
+	v.SetDefault("bike.cassette", slices.Clone(drivetrain.Cassette))
+	v.SetDefault("bike.wheel_circumference", circumference)
 	v.SetDefault("bike.resistance_scaling", 0.2)
 	v.SetDefault("bike.gradient_smoothing", 0.85)
+	v.SetDefault("bike.speed_model", "power")
+	v.SetDefault("bike.position", "hoods")
+	v.SetDefault("bike.tyres", "road")
+	v.SetDefault("bike.bike_weight", 10.0)
+	v.SetDefault("bike.flywheel_mass", 1.5)
+	v.SetDefault("bike.altitude", 0.0)
+	v.SetDefault("bike.temperature", 15.0)
+
+	// Rider defaults
+	v.SetDefault("rider.weight", 75.0)
+	v.SetDefault("rider.ftp", 0.0)
+	v.SetDefault("rider.zone_model", zones.ModelCoggan)
+	v.SetDefault("rider.custom_zones", []float64{55, 75, 90, 105, 120, 150})
 
 	// Display defaults
 	v.SetDefault("display.graph_window_minutes", 5)
@@ -105,12 +363,35 @@ func setDefaults(v *viper.Viper) {
 	// Controls defaults
 	v.SetDefault("controls.shift_up", "Up")
 	v.SetDefault("controls.shift_down", "Down")
+	v.SetDefault("controls.front_shift_up", "Shift+Up")
+	v.SetDefault("controls.front_shift_down", "Shift+Down")
 	v.SetDefault("controls.resistance_up", "Right")
 	v.SetDefault("controls.resistance_down", "Left")
 	v.SetDefault("controls.pause", "Space")
 	v.SetDefault("controls.toggle_view", "Tab")
 }
 
+// presetTables converts custom presets to TOML tables, leaving out unset
+// wheel fields
+func presetTables(presets []DrivetrainPreset) []map[string]any {
+	tables := make([]map[string]any, 0, len(presets))
+	for _, p := range presets {
+		t := map[string]any{
+			"name":       p.Name,
+			"chainrings": p.Chainrings,
+			"cassette":   p.Cassette,
+		}
+		if p.WheelSize != "" {
+			t["wheel_size"] = p.WheelSize
+		}
+		if p.WheelCircumference != 0 {
+			t["wheel_circumference"] = p.WheelCircumference
+		}
+		tables = append(tables, t)
+	}
+	return tables
+}
+
 // DefaultConfigDir returns the default config directory
 func DefaultConfigDir() string {
 	home, _ := os.UserHomeDir()
@@ -127,21 +408,48 @@ func Save(cfg *Config, configDir string) error {
 	v.SetConfigType("toml")
 
 	v.Set("trainer.device_id", cfg.Trainer.DeviceID)
+	v.Set("trainer.native_simulation", cfg.Trainer.NativeSimulation)
+	v.Set("trainer.silence_timeout", cfg.Trainer.SilenceTimeout)
 	v.Set("shifter.device_id", cfg.Shifter.DeviceID)
+	v.Set("shifter.synchro_shift", cfg.Shifter.SynchroShift)
+	v.Set("shifter.prevent_cross_chain", cfg.Shifter.PreventCrossChain)
 	v.Set("bluetooth.trainer_address", cfg.Bluetooth.TrainerAddress)
+	v.Set("bluetooth.heart_rate_address", cfg.Bluetooth.HeartRateAddress)
+	v.Set("bluetooth.power_meter_address", cfg.Bluetooth.PowerMeterAddress)
+	v.Set("bluetooth.cadence_sensor_address", cfg.Bluetooth.CadenceSensorAddress)
+	v.Set("bluetooth.power_source", cfg.Bluetooth.PowerSource)
+	v.Set("bluetooth.cadence_source", cfg.Bluetooth.CadenceSource)
 	v.Set("routes.folder", cfg.Routes.Folder)
+	v.Set("workouts.folder", cfg.Workouts.Folder)
 	v.Set("bike.preset", cfg.Bike.Preset)
 	v.Set("bike.chainrings", cfg.Bike.Chainrings)
 	v.Set("bike.cassette", cfg.Bike.Cassette)
 	v.Set("bike.wheel_circumference", cfg.Bike.WheelCircumference)
-	v.Set("bike.rider_weight", cfg.Bike.RiderWeight)
 	v.Set("bike.resistance_scaling", cfg.Bike.ResistanceScaling)
 	v.Set("bike.gradient_smoothing", cfg.Bike.GradientSmoothing)
+	v.Set("bike.speed_model", cfg.Bike.SpeedModel)
+	v.Set("bike.position", cfg.Bike.Position)
+	v.Set("bike.cda", cfg.Bike.CdA)
+	v.Set("bike.tyres", cfg.Bike.Tyres)
+	v.Set("bike.crr", cfg.Bike.Crr)
+	v.Set("bike.bike_weight", cfg.Bike.BikeWeight)
+	v.Set("bike.flywheel_mass", cfg.Bike.FlywheelMass)
+	v.Set("bike.altitude", cfg.Bike.Altitude)
+	v.Set("bike.temperature", cfg.Bike.Temperature)
+	v.Set("bike.presets", presetTables(cfg.Bike.Presets))
+	v.Set("rider.weight", cfg.Rider.Weight)
+	v.Set("rider.ftp", cfg.Rider.FTP)
+	v.Set("rider.max_hr", cfg.Rider.MaxHR)
+	v.Set("rider.threshold_hr", cfg.Rider.ThresholdHR)
+	v.Set("rider.zone_model", cfg.Rider.ZoneModel)
+	v.Set("rider.custom_zones", cfg.Rider.CustomZones)
 	v.Set("display.graph_window_minutes", cfg.Display.GraphWindowMinutes)
 	v.Set("display.climb_gradient_threshold", cfg.Display.ClimbGradientThreshold)
 	v.Set("display.climb_elevation_threshold", cfg.Display.ClimbElevationThreshold)
 	v.Set("controls.shift_up", cfg.Controls.ShiftUp)
 	v.Set("controls.shift_down", cfg.Controls.ShiftDown)
+	v.Set("controls.front_shift_up", cfg.Controls.FrontShiftUp)
+	v.Set("controls.front_shift_down", cfg.Controls.FrontShiftDown)
 	v.Set("controls.resistance_up", cfg.Controls.ResistanceUp)
 	v.Set("controls.resistance_down", cfg.Controls.ResistanceDown)
 	v.Set("controls.pause", cfg.Controls.Pause)
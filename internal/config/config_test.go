@@ -17,12 +17,18 @@ func TestLoadConfig_Defaults(t *testing.T) {
 	require.NoError(t, err)
 
 	// Check defaults
-	assert.Equal(t, []int{50, 34}, cfg.Bike.Chainrings)
-	assert.Equal(t, []int{11, 12, 13, 14, 15, 17, 19, 21, 24, 28}, cfg.Bike.Cassette)
-	assert.Equal(t, 2.1, cfg.Bike.WheelCircumference)
-	assert.Equal(t, 75.0, cfg.Bike.RiderWeight)
+	assert.Equal(t, "road-2x11", cfg.Bike.Preset)
+	assert.Equal(t, []int{53, 39}, cfg.Bike.Chainrings)
+	assert.Equal(t, []int{11, 12, 13, 14, 15, 17, 19, 21, 23, 25, 28}, cfg.Bike.Cassette)
+	assert.Equal(t, 2.105, cfg.Bike.WheelCircumference)
+	assert.Equal(t, 75.0, cfg.Rider.Weight)
 	assert.Equal(t, 5, cfg.Display.GraphWindowMinutes)
 	assert.Equal(t, 3.0, cfg.Display.ClimbGradientThreshold)
+	assert.True(t, cfg.Trainer.NativeSimulation)
+	assert.Equal(t, 8.0, cfg.Trainer.SilenceTimeout)
+	assert.Equal(t, "power", cfg.Bike.SpeedModel)
+	assert.Equal(t, "trainer", cfg.Bluetooth.PowerSource)
+	assert.Equal(t, "trainer", cfg.Bluetooth.CadenceSource)
 }
 
 func TestSaveConfig(t *testing.T) {
@@ -30,12 +36,18 @@ func TestSaveConfig(t *testing.T) {
 
 	cfg := &Config{
 		Trainer: TrainerConfig{DeviceID: "AA:BB:CC:DD:EE:FF"},
+		Bluetooth: BluetoothConfig{
+			HeartRateAddress:  "11:22:33:44:55:66",
+			PowerMeterAddress: "22:33:44:55:66:77",
+			PowerSource:       "power_meter",
+			CadenceSource:     "power_meter",
+		},
 		Bike: BikeConfig{
 			Chainrings:         []int{52, 36},
 			Cassette:           []int{11, 13, 15, 17, 19, 21, 23, 25},
 			WheelCircumference: 2.1,
-			RiderWeight:        80.0,
 		},
+		Rider: RiderConfig{Weight: 80.0},
 	}
 
 	err := Save(cfg, tmpDir)
@@ -49,7 +61,12 @@ func TestSaveConfig(t *testing.T) {
 	loaded, err := Load(tmpDir)
 	require.NoError(t, err)
 	assert.Equal(t, "AA:BB:CC:DD:EE:FF", loaded.Trainer.DeviceID)
+	assert.Equal(t, "11:22:33:44:55:66", loaded.Bluetooth.HeartRateAddress)
+	assert.Equal(t, "22:33:44:55:66:77", loaded.Bluetooth.PowerMeterAddress)
+	assert.Equal(t, "power_meter", loaded.Bluetooth.PowerSource)
+	assert.Equal(t, "power_meter", loaded.Bluetooth.CadenceSource)
 	assert.Equal(t, []int{52, 36}, loaded.Bike.Chainrings)
+	assert.Equal(t, 80.0, loaded.Rider.Weight)
 }
 
 func TestRoutesFolder_Default(t *testing.T) {
@@ -64,6 +81,14 @@ func TestRoutesFolder_Default(t *testing.T) {
 	}
 }
 
+func TestWorkoutsFolder_Default(t *testing.T) {
+	cfg, err := Load(t.TempDir())
+	require.NoError(t, err)
+
+	home, _ := os.UserHomeDir()
+	assert.Equal(t, filepath.Join(home, ".config", "goc", "workouts"), cfg.Workouts.Folder)
+}
+
 func TestLoadConfig_GradientSmoothingDefault(t *testing.T) {
 	dir := t.TempDir()
 
@@ -77,3 +102,208 @@ func TestLoadConfig_GradientSmoothingDefault(t *testing.T) {
 		t.Errorf("GradientSmoothing = %.2f, want 0.85", cfg.Bike.GradientSmoothing)
 	}
 }
+
+func TestBikeConfig_Physics(t *testing.T) {
+	cfg, err := Load(t.TempDir())
+	require.NoError(t, err)
+
+	p, err := cfg.Bike.Physics()
+	require.NoError(t, err)
+	assert.Equal(t, 0.30, p.CdA)
+	assert.Equal(t, 0.005, p.Crr)
+	assert.Equal(t, 10.0, p.BikeMass)
+	assert.InDelta(t, 1.225, p.AirDensity, 0.001)
+
+	// Explicit values override the presets
+	cfg.Bike.Position = "tt"
+	cfg.Bike.Tyres = "gravel"
+	cfg.Bike.Crr = 0.006
+	p, err = cfg.Bike.Physics()
+	require.NoError(t, err)
+	assert.Equal(t, 0.24, p.CdA)
+	assert.Equal(t, 0.006, p.Crr)
+
+	cfg.Bike.Position = "aerobars"
+	_, err = cfg.Bike.Physics()
+	assert.Error(t, err)
+}
+
+func TestConfig_Validate(t *testing.T) {
+	valid := func() *Config {
+		cfg, err := Load(t.TempDir())
+		require.NoError(t, err)
+		return cfg
+	}
+
+	assert.NoError(t, valid().Validate())
+
+	for name, mutate := range map[string]func(*Config){
+		"no chainrings": func(c *Config) { c.Bike.Chainrings = nil },
+		"rider weight":  func(c *Config) { c.Rider.Weight = -5 },
+		"position":      func(c *Config) { c.Bike.Position = "superman" },
+		"cda":           func(c *Config) { c.Bike.CdA = 3 },
+		"crr":           func(c *Config) { c.Bike.Crr = 0.2 },
+		"bike weight":   func(c *Config) { c.Bike.BikeWeight = 80 },
+		"altitude":      func(c *Config) { c.Bike.Altitude = 9000 },
+		"speed model":   func(c *Config) { c.Bike.SpeedModel = "gears" },
+		"synchro shift": func(c *Config) { c.Shifter.SynchroShift = 20 },
+		"silence":       func(c *Config) { c.Trainer.SilenceTimeout = -1 },
+		"ftp":           func(c *Config) { c.Rider.FTP = 5000 },
+		"threshold hr":  func(c *Config) { c.Rider.MaxHR, c.Rider.ThresholdHR = 180, 190 },
+		"zone model":    func(c *Config) { c.Rider.ZoneModel = "polarized" },
+		"custom zones":  func(c *Config) { c.Rider.ZoneModel, c.Rider.CustomZones = "custom", []float64{90, 60} },
+	} {
+		cfg := valid()
+		mutate(cfg)
+		assert.Error(t, cfg.Validate(), name)
+	}
+}
+
+func TestLoadConfig_Invalid(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config.toml"), []byte("[bike]\ncda = -1\n"), 0644))
+
+	_, err := Load(dir)
+	assert.ErrorContains(t, err, "CdA")
+}
+
+func TestBikeConfig_ApplyPreset(t *testing.T) {
+	cfg, err := Load(t.TempDir())
+	require.NoError(t, err)
+
+	require.NoError(t, cfg.Bike.ApplyPreset("mtb-1x12"))
+	assert.Equal(t, "mtb-1x12", cfg.Bike.Preset)
+	assert.Equal(t, []int{32}, cfg.Bike.Chainrings)
+	assert.Len(t, cfg.Bike.Cassette, 12)
+	assert.Equal(t, 2.298, cfg.Bike.WheelCircumference)
+	assert.NoError(t, cfg.Validate())
+
+	assert.Error(t, cfg.Bike.ApplyPreset("unicycle"))
+	assert.Equal(t, "mtb-1x12", cfg.Bike.Preset)
+}
+
+func TestLoadConfig_CustomPresets(t *testing.T) {
+	dir := t.TempDir()
+	toml := `[bike]
+preset = "commuter"
+
+[[bike.presets]]
+name = "commuter"
+chainrings = [42]
+cassette = [11, 13, 15, 18, 21, 24, 28, 32]
+wheel_size = "700x35c"
+
+[[bike.presets]]
+name = "track"
+chainrings = [50]
+cassette = [14]
+wheel_circumference = 2.1
+`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config.toml"), []byte(toml), 0644))
+
+	cfg, err := Load(dir)
+	require.NoError(t, err)
+	require.Len(t, cfg.Bike.Presets, 2)
+
+	require.NoError(t, cfg.Bike.ApplyPreset("commuter"))
+	assert.Equal(t, []int{42}, cfg.Bike.Chainrings)
+	assert.Equal(t, 2.168, cfg.Bike.WheelCircumference)
+
+	// Custom presets replace built-in ones with the same name
+	require.NoError(t, cfg.Bike.ApplyPreset("track"))
+	assert.Equal(t, []int{50}, cfg.Bike.Chainrings)
+	assert.Equal(t, []int{14}, cfg.Bike.Cassette)
+
+	// Presets survive a save
+	require.NoError(t, Save(cfg, dir))
+	loaded, err := Load(dir)
+	require.NoError(t, err)
+	assert.Equal(t, cfg.Bike.Presets, loaded.Bike.Presets)
+	assert.Equal(t, "track", loaded.Bike.Preset)
+}
+
+func TestLoadConfig_InvalidPreset(t *testing.T) {
+	dir := t.TempDir()
+	toml := "[[bike.presets]]\nname = \"broken\"\nchainrings = [42]\ncassette = [11, 28]\nwheel_size = \"fat\"\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config.toml"), []byte(toml), 0644))
+
+	_, err := Load(dir)
+	assert.ErrorContains(t, err, "bike.presets")
+
+}
+
+func TestLoadConfig_UnknownPreset(t *testing.T) {
+	dir := t.TempDir()
+	toml := "[bike]\npreset = \"unicycle\"\nchainrings = [42]\ncassette = [16]\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config.toml"), []byte(toml), 0644))
+
+	cfg, err := Load(dir)
+	require.NoError(t, err)
+	assert.Empty(t, cfg.Bike.Preset)
+	assert.Equal(t, []int{42}, cfg.Bike.Chainrings)
+}
+
+func TestRiderConfig(t *testing.T) {
+	cfg, err := Load(t.TempDir())
+	require.NoError(t, err)
+	assert.Equal(t, 0.0, cfg.Rider.FTP)
+	assert.Equal(t, 200.0, cfg.Rider.EffectiveFTP())
+	m, err := cfg.Rider.Zones()
+	require.NoError(t, err)
+	assert.Equal(t, "coggan", m.Name)
+
+	dir := t.TempDir()
+	toml := `[rider]
+ftp = 280
+max_hr = 190
+threshold_hr = 172
+zone_model = "custom"
+custom_zones = [60, 85, 100, 120]
+`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config.toml"), []byte(toml), 0644))
+
+	cfg, err = Load(dir)
+	require.NoError(t, err)
+	assert.Equal(t, 280.0, cfg.Rider.FTP)
+	assert.Equal(t, 190, cfg.Rider.MaxHR)
+	assert.Equal(t, 172, cfg.Rider.ThresholdHR)
+	m, err = cfg.Rider.Zones()
+	require.NoError(t, err)
+	assert.Len(t, m.Zones, 5)
+
+	// Saved and loaded back unchanged
+	require.NoError(t, Save(cfg, dir))
+	loaded, err := Load(dir)
+	require.NoError(t, err)
+	assert.Equal(t, cfg.Rider, loaded.Rider)
+
+	// An unknown FTP falls back to the default
+	cfg.Rider.FTP = 0
+	assert.Equal(t, 200.0, cfg.Rider.EffectiveFTP())
+}
+
+func TestLoadConfig_RiderWeightAlias(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config.toml"), []byte("[bike]\nrider_weight = 68.5\n"), 0644))
+
+	cfg, err := Load(dir)
+	require.NoError(t, err)
+	assert.Equal(t, 68.5, cfg.Rider.Weight)
+
+	// Saved under the rider profile from then on
+	require.NoError(t, Save(cfg, dir))
+	saved, err := os.ReadFile(filepath.Join(dir, "config.toml"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(saved), "rider_weight")
+
+	cfg, err = Load(dir)
+	require.NoError(t, err)
+	assert.Equal(t, 68.5, cfg.Rider.Weight)
+
+	// rider.weight wins over the old key
+	toml := "[bike]\nrider_weight = 68.5\n[rider]\nweight = 70\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config.toml"), []byte(toml), 0644))
+	cfg, err = Load(dir)
+	require.NoError(t, err)
+	assert.Equal(t, 70.0, cfg.Rider.Weight)
+}
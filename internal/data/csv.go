@@ -0,0 +1,70 @@
+package data
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+)
+
+// csvHeader names the columns written by EncodeCSV
+var csvHeader = []string{
+	"time", "elapsed_s", "power_w", "cadence_rpm", "speed_kmh", "heart_rate_bpm",
+	"distance_m", "elevation_m", "gradient_pct", "latitude", "longitude", "gear", "step",
+}
+
+// ExportCSV writes a ride's data points as CSV
+func ExportCSV(ride *Ride, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := EncodeCSV(ride, f); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// EncodeCSV writes one row per data point, for spreadsheets and scripts
+func EncodeCSV(ride *Ride, w io.Writer) error {
+	if len(ride.Points) == 0 {
+		return fmt.Errorf("ride has no points")
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+
+	f := func(v float64, prec int) string {
+		return strconv.FormatFloat(v, 'f', prec, 64)
+	}
+	for _, p := range ride.Points {
+		row := []string{
+			p.Timestamp.UTC().Format(time.RFC3339),
+			f(p.Timestamp.Sub(ride.StartTime).Seconds(), 0),
+			f(p.Power, 0),
+			f(p.Cadence, 0),
+			f(p.Speed, 1),
+			strconv.Itoa(p.HeartRate),
+			f(p.Distance, 1),
+			f(p.Elevation, 1),
+			f(p.Gradient, 1),
+			f(p.Latitude, 6),
+			f(p.Longitude, 6),
+			p.GearString,
+			strconv.Itoa(p.Step),
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,39 @@
+package data
+
+import (
+	"bytes"
+	"encoding/csv"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeCSV(t *testing.T) {
+	start := time.Date(2025, 11, 20, 18, 30, 0, 0, time.UTC)
+	ride := &Ride{StartTime: start}
+	ride.AddPoint(RidePoint{Timestamp: start, Power: 180, Cadence: 85, Speed: 30.25})
+	ride.AddPoint(RidePoint{Timestamp: start.Add(time.Second), Power: 210, Cadence: 90, Speed: 31, HeartRate: 142, GearString: "50x17", Step: 1})
+
+	var buf bytes.Buffer
+	require.NoError(t, EncodeCSV(ride, &buf))
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	assert.Equal(t, csvHeader, records[0])
+	assert.Equal(t, []string{
+		"2025-11-20T18:30:01Z", "1", "210", "90", "31.0", "142",
+		"0.0", "0.0", "0.0", "0.000000", "0.000000", "50x17", "1",
+	}, records[2])
+}
+
+func TestExportCSV_NoPoints(t *testing.T) {
+	ride := NewRide()
+	ride.Finish()
+
+	err := ExportCSV(ride, filepath.Join(t.TempDir(), "empty.csv"))
+	assert.Error(t, err)
+}
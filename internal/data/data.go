@@ -1,24 +1,55 @@
 package data
 
 import (
+	"bytes"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
+	"github.com/thiemotorres/goc/internal/analytics"
 	_ "modernc.org/sqlite"
 )
 
+// sqliteTimeFormat is how times are stored, readable by SQLite's date
+// functions. The driver writes it for the _time_format=sqlite option.
+const sqliteTimeFormat = "2006-01-02 15:04:05.999999999-07:00"
+
+// ErrRideNotFound is returned for a ride ID that is not stored
+var ErrRideNotFound = errors.New("ride not found")
+
 // RideSummary is a lightweight ride listing
 type RideSummary struct {
 	ID        string
+	Name      string // Set by the rider, empty by default
 	StartTime time.Time
 	Duration  time.Duration
 	Distance  float64
 	AvgPower  float64
 	GPXName   string
+	FTP       float64 // FTP in effect during the ride, 0 if unknown
+
+	// Analytics, 0 for rides saved before they were recorded. IF and TSS
+	// are also 0 without an FTP.
+	NormalizedPower  float64
+	IntensityFactor  float64
+	TSS              float64
+	VariabilityIndex float64
+	Work             float64 // kJ
+}
+
+// Workload returns the training load of the ride. Rides saved without an
+// FTP are scored against fallbackFTP.
+func (r RideSummary) Workload(fallbackFTP float64) analytics.Workload {
+	tss := r.TSS
+	if r.FTP == 0 {
+		tss = analytics.TSS(r.Duration, r.NormalizedPower, fallbackFTP)
+	}
+	return analytics.Workload{Time: r.StartTime, TSS: tss}
 }
 
 // Store handles ride persistence
@@ -35,17 +66,17 @@ func NewStore(dataDir string) (*Store, error) {
 		return nil, fmt.Errorf("create rides dir: %w", err)
 	}
 
-	// Open SQLite database
+	// Open SQLite database, storing times in a format SQLite can compare
 	dbPath := filepath.Join(dataDir, "history.db")
-	db, err := sql.Open("sqlite", dbPath)
+	db, err := sql.Open("sqlite", dbPath+"?_time_format=sqlite")
 	if err != nil {
 		return nil, fmt.Errorf("open database: %w", err)
 	}
 
-	// Create tables
-	if err := createTables(db); err != nil {
+	// Create or upgrade tables
+	if err := migrate(db, dataDir); err != nil {
 		db.Close()
-		return nil, fmt.Errorf("create tables: %w", err)
+		return nil, fmt.Errorf("migrate database: %w", err)
 	}
 
 	return &Store{
@@ -54,58 +85,111 @@ func NewStore(dataDir string) (*Store, error) {
 	}, nil
 }
 
-func createTables(db *sql.DB) error {
-	_, err := db.Exec(`
-		CREATE TABLE IF NOT EXISTS rides (
-			id TEXT PRIMARY KEY,
-			start_time DATETIME,
-			end_time DATETIME,
-			duration_seconds INTEGER,
-			distance_meters REAL,
-			avg_power REAL,
-			max_power REAL,
-			avg_cadence REAL,
-			avg_speed REAL,
-			total_ascent REAL,
-			gpx_name TEXT,
-			metadata TEXT
-		)
-	`)
-	return err
-}
-
 // Close closes the database connection
 func (s *Store) Close() error {
 	return s.db.Close()
 }
 
-// SaveRide saves a ride to disk and database
+// SaveRide stores a new ride: its database row, FIT activity file and
+// JSON record
 func (s *Store) SaveRide(ride *Ride) error {
+	return s.withTx(func(tx *sql.Tx, files *fileSet) error {
+		return s.insertRide(tx, files, ride)
+	})
+}
+
+// saveNewRide stores a ride like SaveRide, unless a ride starting in the
+// same second is already stored. Returns ErrDuplicateRide then.
+func (s *Store) saveNewRide(ride *Ride) error {
+	return s.withTx(func(tx *sql.Tx, files *fileSet) error {
+		var n int
+		err := tx.QueryRow(`SELECT COUNT(*) FROM rides WHERE unixepoch(start_time) = ?`,
+			ride.StartTime.Unix()).Scan(&n)
+		if err != nil {
+			return fmt.Errorf("check existing rides: %w", err)
+		}
+		if n > 0 {
+			return ErrDuplicateRide
+		}
+		return s.insertRide(tx, files, ride)
+	})
+}
+
+// insertRide stages the files of a new ride and inserts its row
+func (s *Store) insertRide(tx *sql.Tx, files *fileSet, ride *Ride) error {
+	values, err := s.stageRide(ride, files)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(fmt.Sprintf(`INSERT INTO rides (id, %s) VALUES (?%s)`,
+		strings.Join(rideFields, ", "), strings.Repeat(", ?", len(rideFields))),
+		append([]any{ride.ID}, values...)...)
+	return err
+}
+
+// UpdateRide replaces a stored ride with ride, recomputing its summary
+func (s *Store) UpdateRide(ride *Ride) error {
+	return s.withTx(func(tx *sql.Tx, files *fileSet) error {
+		values, err := s.stageRide(ride, files)
+		if err != nil {
+			return err
+		}
+		res, err := tx.Exec(fmt.Sprintf(`UPDATE rides SET %s = ? WHERE id = ?`,
+			strings.Join(rideFields, " = ?, ")),
+			append(values, ride.ID)...)
+		if err != nil {
+			return err
+		}
+		return expectRow(res, ride.ID)
+	})
+}
+
+// rideFields are the columns written from a ride, besides its ID
+var rideFields = []string{
+	"name", "start_time", "end_time", "duration_seconds", "distance_meters",
+	"avg_power", "max_power", "avg_cadence", "avg_speed", "total_ascent", "gpx_name", "metadata", "ftp",
+	"normalized_power", "intensity_factor", "tss", "variability_index", "work_kj",
+}
+
+// stageRide stages the ride files and returns the values of rideFields
+func (s *Store) stageRide(ride *Ride, files *fileSet) ([]any, error) {
 	stats := ride.Stats()
+	summary := analytics.Analyze(ride.PowerSeries(), ride.FTP)
 
-	// Save data file (JSON for MVP)
-	fitPath := s.GetFITPath(ride.ID)
-	if err := ExportFIT(ride, fitPath); err != nil {
-		return fmt.Errorf("export data: %w", err)
+	// FIT activity file
+	var fit bytes.Buffer
+	if err := EncodeFIT(ride, &fit); err != nil {
+		return nil, fmt.Errorf("export data: %w", err)
+	}
+	if err := files.write(s.GetFITPath(ride.ID), fit.Bytes()); err != nil {
+		return nil, err
 	}
 
-	// Save JSON metadata (for full reload if needed)
-	jsonPath := filepath.Join(s.dataDir, "rides", ride.ID+".json")
+	// JSON record with every data point, read back by LoadRide
 	jsonData, err := json.Marshal(ride)
 	if err != nil {
-		return fmt.Errorf("marshal ride: %w", err)
+		return nil, fmt.Errorf("marshal ride: %w", err)
 	}
-	if err := os.WriteFile(jsonPath, jsonData, 0644); err != nil {
-		return fmt.Errorf("write JSON: %w", err)
+	if err := files.write(s.jsonPath(ride.ID), jsonData); err != nil {
+		return nil, err
 	}
 
-	// Insert into database
-	_, err = s.db.Exec(`
-		INSERT INTO rides (id, start_time, end_time, duration_seconds, distance_meters,
-			avg_power, max_power, avg_cadence, avg_speed, total_ascent, gpx_name)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-	`,
-		ride.ID,
+	var metadata sql.NullString
+	if len(ride.Metadata) > 0 {
+		metaJSON, err := json.Marshal(ride.Metadata)
+		if err != nil {
+			return nil, fmt.Errorf("marshal metadata: %w", err)
+		}
+		metadata = sql.NullString{String: string(metaJSON), Valid: true}
+	}
+
+	var name sql.NullString
+	if ride.Name != "" {
+		name = sql.NullString{String: ride.Name, Valid: true}
+	}
+
+	return []any{
+		name,
 		ride.StartTime,
 		ride.EndTime,
 		int(stats.Duration.Seconds()),
@@ -116,18 +200,62 @@ func (s *Store) SaveRide(ride *Ride) error {
 		stats.AvgSpeed,
 		stats.TotalAscent,
 		ride.GPXName,
-	)
+		metadata,
+		ride.FTP,
+		summary.NormalizedPower,
+		summary.IntensityFactor,
+		summary.TSS,
+		summary.VariabilityIndex,
+		summary.Work,
+	}, nil
+}
 
-	return err
+// withTx runs fn in a database transaction and applies the files it
+// stages only if the transaction commits
+func (s *Store) withTx(fn func(tx *sql.Tx, files *fileSet) error) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	files := &fileSet{}
+	if err := fn(tx, files); err != nil {
+		tx.Rollback()
+		files.rollback()
+		return err
+	}
+	if err := files.apply(); err != nil {
+		tx.Rollback()
+		files.rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		files.rollback()
+		return err
+	}
+	files.commit()
+	return nil
+}
+
+// expectRow returns ErrRideNotFound if a statement changed no rows
+func expectRow(res sql.Result, id string) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("%w: %s", ErrRideNotFound, id)
+	}
+	return nil
 }
 
+// summaryColumns are the columns scanned by scanSummary
+const summaryColumns = `id, name, start_time, duration_seconds, distance_meters, avg_power, gpx_name, ftp,
+	normalized_power, intensity_factor, tss, variability_index, work_kj`
+
 // ListRides returns all rides ordered by date descending
 func (s *Store) ListRides() ([]RideSummary, error) {
-	rows, err := s.db.Query(`
-		SELECT id, start_time, duration_seconds, distance_meters, avg_power, gpx_name
-		FROM rides
-		ORDER BY start_time DESC
-	`)
+	rows, err := s.db.Query(`SELECT ` + summaryColumns + ` FROM rides ORDER BY start_time DESC`)
 	if err != nil {
 		return nil, err
 	}
@@ -135,28 +263,176 @@ func (s *Store) ListRides() ([]RideSummary, error) {
 
 	var rides []RideSummary
 	for rows.Next() {
-		var r RideSummary
-		var durationSec int
-		var gpxName sql.NullString
-
-		if err := rows.Scan(&r.ID, &r.StartTime, &durationSec, &r.Distance, &r.AvgPower, &gpxName); err != nil {
+		r, err := scanSummary(rows)
+		if err != nil {
 			return nil, err
 		}
+		rides = append(rides, r)
+	}
+
+	return rides, rows.Err()
+}
+
+// GetRide returns the summary of one ride
+func (s *Store) GetRide(id string) (RideSummary, error) {
+	row := s.db.QueryRow(`SELECT `+summaryColumns+` FROM rides WHERE id = ?`, id)
+	r, err := scanSummary(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return r, fmt.Errorf("%w: %s", ErrRideNotFound, id)
+	}
+	return r, err
+}
 
-		r.Duration = time.Duration(durationSec) * time.Second
-		if gpxName.Valid {
-			r.GPXName = gpxName.String
+// scanSummary reads a row of summaryColumns
+func scanSummary(row interface{ Scan(...any) error }) (RideSummary, error) {
+	var r RideSummary
+	var durationSec int
+	var name, gpxName sql.NullString
+	var ftp, np, intensity, tss, vi, work sql.NullFloat64
+
+	if err := row.Scan(&r.ID, &name, &r.StartTime, &durationSec, &r.Distance, &r.AvgPower, &gpxName, &ftp,
+		&np, &intensity, &tss, &vi, &work); err != nil {
+		return r, err
+	}
+
+	r.Duration = time.Duration(durationSec) * time.Second
+	r.Name = name.String
+	r.GPXName = gpxName.String
+	r.FTP = ftp.Float64
+	r.NormalizedPower = np.Float64
+	r.IntensityFactor = intensity.Float64
+	r.TSS = tss.Float64
+	r.VariabilityIndex = vi.Float64
+	r.Work = work.Float64
+	return r, nil
+}
+
+// LoadRide reads a stored ride with all its data points. Every saved
+// ride has a JSON record; one whose record is missing, such as a ride
+// restored from a backup of its FIT file alone, is decoded from the FIT
+// file instead.
+func (s *Store) LoadRide(id string) (*Ride, error) {
+	ride, fromFIT, err := readRideFile(s.dataDir, id)
+	if err != nil || !fromFIT {
+		return ride, err
+	}
+
+	// The FIT file lacks what only the database keeps
+	var name, gpxName, metadata sql.NullString
+	var ftp sql.NullFloat64
+	err = s.db.QueryRow(`SELECT name, gpx_name, metadata, ftp FROM rides WHERE id = ?`, id).
+		Scan(&name, &gpxName, &metadata, &ftp)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ride, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	ride.Name = name.String
+	ride.GPXName = gpxName.String
+	ride.FTP = ftp.Float64
+	ride.Metadata = make(map[string]string)
+	if metadata.Valid {
+		if err := json.Unmarshal([]byte(metadata.String), &ride.Metadata); err != nil {
+			return nil, fmt.Errorf("parse metadata of %s: %w", id, err)
+		}
+	}
+	return ride, nil
+}
+
+// DeleteRide removes a ride from the database along with its files
+func (s *Store) DeleteRide(id string) error {
+	return s.withTx(func(tx *sql.Tx, files *fileSet) error {
+		res, err := tx.Exec(`DELETE FROM rides WHERE id = ?`, id)
+		if err != nil {
+			return err
 		}
+		if err := expectRow(res, id); err != nil {
+			return err
+		}
+		files.remove(s.jsonPath(id))
+		files.remove(s.GetFITPath(id))
+		return nil
+	})
+}
 
-		rides = append(rides, r)
+// RenameRide sets the name a ride is listed under. An empty name clears it.
+func (s *Store) RenameRide(id, name string) error {
+	ride, err := s.LoadRide(id)
+	if err != nil {
+		return err
 	}
+	ride.Name = name
+	return s.UpdateRide(ride)
+}
 
-	return rides, rows.Err()
+// SetMetadata sets a metadata entry of a ride. An empty value removes it.
+func (s *Store) SetMetadata(id, key, value string) error {
+	ride, err := s.LoadRide(id)
+	if err != nil {
+		return err
+	}
+	if ride.Metadata == nil {
+		ride.Metadata = make(map[string]string)
+	}
+	if value == "" {
+		delete(ride.Metadata, key)
+	} else {
+		ride.Metadata[key] = value
+	}
+	return s.UpdateRide(ride)
+}
+
+// readRideFile reads a ride from its JSON record, falling back to
+// decoding its FIT file, which fromFIT reports
+func readRideFile(dataDir, id string) (ride *Ride, fromFIT bool, err error) {
+	data, err := os.ReadFile(jsonPath(dataDir, id))
+	if err == nil {
+		var ride Ride
+		if err := json.Unmarshal(data, &ride); err != nil {
+			return nil, false, fmt.Errorf("parse ride %s: %w", id, err)
+		}
+		return &ride, false, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, false, err
+	}
+
+	f, err := os.Open(fitPath(dataDir, id))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, false, fmt.Errorf("%w: %s", ErrRideNotFound, id)
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	defer f.Close()
+
+	ride, err = DecodeFIT(f)
+	if err != nil {
+		return nil, false, fmt.Errorf("decode ride %s: %w", id, err)
+	}
+	ride.ID = id
+	return ride, true, nil
+}
+
+// jsonPath returns the path to a ride's full JSON record
+func (s *Store) jsonPath(rideID string) string {
+	return jsonPath(s.dataDir, rideID)
 }
 
 // GetFITPath returns the path to a ride's data file
 func (s *Store) GetFITPath(rideID string) string {
-	return filepath.Join(s.dataDir, "rides", rideID+".fit")
+	return fitPath(s.dataDir, rideID)
+}
+
+// jsonPath returns the path to a ride's JSON record in dataDir
+func jsonPath(dataDir, rideID string) string {
+	return filepath.Join(dataDir, "rides", rideID+".json")
+}
+
+// fitPath returns the path to a ride's FIT file in dataDir
+func fitPath(dataDir, rideID string) string {
+	return filepath.Join(dataDir, "rides", rideID+".fit")
 }
 
 // DefaultDataDir returns the default data directory
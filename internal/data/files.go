@@ -0,0 +1,88 @@
+package data
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// fileChange is a pending write or removal of a ride file
+type fileChange struct {
+	path    string
+	tmp     string // new content, empty for a removal
+	backup  string // previous content while the change is applied
+	applied bool
+}
+
+// fileSet stages ride file changes so they can be applied together with a
+// database transaction and undone if the transaction fails. New content is
+// written next to its target first; applying moves the old files aside
+// and the new ones into place.
+type fileSet struct {
+	changes []*fileChange
+}
+
+// write stages new content for path
+func (fs *fileSet) write(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	fs.changes = append(fs.changes, &fileChange{path: path, tmp: tmp})
+	return nil
+}
+
+// remove stages the removal of path, which need not exist
+func (fs *fileSet) remove(path string) {
+	fs.changes = append(fs.changes, &fileChange{path: path})
+}
+
+// apply moves the staged files into place, keeping the old ones as backups
+func (fs *fileSet) apply() error {
+	for _, c := range fs.changes {
+		backup := c.path + ".bak"
+		err := os.Rename(c.path, backup)
+		switch {
+		case err == nil:
+			c.backup = backup
+		case !errors.Is(err, os.ErrNotExist):
+			return fmt.Errorf("back up %s: %w", c.path, err)
+		}
+		c.applied = true
+
+		if c.tmp != "" {
+			if err := os.Rename(c.tmp, c.path); err != nil {
+				return fmt.Errorf("replace %s: %w", c.path, err)
+			}
+			c.tmp = ""
+		}
+	}
+	return nil
+}
+
+// rollback undoes applied changes and drops staged content
+func (fs *fileSet) rollback() {
+	for i := len(fs.changes) - 1; i >= 0; i-- {
+		c := fs.changes[i]
+		if c.tmp != "" {
+			os.Remove(c.tmp)
+		}
+		if !c.applied {
+			continue
+		}
+		if c.backup != "" {
+			os.Rename(c.backup, c.path)
+		} else {
+			os.Remove(c.path)
+		}
+	}
+}
+
+// commit drops the backups once the transaction has committed
+func (fs *fileSet) commit() {
+	for _, c := range fs.changes {
+		if c.backup != "" {
+			os.Remove(c.backup)
+		}
+	}
+}
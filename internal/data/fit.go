@@ -1,36 +1,478 @@
 package data
 
 import (
-	"encoding/json"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
 	"os"
+	"time"
 )
 
-// ExportFIT writes ride data to a file
-// Note: For MVP, this exports as JSON. FIT binary format can be added later.
+// FIT protocol constants
+const (
+	fitHeaderSize      = 14
+	fitProtocolVersion = 0x20 // 2.0
+	fitProfileVersion  = 2132 // 21.32
+
+	// fitEpochOffset is the FIT time origin (1989-12-31 00:00:00 UTC)
+	// in Unix seconds
+	fitEpochOffset = 631065600
+)
+
+// FIT base types
+const (
+	fitEnum    byte = 0x00
+	fitSint8   byte = 0x01
+	fitUint8   byte = 0x02
+	fitSint16  byte = 0x83
+	fitUint16  byte = 0x84
+	fitSint32  byte = 0x85
+	fitUint32  byte = 0x86
+	fitUint8z  byte = 0x0A
+	fitUint16z byte = 0x8B
+	fitUint32z byte = 0x8C
+	fitByte    byte = 0x0D
+)
+
+// FIT global message numbers
+const (
+	fitMesgFileID     uint16 = 0
+	fitMesgSession    uint16 = 18
+	fitMesgLap        uint16 = 19
+	fitMesgRecord     uint16 = 20
+	fitMesgEvent      uint16 = 21
+	fitMesgDeviceInfo uint16 = 23
+	fitMesgActivity   uint16 = 34
+)
+
+// FIT field numbers shared across messages
+const (
+	fitFieldTimestamp    byte = 253
+	fitFieldMessageIndex byte = 254
+)
+
+// FIT profile enum values
+const (
+	fitFileActivity          = 4
+	fitManufacturerDev       = 255
+	fitProductGoc            = 1
+	fitSportCycling          = 2
+	fitSubSportIndoorCycling = 6
+	fitSubSportVirtual       = 58
+	fitEventTimer            = 0
+	fitEventLap              = 9
+	fitEventSession          = 8
+	fitEventActivity         = 26
+	fitEventTypeStart        = 0
+	fitEventTypeStop         = 1
+	fitEventTypeStopAll      = 4
+	fitActivityManual        = 0
+	fitLapTriggerManual      = 0
+	fitSessionTriggerStop    = 0
+)
+
+// fitInvalid holds the "no value" marker for each base type
+var fitInvalid = map[byte]uint64{
+	fitEnum:    0xFF,
+	fitSint8:   0x7F,
+	fitUint8:   0xFF,
+	fitSint16:  0x7FFF,
+	fitUint16:  0xFFFF,
+	fitSint32:  0x7FFFFFFF,
+	fitUint32:  0xFFFFFFFF,
+	fitUint8z:  0x00,
+	fitUint16z: 0x0000,
+	fitUint32z: 0x00000000,
+	fitByte:    0xFF,
+}
+
+// fitSize returns the byte size of a numeric base type
+func fitSize(baseType byte) int {
+	switch baseType {
+	case fitSint16, fitUint16, fitUint16z:
+		return 2
+	case fitSint32, fitUint32, fitUint32z:
+		return 4
+	default:
+		return 1
+	}
+}
+
+// fitField is a single field of a FIT message holding its raw encoded value
+type fitField struct {
+	num      byte
+	baseType byte
+	value    uint64
+}
+
+// fitMessage is a FIT data message ready to be encoded
+type fitMessage struct {
+	global uint16
+	fields []fitField
+}
+
+// fitEncoder writes FIT definition and data messages
+type fitEncoder struct {
+	buf    bytes.Buffer
+	locals map[uint16]uint8     // global message -> local message type
+	defs   map[uint8][]fitField // last definition per local message type
+}
+
+func newFITEncoder() *fitEncoder {
+	return &fitEncoder{
+		locals: make(map[uint16]uint8),
+		defs:   make(map[uint8][]fitField),
+	}
+}
+
+// write encodes a message, emitting a definition first when needed
+func (e *fitEncoder) write(m fitMessage) {
+	local, ok := e.locals[m.global]
+	if !ok {
+		local = uint8(len(e.locals) % 16)
+		e.locals[m.global] = local
+	}
+
+	if !sameLayout(e.defs[local], m.fields) {
+		e.writeDefinition(local, m)
+		e.defs[local] = m.fields
+	}
+
+	e.buf.WriteByte(local & 0x0F)
+	for _, f := range m.fields {
+		var b [4]byte
+		binary.LittleEndian.PutUint32(b[:], uint32(f.value))
+		e.buf.Write(b[:fitSize(f.baseType)])
+	}
+}
+
+func (e *fitEncoder) writeDefinition(local uint8, m fitMessage) {
+	e.buf.WriteByte(0x40 | (local & 0x0F))
+	e.buf.WriteByte(0) // reserved
+	e.buf.WriteByte(0) // little endian
+	binary.Write(&e.buf, binary.LittleEndian, m.global)
+	e.buf.WriteByte(byte(len(m.fields)))
+	for _, f := range m.fields {
+		e.buf.Write([]byte{f.num, byte(fitSize(f.baseType)), f.baseType})
+	}
+}
+
+func sameLayout(a, b []fitField) bool {
+	if a == nil || len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].num != b[i].num || a[i].baseType != b[i].baseType {
+			return false
+		}
+	}
+	return true
+}
+
+// WriteTo writes the complete FIT file (header, records, CRC) to w
+func (e *fitEncoder) WriteTo(w io.Writer) (int64, error) {
+	records := e.buf.Bytes()
+
+	header := make([]byte, fitHeaderSize)
+	header[0] = fitHeaderSize
+	header[1] = fitProtocolVersion
+	binary.LittleEndian.PutUint16(header[2:4], fitProfileVersion)
+	binary.LittleEndian.PutUint32(header[4:8], uint32(len(records)))
+	copy(header[8:12], ".FIT")
+	binary.LittleEndian.PutUint16(header[12:14], fitCRC(0, header[:12]))
+
+	crc := fitCRC(fitCRC(0, header), records)
+	trailer := []byte{byte(crc), byte(crc >> 8)}
+
+	var total int64
+	for _, chunk := range [][]byte{header, records, trailer} {
+		n, err := w.Write(chunk)
+		total += int64(n)
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+var fitCRCTable = [16]uint16{
+	0x0000, 0xCC01, 0xD801, 0x1400, 0xF001, 0x3C00, 0x2800, 0xE401,
+	0xA001, 0x6C00, 0x7800, 0xB401, 0x5000, 0x9C01, 0x8801, 0x4400,
+}
+
+// fitCRC updates a FIT CRC-16 with the given bytes
+func fitCRC(crc uint16, data []byte) uint16 {
+	for _, b := range data {
+		tmp := fitCRCTable[crc&0xF]
+		crc = (crc >> 4) & 0x0FFF
+		crc = crc ^ tmp ^ fitCRCTable[b&0xF]
+
+		tmp = fitCRCTable[crc&0xF]
+		crc = (crc >> 4) & 0x0FFF
+		crc = crc ^ tmp ^ fitCRCTable[(b>>4)&0xF]
+	}
+	return crc
+}
+
+// Field value helpers
+
+func fitTime(t time.Time) uint64 {
+	return uint64(uint32(t.Unix() - fitEpochOffset))
+}
+
+// fitScaled encodes (v+offset)*scale, or the invalid marker if out of range
+func fitScaled(baseType byte, v, scale, offset float64) uint64 {
+	raw := math.Round((v + offset) * scale)
+	var min, max float64
+	switch baseType {
+	case fitSint16:
+		min, max = math.MinInt16, math.MaxInt16-1
+	case fitSint32:
+		min, max = math.MinInt32, math.MaxInt32-1
+	case fitUint8, fitEnum:
+		min, max = 0, math.MaxUint8-1
+	case fitUint16:
+		min, max = 0, math.MaxUint16-1
+	default:
+		min, max = 0, math.MaxUint32-1
+	}
+	if math.IsNaN(raw) || raw < min || raw > max {
+		return fitInvalid[baseType]
+	}
+	if raw < 0 {
+		return uint64(int64(raw)) & (1<<(8*fitSize(baseType)) - 1)
+	}
+	return uint64(raw)
+}
+
+// fitSemicircles converts degrees to FIT semicircles
+func fitSemicircles(deg float64) uint64 {
+	return fitScaled(fitSint32, deg, math.Pow(2, 31)/180, 0)
+}
+
+// ExportFIT writes ride data as a FIT activity file
 func ExportFIT(ride *Ride, path string) error {
-	// For MVP, export as JSON which is human-readable and importable
-	// FIT binary encoding can be added with a proper encoder library
-
-	export := struct {
-		ID        string      `json:"id"`
-		StartTime string      `json:"start_time"`
-		EndTime   string      `json:"end_time"`
-		GPXName   string      `json:"gpx_name,omitempty"`
-		Stats     RideStats   `json:"stats"`
-		Points    []RidePoint `json:"points"`
-	}{
-		ID:        ride.ID,
-		StartTime: ride.StartTime.Format("2006-01-02T15:04:05Z"),
-		EndTime:   ride.EndTime.Format("2006-01-02T15:04:05Z"),
-		GPXName:   ride.GPXName,
-		Stats:     ride.Stats(),
-		Points:    ride.Points,
-	}
-
-	data, err := json.MarshalIndent(export, "", "  ")
+	f, err := os.Create(path)
 	if err != nil {
 		return err
 	}
 
-	return os.WriteFile(path, data, 0644)
+	if err := EncodeFIT(ride, f); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// EncodeFIT encodes a ride as a FIT activity to w
+func EncodeFIT(ride *Ride, w io.Writer) error {
+	if len(ride.Points) == 0 {
+		return fmt.Errorf("ride has no points")
+	}
+
+	enc := newFITEncoder()
+	stats := ride.Stats()
+
+	start := ride.StartTime
+	end := ride.EndTime
+	if end.IsZero() {
+		end = ride.Points[len(ride.Points)-1].Timestamp
+	}
+	elapsed := end.Sub(start).Seconds()
+	timer := timerTime(ride.Points)
+
+	enc.write(fitMessage{global: fitMesgFileID, fields: []fitField{
+		{0, fitEnum, fitFileActivity},
+		{1, fitUint16, fitManufacturerDev},
+		{2, fitUint16, fitProductGoc},
+		{3, fitUint32z, uint64(uint32(start.Unix()))},
+		{4, fitUint32, fitTime(start)},
+	}})
+
+	enc.write(fitMessage{global: fitMesgDeviceInfo, fields: []fitField{
+		{fitFieldTimestamp, fitUint32, fitTime(start)},
+		{0, fitUint8, 0}, // device index: creator
+		{2, fitUint16, fitManufacturerDev},
+		{4, fitUint16, fitProductGoc},
+	}})
+
+	enc.write(fitEvent(start, fitEventTimer, fitEventTypeStart))
+
+	// Gaps stop the timer between the records around them
+	gaps := ride.Gaps
+	for _, p := range ride.Points {
+		for len(gaps) > 0 && !gaps[0].End.After(p.Timestamp) {
+			enc.write(fitEvent(gaps[0].Start, fitEventTimer, fitEventTypeStopAll))
+			enc.write(fitEvent(gaps[0].End, fitEventTimer, fitEventTypeStart))
+			gaps = gaps[1:]
+		}
+		enc.write(fitRecord(p))
+	}
+
+	enc.write(fitEvent(end, fitEventTimer, fitEventTypeStopAll))
+
+	var startLat, startLon uint64 = fitInvalid[fitSint32], fitInvalid[fitSint32]
+	if first := ride.Points[0]; first.Latitude != 0 || first.Longitude != 0 {
+		startLat, startLon = fitSemicircles(first.Latitude), fitSemicircles(first.Longitude)
+	}
+
+	hrAvg, hrMax := heartRateStats(ride.Points)
+
+	laps := ride.Laps()
+	for i, lap := range laps {
+		lapStats := lap.Stats()
+		lapHRAvg, lapHRMax := heartRateStats(lap.Points)
+		enc.write(fitMessage{global: fitMesgLap, fields: []fitField{
+			{fitFieldTimestamp, fitUint32, fitTime(lap.EndTime)},
+			{fitFieldMessageIndex, fitUint16, uint64(i)},
+			{0, fitEnum, fitEventLap},
+			{1, fitEnum, fitEventTypeStop},
+			{2, fitUint32, fitTime(lap.StartTime)},
+			{7, fitUint32, fitScaled(fitUint32, lap.EndTime.Sub(lap.StartTime).Seconds(), 1000, 0)},
+			{8, fitUint32, fitScaled(fitUint32, timerTime(lap.Points), 1000, 0)},
+			{9, fitUint32, fitScaled(fitUint32, lapStats.Distance-lap.Points[0].Distance, 100, 0)},
+			{13, fitUint16, fitScaled(fitUint16, lapStats.AvgSpeed/3.6, 1000, 0)},
+			{14, fitUint16, fitScaled(fitUint16, lapStats.MaxSpeed/3.6, 1000, 0)},
+			{15, fitUint8, fitScaled(fitUint8, lapHRAvg, 1, 0)},
+			{16, fitUint8, fitScaled(fitUint8, lapHRMax, 1, 0)},
+			{17, fitUint8, fitScaled(fitUint8, lapStats.AvgCadence, 1, 0)},
+			{19, fitUint16, fitScaled(fitUint16, lapStats.AvgPower, 1, 0)},
+			{20, fitUint16, fitScaled(fitUint16, lapStats.MaxPower, 1, 0)},
+			{21, fitUint16, fitScaled(fitUint16, lapStats.TotalAscent, 1, 0)},
+			{24, fitEnum, fitLapTriggerManual},
+			{25, fitEnum, fitSportCycling},
+		}})
+	}
+
+	subSport := uint64(fitSubSportIndoorCycling)
+	if ride.GPXName != "" {
+		subSport = fitSubSportVirtual
+	}
+
+	enc.write(fitMessage{global: fitMesgSession, fields: []fitField{
+		{fitFieldTimestamp, fitUint32, fitTime(end)},
+		{fitFieldMessageIndex, fitUint16, 0},
+		{0, fitEnum, fitEventSession},
+		{1, fitEnum, fitEventTypeStop},
+		{2, fitUint32, fitTime(start)},
+		{3, fitSint32, startLat},
+		{4, fitSint32, startLon},
+		{5, fitEnum, fitSportCycling},
+		{6, fitEnum, subSport},
+		{7, fitUint32, fitScaled(fitUint32, elapsed, 1000, 0)},
+		{8, fitUint32, fitScaled(fitUint32, timer, 1000, 0)},
+		{9, fitUint32, fitScaled(fitUint32, stats.Distance, 100, 0)},
+		{11, fitUint16, fitScaled(fitUint16, workKJ(ride.Points), 1, 0)}, // kcal ≈ kJ at ~24% efficiency
+		{14, fitUint16, fitScaled(fitUint16, stats.AvgSpeed/3.6, 1000, 0)},
+		{15, fitUint16, fitScaled(fitUint16, stats.MaxSpeed/3.6, 1000, 0)},
+		{16, fitUint8, fitScaled(fitUint8, hrAvg, 1, 0)},
+		{17, fitUint8, fitScaled(fitUint8, hrMax, 1, 0)},
+		{18, fitUint8, fitScaled(fitUint8, stats.AvgCadence, 1, 0)},
+		{20, fitUint16, fitScaled(fitUint16, stats.AvgPower, 1, 0)},
+		{21, fitUint16, fitScaled(fitUint16, stats.MaxPower, 1, 0)},
+		{22, fitUint16, fitScaled(fitUint16, stats.TotalAscent, 1, 0)},
+		{25, fitUint16, 0},
+		{26, fitUint16, uint64(len(laps))},
+		{28, fitEnum, fitSessionTriggerStop},
+	}})
+
+	_, offset := end.Zone()
+	enc.write(fitMessage{global: fitMesgActivity, fields: []fitField{
+		{fitFieldTimestamp, fitUint32, fitTime(end)},
+		{0, fitUint32, fitScaled(fitUint32, timer, 1000, 0)},
+		{1, fitUint16, 1},
+		{2, fitEnum, fitActivityManual},
+		{3, fitEnum, fitEventActivity},
+		{4, fitEnum, fitEventTypeStop},
+		{5, fitUint32, fitTime(end.Add(time.Duration(offset) * time.Second))},
+	}})
+
+	_, err := enc.WriteTo(w)
+	return err
+}
+
+func fitEvent(t time.Time, event, eventType uint64) fitMessage {
+	return fitMessage{global: fitMesgEvent, fields: []fitField{
+		{fitFieldTimestamp, fitUint32, fitTime(t)},
+		{0, fitEnum, event},
+		{1, fitEnum, eventType},
+		{3, fitUint32, 0}, // data: timer trigger manual
+		{4, fitUint8, 0},  // event group
+	}}
+}
+
+func fitRecord(p RidePoint) fitMessage {
+	lat, lon := fitInvalid[fitSint32], fitInvalid[fitSint32]
+	altitude := fitInvalid[fitUint16]
+	if p.Latitude != 0 || p.Longitude != 0 {
+		lat, lon = fitSemicircles(p.Latitude), fitSemicircles(p.Longitude)
+		altitude = fitScaled(fitUint16, p.Elevation, 5, 500)
+	}
+
+	heartRate := fitInvalid[fitUint8]
+	if p.HeartRate > 0 {
+		heartRate = fitScaled(fitUint8, float64(p.HeartRate), 1, 0)
+	}
+
+	return fitMessage{global: fitMesgRecord, fields: []fitField{
+		{fitFieldTimestamp, fitUint32, fitTime(p.Timestamp)},
+		{0, fitSint32, lat},
+		{1, fitSint32, lon},
+		{2, fitUint16, altitude},
+		{3, fitUint8, heartRate},
+		{4, fitUint8, fitScaled(fitUint8, p.Cadence, 1, 0)},
+		{5, fitUint32, fitScaled(fitUint32, p.Distance, 100, 0)},
+		{6, fitUint16, fitScaled(fitUint16, p.Speed/3.6, 1000, 0)},
+		{7, fitUint16, fitScaled(fitUint16, p.Power, 1, 0)},
+		{9, fitSint16, fitScaled(fitSint16, p.Gradient, 100, 0)},
+	}}
+}
+
+// timerTime sums the time between points, ignoring gaps longer than
+// a few seconds (pauses)
+func timerTime(points []RidePoint) float64 {
+	const maxGap = 5 * time.Second
+
+	var total time.Duration
+	for i := 1; i < len(points); i++ {
+		dt := points[i].Timestamp.Sub(points[i-1].Timestamp)
+		if dt > 0 && dt <= maxGap {
+			total += dt
+		}
+	}
+	return total.Seconds()
+}
+
+// workKJ integrates power over time in kilojoules
+func workKJ(points []RidePoint) float64 {
+	var joules float64
+	for i := 1; i < len(points); i++ {
+		dt := points[i].Timestamp.Sub(points[i-1].Timestamp).Seconds()
+		if dt > 0 && dt <= 5 {
+			joules += points[i].Power * dt
+		}
+	}
+	return joules / 1000
+}
+
+// heartRateStats returns average and max heart rate over points with HR data
+func heartRateStats(points []RidePoint) (avg, max float64) {
+	var total, n int
+	for _, p := range points {
+		if p.HeartRate <= 0 {
+			continue
+		}
+		total += p.HeartRate
+		n++
+		if float64(p.HeartRate) > max {
+			max = float64(p.HeartRate)
+		}
+	}
+	if n == 0 {
+		return math.NaN(), math.NaN()
+	}
+	return float64(total) / float64(n), max
 }
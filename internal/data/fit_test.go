@@ -1,6 +1,10 @@
 package data
 
 import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
 	"os"
 	"path/filepath"
 	"testing"
@@ -40,3 +44,294 @@ func TestExportFIT(t *testing.T) {
 	require.NoError(t, err)
 	assert.Greater(t, info.Size(), int64(0))
 }
+
+func TestExportFIT_NoPoints(t *testing.T) {
+	ride := NewRide()
+	ride.Finish()
+
+	err := ExportFIT(ride, filepath.Join(t.TempDir(), "empty.fit"))
+	assert.Error(t, err)
+}
+
+func TestEncodeFIT_RoundTrip(t *testing.T) {
+	start := time.Date(2025, 11, 20, 18, 30, 0, 0, time.UTC)
+	ride := &Ride{
+		ID:        "2025-11-20-183000",
+		StartTime: start,
+		EndTime:   start.Add(10 * time.Second),
+		GPXName:   "Test Route",
+	}
+	for i := 0; i < 10; i++ {
+		ride.AddPoint(RidePoint{
+			Timestamp: start.Add(time.Duration(i+1) * time.Second),
+			Power:     200 + float64(i*10),
+			Cadence:   90,
+			Speed:     36, // 10 m/s
+			Latitude:  45.0 + float64(i)*0.001,
+			Longitude: 7.5,
+			Elevation: 100 + float64(i),
+			Distance:  float64(i * 10),
+			HeartRate: 140 + i,
+			Gradient:  -2.5,
+		})
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, EncodeFIT(ride, &buf))
+
+	msgs, err := decodeTestFIT(buf.Bytes())
+	require.NoError(t, err)
+
+	// Message order: file_id, device_info, timer start, records, timer stop, lap, session, activity
+	require.GreaterOrEqual(t, len(msgs), 16)
+	assert.Equal(t, fitMesgFileID, msgs[0].global)
+	assert.Equal(t, uint64(4), msgs[0].fields[0]) // activity file
+
+	records := filterTestFIT(msgs, 20)
+	require.Len(t, records, 10)
+
+	for i, rec := range records {
+		p := ride.Points[i]
+		assert.Equal(t, uint64(p.Timestamp.Unix()-631065600), rec.fields[253], "timestamp %d", i)
+		assert.Equal(t, uint64(p.Power), rec.fields[7], "power %d", i)
+		assert.Equal(t, uint64(90), rec.fields[4])
+		assert.Equal(t, uint64(10000), rec.fields[6]) // 10 m/s * 1000
+		assert.Equal(t, uint64(p.Distance*100), rec.fields[5])
+		assert.Equal(t, uint64(p.HeartRate), rec.fields[3])
+		assert.InDelta(t, p.Elevation, float64(rec.fields[2])/5-500, 0.2)
+		assert.InDelta(t, p.Latitude, float64(int32(rec.fields[0]))*180/math.Pow(2, 31), 1e-6)
+		assert.InDelta(t, p.Longitude, float64(int32(rec.fields[1]))*180/math.Pow(2, 31), 1e-6)
+		assert.Equal(t, int16(-250), int16(rec.fields[9]))
+	}
+
+	events := filterTestFIT(msgs, 21)
+	require.Len(t, events, 2)
+	assert.Equal(t, uint64(0), events[0].fields[1]) // start
+	assert.Equal(t, uint64(4), events[1].fields[1]) // stop_all
+
+	laps := filterTestFIT(msgs, 19)
+	require.Len(t, laps, 1)
+	assert.Equal(t, uint64(245), laps[0].fields[19]) // avg power
+
+	sessions := filterTestFIT(msgs, 18)
+	require.Len(t, sessions, 1)
+	session := sessions[0]
+	assert.Equal(t, uint64(2), session.fields[5])      // cycling
+	assert.Equal(t, uint64(58), session.fields[6])     // virtual activity
+	assert.Equal(t, uint64(10000), session.fields[7])  // 10 s elapsed
+	assert.Equal(t, uint64(9000), session.fields[8])   // 9 s timer
+	assert.Equal(t, uint64(245), session.fields[20])   // avg power
+	assert.Equal(t, uint64(290), session.fields[21])   // max power
+	assert.Equal(t, uint64(149), session.fields[17])   // max HR
+	assert.Equal(t, uint64(1), session.fields[26])     // num laps
+	assert.Equal(t, uint64(90*100), session.fields[9]) // 90 m distance
+	assert.Equal(t, uint64(10000), session.fields[14]) // avg speed
+
+	activities := filterTestFIT(msgs, 34)
+	require.Len(t, activities, 1)
+	assert.Equal(t, uint64(1), activities[0].fields[1]) // one session
+	assert.Equal(t, msgs[len(msgs)-1].global, uint16(34))
+}
+
+func TestEncodeFIT_MissingValuesAreInvalid(t *testing.T) {
+	start := time.Date(2025, 11, 20, 18, 30, 0, 0, time.UTC)
+	ride := &Ride{StartTime: start, EndTime: start.Add(2 * time.Second)}
+	ride.AddPoint(RidePoint{Timestamp: start, Power: 150, Cadence: 80, Speed: 25})
+	ride.AddPoint(RidePoint{Timestamp: start.Add(time.Second), Power: 160, Cadence: 82, Speed: 26})
+
+	var buf bytes.Buffer
+	require.NoError(t, EncodeFIT(ride, &buf))
+
+	msgs, err := decodeTestFIT(buf.Bytes())
+	require.NoError(t, err)
+
+	records := filterTestFIT(msgs, 20)
+	require.Len(t, records, 2)
+	assert.Equal(t, uint64(0x7FFFFFFF), records[0].fields[0]) // no latitude
+	assert.Equal(t, uint64(0x7FFFFFFF), records[0].fields[1]) // no longitude
+	assert.Equal(t, uint64(0xFFFF), records[0].fields[2])     // no altitude
+	assert.Equal(t, uint64(0xFF), records[0].fields[3])       // no heart rate
+
+	session := filterTestFIT(msgs, 18)[0]
+	assert.Equal(t, uint64(6), session.fields[6])     // indoor cycling
+	assert.Equal(t, uint64(0xFF), session.fields[16]) // no avg HR
+}
+
+func TestEncodeFIT_DetectsCorruption(t *testing.T) {
+	start := time.Now()
+	ride := &Ride{StartTime: start}
+	ride.AddPoint(RidePoint{Timestamp: start, Power: 150})
+
+	var buf bytes.Buffer
+	require.NoError(t, EncodeFIT(ride, &buf))
+
+	data := buf.Bytes()
+	data[20] ^= 0xFF
+
+	_, err := decodeTestFIT(data)
+	assert.Error(t, err)
+}
+
+// Minimal FIT decoder used to verify the encoder. It deliberately shares
+// no code with the production encoder.
+
+type testFITMessage struct {
+	global uint16
+	fields map[byte]uint64
+}
+
+type testFITDefinition struct {
+	global    uint16
+	bigEndian bool
+	fields    [][2]byte // field number, size
+}
+
+func filterTestFIT(msgs []testFITMessage, global uint16) []testFITMessage {
+	var out []testFITMessage
+	for _, m := range msgs {
+		if m.global == global {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// testCRC is a bitwise CRC-16/ARC, the checksum FIT uses
+func testCRC(data []byte) uint16 {
+	var crc uint16
+	for _, b := range data {
+		crc ^= uint16(b)
+		for i := 0; i < 8; i++ {
+			if crc&1 != 0 {
+				crc = (crc >> 1) ^ 0xA001
+			} else {
+				crc >>= 1
+			}
+		}
+	}
+	return crc
+}
+
+func decodeTestFIT(data []byte) ([]testFITMessage, error) {
+	if len(data) < 14 || string(data[8:12]) != ".FIT" {
+		return nil, errors.New("not a FIT file")
+	}
+	headerSize := int(data[0])
+	dataSize := int(binary.LittleEndian.Uint32(data[4:8]))
+	if headerSize == 14 && binary.LittleEndian.Uint16(data[12:14]) != testCRC(data[:12]) {
+		return nil, errors.New("header CRC mismatch")
+	}
+	if len(data) != headerSize+dataSize+2 {
+		return nil, errors.New("size mismatch")
+	}
+	if binary.LittleEndian.Uint16(data[len(data)-2:]) != testCRC(data[:len(data)-2]) {
+		return nil, errors.New("file CRC mismatch")
+	}
+
+	defs := make(map[byte]testFITDefinition)
+	var msgs []testFITMessage
+
+	pos := headerSize
+	end := headerSize + dataSize
+	for pos < end {
+		header := data[pos]
+		pos++
+		if header&0x80 != 0 {
+			return nil, errors.New("compressed timestamps not expected")
+		}
+		local := header & 0x0F
+
+		if header&0x40 != 0 {
+			def := testFITDefinition{bigEndian: data[pos+1] == 1}
+			if def.bigEndian {
+				def.global = binary.BigEndian.Uint16(data[pos+2 : pos+4])
+			} else {
+				def.global = binary.LittleEndian.Uint16(data[pos+2 : pos+4])
+			}
+			n := int(data[pos+4])
+			pos += 5
+			for i := 0; i < n; i++ {
+				def.fields = append(def.fields, [2]byte{data[pos], data[pos+1]})
+				pos += 3
+			}
+			defs[local] = def
+			continue
+		}
+
+		def, ok := defs[local]
+		if !ok {
+			return nil, errors.New("data message without definition")
+		}
+		msg := testFITMessage{global: def.global, fields: make(map[byte]uint64)}
+		for _, f := range def.fields {
+			raw := data[pos : pos+int(f[1])]
+			pos += int(f[1])
+			var v uint64
+			for i := range raw {
+				b := raw[i]
+				if def.bigEndian {
+					b = raw[len(raw)-1-i]
+				}
+				v |= uint64(b) << (8 * i)
+			}
+			msg.fields[f[0]] = v
+		}
+		msgs = append(msgs, msg)
+	}
+
+	return msgs, nil
+}
+
+func TestEncodeFIT_GapStopsTimer(t *testing.T) {
+	start := time.Date(2025, 11, 20, 18, 30, 0, 0, time.UTC)
+	ride := &Ride{StartTime: start, EndTime: start.Add(60 * time.Second)}
+	ride.AddPoint(RidePoint{Timestamp: start.Add(time.Second), Power: 150})
+	ride.AddPoint(RidePoint{Timestamp: start.Add(2 * time.Second), Power: 150})
+	ride.AddPoint(RidePoint{Timestamp: start.Add(50 * time.Second), Power: 150})
+	ride.MarkGap(start.Add(2*time.Second), start.Add(49*time.Second))
+
+	var buf bytes.Buffer
+	require.NoError(t, EncodeFIT(ride, &buf))
+
+	msgs, err := decodeTestFIT(buf.Bytes())
+	require.NoError(t, err)
+
+	var sequence []string
+	for _, m := range msgs {
+		switch m.global {
+		case 20:
+			sequence = append(sequence, "record")
+		case 21:
+			sequence = append(sequence, map[uint64]string{0: "start", 4: "stop"}[m.fields[1]])
+		}
+	}
+	assert.Equal(t, []string{"start", "record", "record", "stop", "start", "record", "stop"}, sequence)
+
+	events := filterTestFIT(msgs, 21)
+	assert.Equal(t, uint64(start.Add(2*time.Second).Unix()-631065600), events[1].fields[253])
+	assert.Equal(t, uint64(start.Add(49*time.Second).Unix()-631065600), events[2].fields[253])
+}
+
+func TestEncodeFIT_WorkoutLaps(t *testing.T) {
+	start := time.Date(2025, 11, 20, 18, 30, 0, 0, time.UTC)
+	ride := &Ride{StartTime: start, EndTime: start.Add(4 * time.Second)}
+	for i, step := range []int{0, 0, 1, 1} {
+		ride.AddPoint(RidePoint{Timestamp: start.Add(time.Duration(i+1) * time.Second), Power: float64(100 + 100*step), Step: step})
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, EncodeFIT(ride, &buf))
+
+	msgs, err := decodeTestFIT(buf.Bytes())
+	require.NoError(t, err)
+
+	laps := filterTestFIT(msgs, 19)
+	require.Len(t, laps, 2)
+	assert.Equal(t, uint64(100), laps[0].fields[19]) // avg power
+	assert.Equal(t, uint64(200), laps[1].fields[19])
+	assert.Equal(t, uint64(1), laps[1].fields[254]) // message index
+
+	sessions := filterTestFIT(msgs, 18)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, uint64(2), sessions[0].fields[26]) // num laps
+}
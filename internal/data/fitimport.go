@@ -0,0 +1,401 @@
+package data
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// ErrDuplicateRide is returned when an imported ride already exists in the store
+var ErrDuplicateRide = errors.New("ride already exists")
+
+// fitDecodedMessage is a decoded FIT data message with raw field values.
+// Invalid values and fields that are not scalar are omitted.
+type fitDecodedMessage struct {
+	global uint16
+	fields map[byte]uint64
+	types  map[byte]byte
+}
+
+// value returns a field value and whether it is present
+func (m fitDecodedMessage) value(num byte) (uint64, bool) {
+	v, ok := m.fields[num]
+	return v, ok
+}
+
+// signed returns a field value interpreted as a signed integer
+func (m fitDecodedMessage) signed(num byte) (int64, bool) {
+	v, ok := m.fields[num]
+	if !ok {
+		return 0, false
+	}
+	switch fitSize(m.types[num]) {
+	case 1:
+		return int64(int8(v)), true
+	case 2:
+		return int64(int16(v)), true
+	default:
+		return int64(int32(v)), true
+	}
+}
+
+type fitFieldDef struct {
+	num      byte
+	size     byte
+	baseType byte
+}
+
+type fitDefinition struct {
+	global    uint16
+	bigEndian bool
+	fields    []fitFieldDef
+	devSize   int // total size of developer fields, skipped
+}
+
+// decodeFIT parses the records of a FIT file
+func decodeFIT(data []byte) ([]fitDecodedMessage, error) {
+	if len(data) < 12 {
+		return nil, errors.New("file too short for FIT header")
+	}
+	headerSize := int(data[0])
+	if headerSize < 12 || len(data) < headerSize || string(data[8:12]) != ".FIT" {
+		return nil, errors.New("not a FIT file")
+	}
+
+	dataSize := int(binary.LittleEndian.Uint32(data[4:8]))
+	end := headerSize + dataSize
+	if len(data) < end {
+		return nil, errors.New("FIT file truncated")
+	}
+	if len(data) >= end+2 {
+		want := binary.LittleEndian.Uint16(data[end : end+2])
+		if want != 0 && fitCRC(0, data[:end]) != want {
+			return nil, errors.New("FIT file CRC mismatch")
+		}
+	}
+
+	defs := make(map[byte]fitDefinition)
+	var msgs []fitDecodedMessage
+	var lastTimestamp uint32
+
+	pos := headerSize
+	for pos < end {
+		header := data[pos]
+		pos++
+
+		var local byte
+		compressedOffset := -1
+		if header&0x80 != 0 {
+			// Compressed timestamp header
+			local = (header >> 5) & 0x03
+			compressedOffset = int(header & 0x1F)
+		} else {
+			local = header & 0x0F
+		}
+
+		if header&0x80 == 0 && header&0x40 != 0 {
+			def, n, err := decodeFITDefinition(data[pos:end], header&0x20 != 0)
+			if err != nil {
+				return nil, err
+			}
+			defs[local] = def
+			pos += n
+			continue
+		}
+
+		def, ok := defs[local]
+		if !ok {
+			return nil, fmt.Errorf("data message for undefined local type %d", local)
+		}
+
+		msg := fitDecodedMessage{
+			global: def.global,
+			fields: make(map[byte]uint64),
+			types:  make(map[byte]byte),
+		}
+		for _, f := range def.fields {
+			if pos+int(f.size) > end {
+				return nil, errors.New("FIT record truncated")
+			}
+			raw := data[pos : pos+int(f.size)]
+			pos += int(f.size)
+
+			// Only scalar numeric fields are used
+			if int(f.size) != fitSize(f.baseType) {
+				continue
+			}
+			var v uint64
+			switch f.size {
+			case 1:
+				v = uint64(raw[0])
+			case 2:
+				if def.bigEndian {
+					v = uint64(binary.BigEndian.Uint16(raw))
+				} else {
+					v = uint64(binary.LittleEndian.Uint16(raw))
+				}
+			case 4:
+				if def.bigEndian {
+					v = uint64(binary.BigEndian.Uint32(raw))
+				} else {
+					v = uint64(binary.LittleEndian.Uint32(raw))
+				}
+			}
+			if invalid, known := fitInvalid[f.baseType]; known && v == invalid {
+				continue
+			}
+			msg.fields[f.num] = v
+			msg.types[f.num] = f.baseType
+		}
+		pos += def.devSize
+		if pos > end {
+			return nil, errors.New("FIT record truncated")
+		}
+
+		if ts, ok := msg.fields[fitFieldTimestamp]; ok {
+			lastTimestamp = uint32(ts)
+		} else if compressedOffset >= 0 {
+			ts := (lastTimestamp &^ 0x1F) + uint32(compressedOffset)
+			if uint32(compressedOffset) < lastTimestamp&0x1F {
+				ts += 0x20
+			}
+			lastTimestamp = ts
+			msg.fields[fitFieldTimestamp] = uint64(ts)
+			msg.types[fitFieldTimestamp] = fitUint32
+		}
+
+		msgs = append(msgs, msg)
+	}
+
+	return msgs, nil
+}
+
+// decodeFITDefinition parses a definition message body and returns it with
+// the number of bytes consumed
+func decodeFITDefinition(data []byte, hasDevFields bool) (fitDefinition, int, error) {
+	if len(data) < 5 {
+		return fitDefinition{}, 0, errors.New("FIT definition truncated")
+	}
+
+	def := fitDefinition{bigEndian: data[1] == 1}
+	if def.bigEndian {
+		def.global = binary.BigEndian.Uint16(data[2:4])
+	} else {
+		def.global = binary.LittleEndian.Uint16(data[2:4])
+	}
+
+	n := int(data[4])
+	pos := 5
+	if len(data) < pos+3*n {
+		return fitDefinition{}, 0, errors.New("FIT definition truncated")
+	}
+	for i := 0; i < n; i++ {
+		def.fields = append(def.fields, fitFieldDef{
+			num:      data[pos],
+			size:     data[pos+1],
+			baseType: data[pos+2],
+		})
+		pos += 3
+	}
+
+	if hasDevFields {
+		if len(data) < pos+1 {
+			return fitDefinition{}, 0, errors.New("FIT definition truncated")
+		}
+		nDev := int(data[pos])
+		pos++
+		if len(data) < pos+3*nDev {
+			return fitDefinition{}, 0, errors.New("FIT definition truncated")
+		}
+		for i := 0; i < nDev; i++ {
+			def.devSize += int(data[pos+1])
+			pos += 3
+		}
+	}
+
+	return def, pos, nil
+}
+
+func fromFITTime(v uint64) time.Time {
+	return time.Unix(int64(v)+fitEpochOffset, 0)
+}
+
+func fromSemicircles(v int64) float64 {
+	return float64(v) * 180 / math.Pow(2, 31)
+}
+
+// DecodeFIT reads a FIT activity into a Ride built from its record and
+// session messages
+func DecodeFIT(r io.Reader) (*Ride, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	msgs, err := decodeFIT(data)
+	if err != nil {
+		return nil, err
+	}
+
+	ride := &Ride{
+		Points:   make([]RidePoint, 0),
+		Metadata: make(map[string]string),
+	}
+
+	var sessionStart, sessionEnd time.Time
+	var sessionElapsed float64
+	sessions := 0
+
+	for _, m := range msgs {
+		switch m.global {
+		case fitMesgFileID:
+			if v, ok := m.value(1); ok {
+				ride.Metadata["manufacturer"] = strconv.FormatUint(v, 10)
+			}
+			if v, ok := m.value(2); ok {
+				ride.Metadata["product"] = strconv.FormatUint(v, 10)
+			}
+
+		case fitMesgSession:
+			sessions++
+			if v, ok := m.value(2); ok {
+				sessionStart = fromFITTime(v)
+			}
+			if v, ok := m.value(7); ok {
+				sessionElapsed = float64(v) / 1000
+			}
+			if v, ok := m.value(fitFieldTimestamp); ok {
+				sessionEnd = fromFITTime(v)
+			}
+
+		case fitMesgRecord:
+			if p, ok := decodeFITRecord(m); ok {
+				ride.Points = append(ride.Points, p)
+			}
+		}
+	}
+
+	if len(ride.Points) == 0 {
+		return nil, errors.New("FIT file has no records")
+	}
+	if sessions > 1 {
+		// A ride has one start and end; splitting the records is not supported
+		return nil, fmt.Errorf("FIT file has %d sessions, only single-session activities are supported", sessions)
+	}
+
+	fillDistance(ride.Points)
+
+	first := ride.Points[0].Timestamp
+	last := ride.Points[len(ride.Points)-1].Timestamp
+
+	ride.StartTime = first
+	if !sessionStart.IsZero() {
+		ride.StartTime = sessionStart
+	}
+	switch {
+	case sessionElapsed > 0:
+		ride.EndTime = ride.StartTime.Add(time.Duration(sessionElapsed * float64(time.Second)))
+	case !sessionEnd.IsZero():
+		ride.EndTime = sessionEnd
+	default:
+		ride.EndTime = last
+	}
+
+	ride.ID = ride.StartTime.Format("2006-01-02-150405")
+
+	return ride, nil
+}
+
+func decodeFITRecord(m fitDecodedMessage) (RidePoint, bool) {
+	ts, ok := m.value(fitFieldTimestamp)
+	if !ok {
+		return RidePoint{}, false
+	}
+
+	p := RidePoint{Timestamp: fromFITTime(ts)}
+
+	lat, hasLat := m.signed(0)
+	lon, hasLon := m.signed(1)
+	if hasLat && hasLon {
+		p.Latitude = fromSemicircles(lat)
+		p.Longitude = fromSemicircles(lon)
+	}
+	if v, ok := m.value(78); ok { // enhanced_altitude
+		p.Elevation = float64(v)/5 - 500
+	} else if v, ok := m.value(2); ok {
+		p.Elevation = float64(v)/5 - 500
+	}
+	if v, ok := m.value(3); ok {
+		p.HeartRate = int(v)
+	}
+	if v, ok := m.value(4); ok {
+		p.Cadence = float64(v)
+	}
+	if v, ok := m.value(5); ok {
+		p.Distance = float64(v) / 100
+	} else {
+		p.Distance = -1 // filled from speed later
+	}
+	if v, ok := m.value(73); ok { // enhanced_speed
+		p.Speed = float64(v) / 1000 * 3.6
+	} else if v, ok := m.value(6); ok {
+		p.Speed = float64(v) / 1000 * 3.6
+	}
+	if v, ok := m.value(7); ok {
+		p.Power = float64(v)
+	}
+	if v, ok := m.signed(9); ok {
+		p.Gradient = float64(v) / 100
+	}
+
+	return p, true
+}
+
+// fillDistance integrates speed for points whose record had no distance
+func fillDistance(points []RidePoint) {
+	var prev float64
+	for i := range points {
+		if points[i].Distance < 0 {
+			if i > 0 {
+				dt := points[i].Timestamp.Sub(points[i-1].Timestamp).Seconds()
+				prev += points[i].Speed / 3.6 * dt
+			}
+			points[i].Distance = prev
+		}
+		prev = points[i].Distance
+	}
+}
+
+// ImportFIT decodes a FIT activity file and saves it to the store.
+// Returns ErrDuplicateRide if a ride with the same start time already exists.
+func ImportFIT(store *Store, path string) (*Ride, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	ride, err := DecodeFIT(f)
+	if err != nil {
+		return nil, fmt.Errorf("decode %s: %w", filepath.Base(path), err)
+	}
+
+	ride.Metadata["source"] = "fit-import"
+	ride.Metadata["source_file"] = filepath.Base(path)
+
+	err = store.saveNewRide(ride)
+	if errors.Is(err, ErrDuplicateRide) {
+		return ride, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("save ride: %w", err)
+	}
+
+	return ride, nil
+}
@@ -0,0 +1,205 @@
+package data
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testImportRide(start time.Time) *Ride {
+	ride := &Ride{
+		ID:        start.Format("2006-01-02-150405"),
+		StartTime: start,
+		EndTime:   start.Add(5 * time.Second),
+	}
+	for i := 0; i < 5; i++ {
+		ride.AddPoint(RidePoint{
+			Timestamp: start.Add(time.Duration(i) * time.Second),
+			Power:     180 + float64(i),
+			Cadence:   85,
+			Speed:     30.6,
+			Latitude:  46.5,
+			Longitude: 8.25,
+			Elevation: 420.4,
+			Distance:  float64(i) * 8.5,
+			HeartRate: 130,
+		})
+	}
+	return ride
+}
+
+func TestDecodeFIT_RoundTrip(t *testing.T) {
+	start := time.Date(2025, 6, 1, 7, 0, 0, 0, time.UTC)
+	original := testImportRide(start)
+
+	var buf bytes.Buffer
+	require.NoError(t, EncodeFIT(original, &buf))
+
+	ride, err := DecodeFIT(&buf)
+	require.NoError(t, err)
+
+	assert.True(t, ride.StartTime.Equal(start))
+	assert.True(t, ride.EndTime.Equal(start.Add(5*time.Second)))
+	require.Len(t, ride.Points, 5)
+
+	for i, p := range ride.Points {
+		want := original.Points[i]
+		assert.True(t, p.Timestamp.Equal(want.Timestamp))
+		assert.Equal(t, want.Power, p.Power)
+		assert.Equal(t, want.Cadence, p.Cadence)
+		assert.InDelta(t, want.Speed, p.Speed, 0.01)
+		assert.InDelta(t, want.Distance, p.Distance, 0.01)
+		assert.InDelta(t, want.Latitude, p.Latitude, 1e-6)
+		assert.InDelta(t, want.Longitude, p.Longitude, 1e-6)
+		assert.InDelta(t, want.Elevation, p.Elevation, 0.2)
+		assert.Equal(t, want.HeartRate, p.HeartRate)
+	}
+	assert.Equal(t, "255", ride.Metadata["manufacturer"])
+}
+
+func TestDecodeFIT_CompressedTimestampsAndDeveloperFields(t *testing.T) {
+	var records bytes.Buffer
+
+	// Definition: local 0, record with timestamp and power, one developer field
+	records.Write([]byte{0x60, 0, 0})
+	binary.Write(&records, binary.LittleEndian, uint16(20))
+	records.Write([]byte{2, 253, 4, 0x86, 7, 2, 0x84})
+	records.Write([]byte{1, 0, 2, 0}) // developer field: num 0, size 2, index 0
+
+	base := uint32(1000000030) // low 5 bits = 30
+	records.WriteByte(0x00)
+	binary.Write(&records, binary.LittleEndian, base)
+	binary.Write(&records, binary.LittleEndian, uint16(200))
+	records.Write([]byte{0xAA, 0xBB}) // developer data, skipped
+
+	// Definition: local 1, record with power only (big endian)
+	records.Write([]byte{0x41, 0, 1})
+	binary.Write(&records, binary.BigEndian, uint16(20))
+	records.Write([]byte{1, 7, 2, 0x84})
+
+	// Compressed timestamp header: local 1, offset 2 (rolls over from 30)
+	records.WriteByte(0x80 | 1<<5 | 2)
+	binary.Write(&records, binary.BigEndian, uint16(210))
+
+	data := buildTestFITFile(records.Bytes())
+
+	ride, err := DecodeFIT(bytes.NewReader(data))
+	require.NoError(t, err)
+	require.Len(t, ride.Points, 2)
+
+	assert.Equal(t, 200.0, ride.Points[0].Power)
+	assert.Equal(t, 210.0, ride.Points[1].Power)
+	assert.Equal(t, 4*time.Second, ride.Points[1].Timestamp.Sub(ride.Points[0].Timestamp))
+}
+
+func TestDecodeFIT_Errors(t *testing.T) {
+	_, err := DecodeFIT(bytes.NewReader([]byte("not a fit file at all")))
+	assert.Error(t, err)
+
+	// Valid header, no records
+	_, err = DecodeFIT(bytes.NewReader(buildTestFITFile(nil)))
+	assert.Error(t, err)
+
+	// Corrupted CRC
+	var buf bytes.Buffer
+	require.NoError(t, EncodeFIT(testImportRide(time.Now()), &buf))
+	data := buf.Bytes()
+	data[len(data)-5] ^= 0xFF
+	_, err = DecodeFIT(bytes.NewReader(data))
+	assert.Error(t, err)
+}
+
+func TestDecodeFIT_MultipleSessions(t *testing.T) {
+	var records bytes.Buffer
+
+	// Definition: local 0, record with timestamp and power
+	records.Write([]byte{0x40, 0, 0})
+	binary.Write(&records, binary.LittleEndian, uint16(20))
+	records.Write([]byte{2, 253, 4, 0x86, 7, 2, 0x84})
+	records.WriteByte(0x00)
+	binary.Write(&records, binary.LittleEndian, uint32(1000000000))
+	binary.Write(&records, binary.LittleEndian, uint16(200))
+
+	// Definition: local 1, session with timestamp and start time
+	records.Write([]byte{0x41, 0, 0})
+	binary.Write(&records, binary.LittleEndian, uint16(18))
+	records.Write([]byte{2, 253, 4, 0x86, 2, 4, 0x86})
+	for _, start := range []uint32{1000000000, 1000003600} {
+		records.WriteByte(0x01)
+		binary.Write(&records, binary.LittleEndian, start+600)
+		binary.Write(&records, binary.LittleEndian, start)
+	}
+
+	_, err := DecodeFIT(bytes.NewReader(buildTestFITFile(records.Bytes())))
+	assert.ErrorContains(t, err, "2 sessions")
+}
+
+func TestImportFIT(t *testing.T) {
+	store, err := NewStore(t.TempDir())
+	require.NoError(t, err)
+	defer store.Close()
+
+	start := time.Date(2024, 3, 10, 9, 15, 0, 0, time.UTC)
+	path := filepath.Join(t.TempDir(), "morning.fit")
+	require.NoError(t, ExportFIT(testImportRide(start), path))
+
+	ride, err := ImportFIT(store, path)
+	require.NoError(t, err)
+	assert.Equal(t, "fit-import", ride.Metadata["source"])
+	assert.Equal(t, "morning.fit", ride.Metadata["source_file"])
+
+	rides, err := store.ListRides()
+	require.NoError(t, err)
+	require.Len(t, rides, 1)
+	assert.True(t, rides[0].StartTime.Equal(start))
+
+	var metadata string
+	require.NoError(t, store.db.QueryRow(`SELECT metadata FROM rides WHERE id = ?`, ride.ID).Scan(&metadata))
+	assert.Contains(t, metadata, `"source":"fit-import"`)
+
+	// Importing the same file again is skipped
+	_, err = ImportFIT(store, path)
+	assert.ErrorIs(t, err, ErrDuplicateRide)
+
+	// As is the same ride stored in another time zone
+	other := testImportRide(start.In(time.FixedZone("CET", 3600)))
+	other.ID = "other"
+	otherPath := filepath.Join(t.TempDir(), "other.fit")
+	require.NoError(t, ExportFIT(other, otherPath))
+	_, err = ImportFIT(store, otherPath)
+	assert.ErrorIs(t, err, ErrDuplicateRide)
+
+	rides, err = store.ListRides()
+	require.NoError(t, err)
+	assert.Len(t, rides, 1)
+}
+
+func TestImportFIT_MissingFile(t *testing.T) {
+	store, err := NewStore(t.TempDir())
+	require.NoError(t, err)
+	defer store.Close()
+
+	_, err = ImportFIT(store, filepath.Join(t.TempDir(), "missing.fit"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+// buildTestFITFile wraps raw records in a FIT header and CRC
+func buildTestFITFile(records []byte) []byte {
+	header := make([]byte, 14)
+	header[0] = 14
+	header[1] = 0x10
+	binary.LittleEndian.PutUint16(header[2:4], 2100)
+	binary.LittleEndian.PutUint32(header[4:8], uint32(len(records)))
+	copy(header[8:12], ".FIT")
+	binary.LittleEndian.PutUint16(header[12:14], testCRC(header[:12]))
+
+	data := append(header, records...)
+	crc := testCRC(data)
+	return append(data, byte(crc), byte(crc>>8))
+}
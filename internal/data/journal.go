@@ -0,0 +1,322 @@
+package data
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// journalFlushInterval bounds how much of a ride a crash can lose
+const journalFlushInterval = 5 * time.Second
+
+// errNoJournalHeader marks a journal cut short before its ride was written
+var errNoJournalHeader = errors.New("journal has no ride header")
+
+// journalEntry is one line of a ride journal. The first line holds the
+// ride without its points; every later line adds a point or a gap, or
+// pauses or resumes the ride.
+type journalEntry struct {
+	Ride    *Ride             `json:"ride,omitempty"`
+	Session map[string]string `json:"session,omitempty"` // how to resume riding
+	Point   *RidePoint        `json:"point,omitempty"`
+	Gap     *Gap              `json:"gap,omitempty"`
+	Paused  *bool             `json:"paused,omitempty"`
+}
+
+// Journal records a ride to disk as it is ridden, so a crash or power cut
+// loses at most a few seconds. It is an append-only file of JSON lines,
+// removed once the ride is saved.
+type Journal struct {
+	path    string
+	f       *os.File
+	w       *bufio.Writer
+	flushed time.Time
+}
+
+// UnfinishedRide is a ride whose journal was left behind
+type UnfinishedRide struct {
+	Ride    *Ride
+	Session map[string]string // as given to StartJournal
+}
+
+// StartJournal starts the journal of a new ride. session holds whatever
+// the caller needs to resume riding, such as the route file.
+func (s *Store) StartJournal(ride *Ride, session map[string]string) (*Journal, error) {
+	if err := os.MkdirAll(s.journalDir(), 0755); err != nil {
+		return nil, fmt.Errorf("create journal dir: %w", err)
+	}
+
+	path := s.journalPath(ride.ID)
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return nil, err
+	}
+	j := &Journal{path: path, f: f, w: bufio.NewWriter(f)}
+
+	header := *ride
+	header.Points, header.Gaps = nil, nil
+	if err := j.write(journalEntry{Ride: &header, Session: session}); err != nil {
+		f.Close()
+		return nil, err
+	}
+	for _, g := range ride.Gaps {
+		if err := j.write(journalEntry{Gap: &g}); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+	for _, p := range ride.Points {
+		if err := j.write(journalEntry{Point: &p}); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+	if err := j.Flush(); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return j, nil
+}
+
+// AddPoint appends a data point, flushing to disk every few seconds
+func (j *Journal) AddPoint(p RidePoint) error {
+	if err := j.write(journalEntry{Point: &p}); err != nil {
+		return err
+	}
+	if time.Since(j.flushed) >= journalFlushInterval {
+		return j.Flush()
+	}
+	return nil
+}
+
+// MarkGap appends a period without data
+func (j *Journal) MarkGap(start, end time.Time) error {
+	return j.write(journalEntry{Gap: &Gap{Start: start, End: end}})
+}
+
+// Pause records that the ride is paused. Like Ride.AddPoint, reading the
+// journal drops the points added until Resume.
+func (j *Journal) Pause() error {
+	paused := true
+	return j.write(journalEntry{Paused: &paused})
+}
+
+// Resume records that the ride is recording again
+func (j *Journal) Resume() error {
+	paused := false
+	return j.write(journalEntry{Paused: &paused})
+}
+
+func (j *Journal) write(e journalEntry) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("marshal journal entry: %w", err)
+	}
+	if _, err := j.w.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("write journal: %w", err)
+	}
+	return nil
+}
+
+// Flush writes buffered entries and syncs them to disk
+func (j *Journal) Flush() error {
+	if err := j.w.Flush(); err != nil {
+		return fmt.Errorf("write journal: %w", err)
+	}
+	if err := j.f.Sync(); err != nil {
+		return fmt.Errorf("sync journal: %w", err)
+	}
+	j.flushed = time.Now()
+	return nil
+}
+
+// Close flushes and closes the journal, leaving it to be recovered
+func (j *Journal) Close() error {
+	err := j.Flush()
+	if cerr := j.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// Discard closes and removes the journal once the ride is saved
+func (j *Journal) Discard() error {
+	j.f.Close()
+	if err := os.Remove(j.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// UnfinishedRides returns the rides left in journals, oldest first.
+// Journals without data points, or of rides saved before their journal
+// was removed, are cleaned up.
+func (s *Store) UnfinishedRides() ([]UnfinishedRide, error) {
+	entries, err := os.ReadDir(s.journalDir())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var rides []UnfinishedRide
+	for _, entry := range entries {
+		id, ok := strings.CutSuffix(entry.Name(), ".jsonl")
+		if entry.IsDir() || !ok {
+			continue
+		}
+
+		u, _, err := readJournal(s.journalPath(id))
+		if err != nil && !errors.Is(err, errNoJournalHeader) {
+			return nil, err
+		}
+		if err != nil || len(u.Ride.Points) == 0 || s.rideExists(id) {
+			if err := s.DiscardJournal(id); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		rides = append(rides, u)
+	}
+
+	sort.Slice(rides, func(i, k int) bool {
+		return rides[i].Ride.StartTime.Before(rides[k].Ride.StartTime)
+	})
+	return rides, nil
+}
+
+// RecoverRide saves the ride in a journal, ending it at its last data
+// point, and removes the journal
+func (s *Store) RecoverRide(id string) (*Ride, error) {
+	u, _, err := readJournal(s.journalPath(id))
+	if err != nil {
+		return nil, err
+	}
+	ride := u.Ride
+	if len(ride.Points) == 0 {
+		return nil, fmt.Errorf("ride %s has no data points", id)
+	}
+	ride.EndTime = ride.Points[len(ride.Points)-1].Timestamp
+	ride.Paused = false
+
+	if !s.rideExists(id) {
+		if err := s.SaveRide(ride); err != nil {
+			return nil, fmt.Errorf("save ride: %w", err)
+		}
+	}
+	return ride, s.DiscardJournal(id)
+}
+
+// ResumeJournal reopens a journal to continue its ride. A partly written
+// last entry is dropped.
+func (s *Store) ResumeJournal(id string) (UnfinishedRide, *Journal, error) {
+	path := s.journalPath(id)
+	u, size, err := readJournal(path)
+	if err != nil {
+		return u, nil, err
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY, 0644)
+	if err != nil {
+		return u, nil, err
+	}
+	if err := f.Truncate(size); err != nil {
+		f.Close()
+		return u, nil, fmt.Errorf("truncate journal: %w", err)
+	}
+	if _, err := f.Seek(size, io.SeekStart); err != nil {
+		f.Close()
+		return u, nil, err
+	}
+
+	j := &Journal{path: path, f: f, w: bufio.NewWriter(f), flushed: time.Now()}
+	if u.Ride.Paused {
+		if err := j.Resume(); err != nil {
+			f.Close()
+			return u, nil, err
+		}
+		u.Ride.Paused = false
+	}
+	return u, j, nil
+}
+
+// DiscardJournal removes a journal without saving its ride
+func (s *Store) DiscardJournal(id string) error {
+	if err := os.Remove(s.journalPath(id)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// readJournal reads a ride journal and returns the size of its complete
+// lines. Only a last line without its newline was cut short by a crash
+// and is left out; a damaged line before it is skipped.
+func readJournal(path string) (UnfinishedRide, int64, error) {
+	var u UnfinishedRide
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return u, 0, fmt.Errorf("%w: no journal %s", ErrRideNotFound, filepath.Base(path))
+	}
+	if err != nil {
+		return u, 0, err
+	}
+
+	var size int64
+	for len(data) > 0 {
+		end := bytes.IndexByte(data, '\n')
+		if end < 0 {
+			break // last line was never finished
+		}
+		line := data[:end]
+		data = data[end+1:]
+		size += int64(end + 1)
+
+		var e journalEntry
+		if err := json.Unmarshal(line, &e); err != nil {
+			continue // damaged, but the entries after it are whole
+		}
+
+		switch {
+		case e.Ride != nil:
+			u.Ride, u.Session = e.Ride, e.Session
+		case u.Ride == nil:
+			return u, 0, fmt.Errorf("%s: %w", filepath.Base(path), errNoJournalHeader)
+		case e.Paused != nil:
+			u.Ride.Paused = *e.Paused
+		case e.Point != nil:
+			u.Ride.AddPoint(*e.Point)
+		case e.Gap != nil:
+			u.Ride.Gaps = append(u.Ride.Gaps, *e.Gap)
+		}
+	}
+
+	if u.Ride == nil {
+		return u, 0, fmt.Errorf("%s: %w", filepath.Base(path), errNoJournalHeader)
+	}
+	return u, size, nil
+}
+
+// rideExists reports whether a ride is already in the database
+func (s *Store) rideExists(id string) bool {
+	_, err := s.GetRide(id)
+	return err == nil
+}
+
+func (s *Store) journalDir() string {
+	return filepath.Join(s.dataDir, "journal")
+}
+
+// journalPath returns the path to a ride's journal
+func (s *Store) journalPath(rideID string) string {
+	return filepath.Join(s.journalDir(), rideID+".jsonl")
+}
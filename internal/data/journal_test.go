@@ -0,0 +1,220 @@
+package data
+
+import (
+	"bufio"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// journalRide starts a journal and records n points a second apart
+func journalRide(t *testing.T, store *Store, n int) (*Ride, *Journal) {
+	t.Helper()
+	ride := NewRide()
+	ride.GPXName = "alpe.gpx"
+	ride.FTP = 250
+	j, err := store.StartJournal(ride, map[string]string{"route": "routes/alpe.gpx"})
+	require.NoError(t, err)
+
+	for i := range n {
+		p := RidePoint{Timestamp: ride.StartTime.Add(time.Duration(i) * time.Second), Power: 200, Distance: float64(i * 10)}
+		ride.AddPoint(p)
+		require.NoError(t, j.AddPoint(p))
+	}
+	return ride, j
+}
+
+func TestJournal_Recover(t *testing.T) {
+	store, err := NewStore(t.TempDir())
+	require.NoError(t, err)
+	defer store.Close()
+
+	ride, j := journalRide(t, store, 10)
+	gapStart := ride.StartTime.Add(3 * time.Second)
+	require.NoError(t, j.MarkGap(gapStart, gapStart.Add(time.Second)))
+	require.NoError(t, j.Close()) // the session ends without saving
+
+	unfinished, err := store.UnfinishedRides()
+	require.NoError(t, err)
+	require.Len(t, unfinished, 1)
+	u := unfinished[0]
+	assert.Equal(t, ride.ID, u.Ride.ID)
+	assert.Equal(t, "alpe.gpx", u.Ride.GPXName)
+	assert.Equal(t, 250.0, u.Ride.FTP)
+	assert.Len(t, u.Ride.Points, 10)
+	assert.Len(t, u.Ride.Gaps, 1)
+	assert.Equal(t, "routes/alpe.gpx", u.Session["route"])
+
+	recovered, err := store.RecoverRide(ride.ID)
+	require.NoError(t, err)
+	assert.True(t, ride.Points[9].Timestamp.Equal(recovered.EndTime))
+
+	summary, err := store.GetRide(ride.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 9*time.Second, summary.Duration)
+
+	unfinished, err = store.UnfinishedRides()
+	require.NoError(t, err)
+	assert.Empty(t, unfinished)
+}
+
+func TestJournal_Flush(t *testing.T) {
+	store, err := NewStore(t.TempDir())
+	require.NoError(t, err)
+	defer store.Close()
+
+	ride, j := journalRide(t, store, 3)
+	defer j.Discard()
+
+	// Points are buffered until the next flush
+	u, _, err := readJournal(store.journalPath(ride.ID))
+	require.NoError(t, err)
+	assert.Empty(t, u.Ride.Points)
+
+	require.NoError(t, j.Flush())
+	u, _, err = readJournal(store.journalPath(ride.ID))
+	require.NoError(t, err)
+	assert.Len(t, u.Ride.Points, 3)
+}
+
+func TestJournal_TornWrite(t *testing.T) {
+	store, err := NewStore(t.TempDir())
+	require.NoError(t, err)
+	defer store.Close()
+
+	ride, j := journalRide(t, store, 5)
+	require.NoError(t, j.Close())
+
+	// A crash in the middle of a write
+	f, err := os.OpenFile(store.journalPath(ride.ID), os.O_APPEND|os.O_WRONLY, 0644)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"point":{"Timestamp":"2025-`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	unfinished, err := store.UnfinishedRides()
+	require.NoError(t, err)
+	require.Len(t, unfinished, 1)
+	assert.Len(t, unfinished[0].Ride.Points, 5)
+
+	// Resuming drops the partial entry and appends after it
+	u, j, err := store.ResumeJournal(ride.ID)
+	require.NoError(t, err)
+	assert.Len(t, u.Ride.Points, 5)
+	last := u.Ride.Points[4]
+	require.NoError(t, j.AddPoint(RidePoint{Timestamp: last.Timestamp.Add(time.Minute), Power: 300}))
+	require.NoError(t, j.Close())
+
+	u, _, err = readJournal(store.journalPath(ride.ID))
+	require.NoError(t, err)
+	require.Len(t, u.Ride.Points, 6)
+	assert.Equal(t, 300.0, u.Ride.Points[5].Power)
+}
+
+func TestJournal_DamagedLine(t *testing.T) {
+	store, err := NewStore(t.TempDir())
+	require.NoError(t, err)
+	defer store.Close()
+
+	ride, j := journalRide(t, store, 3)
+	require.NoError(t, j.Close())
+
+	// A damaged line followed by whole entries
+	f, err := os.OpenFile(store.journalPath(ride.ID), os.O_APPEND|os.O_WRONLY, 0644)
+	require.NoError(t, err)
+	_, err = f.WriteString("{\"point\":{\"Timest\x00\n")
+	require.NoError(t, err)
+	j = &Journal{f: f, w: bufio.NewWriter(f)}
+	require.NoError(t, j.AddPoint(RidePoint{Timestamp: ride.StartTime.Add(3 * time.Second), Power: 300}))
+	require.NoError(t, j.Close())
+
+	// Resuming skips the damaged line and keeps what follows
+	u, j, err := store.ResumeJournal(ride.ID)
+	require.NoError(t, err)
+	require.Len(t, u.Ride.Points, 4)
+	assert.Equal(t, 300.0, u.Ride.Points[3].Power)
+	require.NoError(t, j.AddPoint(RidePoint{Timestamp: ride.StartTime.Add(4 * time.Second), Power: 310}))
+	require.NoError(t, j.Close())
+
+	u, _, err = readJournal(store.journalPath(ride.ID))
+	require.NoError(t, err)
+	require.Len(t, u.Ride.Points, 5)
+	assert.Equal(t, 310.0, u.Ride.Points[4].Power)
+}
+
+func TestJournal_Pause(t *testing.T) {
+	store, err := NewStore(t.TempDir())
+	require.NoError(t, err)
+	defer store.Close()
+
+	ride, j := journalRide(t, store, 5)
+	add := func(i int) {
+		p := RidePoint{Timestamp: ride.StartTime.Add(time.Duration(i) * time.Second), Power: float64(i)}
+		ride.AddPoint(p)
+		require.NoError(t, j.AddPoint(p))
+	}
+
+	// Points sent while paused are journaled but not kept
+	ride.Pause()
+	require.NoError(t, j.Pause())
+	for i := 5; i < 8; i++ {
+		add(i)
+	}
+	ride.Resume()
+	require.NoError(t, j.Resume())
+	add(8)
+	add(9)
+
+	// The session ends paused
+	ride.Pause()
+	require.NoError(t, j.Pause())
+	add(10)
+	require.NoError(t, j.Close())
+
+	u, _, err := readJournal(store.journalPath(ride.ID))
+	require.NoError(t, err)
+	require.Len(t, u.Ride.Points, len(ride.Points))
+	for i, p := range u.Ride.Points {
+		assert.True(t, ride.Points[i].Timestamp.Equal(p.Timestamp))
+	}
+	assert.True(t, u.Ride.Paused)
+
+	// Resuming the ride records again
+	u, j, err = store.ResumeJournal(ride.ID)
+	require.NoError(t, err)
+	assert.False(t, u.Ride.Paused)
+	assert.Len(t, u.Ride.Points, 7)
+	require.NoError(t, j.AddPoint(RidePoint{Timestamp: ride.StartTime.Add(11 * time.Second), Power: 11}))
+	require.NoError(t, j.Close())
+
+	recovered, err := store.RecoverRide(ride.ID)
+	require.NoError(t, err)
+	require.Len(t, recovered.Points, 8)
+	assert.Equal(t, 8.0, recovered.Points[5].Power)
+	assert.Equal(t, 11.0, recovered.Points[7].Power)
+}
+
+func TestJournal_Cleanup(t *testing.T) {
+	store, err := NewStore(t.TempDir())
+	require.NoError(t, err)
+	defer store.Close()
+
+	// A journal without points
+	empty, j := journalRide(t, store, 0)
+	require.NoError(t, j.Close())
+
+	// A ride saved before its journal was removed
+	saved, j := journalRide(t, store, 3)
+	require.NoError(t, j.Close())
+	saved.Finish()
+	require.NoError(t, store.SaveRide(saved))
+
+	unfinished, err := store.UnfinishedRides()
+	require.NoError(t, err)
+	assert.Empty(t, unfinished)
+	assert.NoFileExists(t, store.journalPath(empty.ID))
+	assert.NoFileExists(t, store.journalPath(saved.ID))
+}
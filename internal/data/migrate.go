@@ -0,0 +1,223 @@
+package data
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/thiemotorres/goc/internal/analytics"
+)
+
+// migration upgrades the database schema by one version. Migrations must
+// be idempotent: databases from before versioning may already have some
+// of their changes. dataDir holds the ride files.
+type migration struct {
+	description string
+	apply       func(tx *sql.Tx, dataDir string) error
+}
+
+// migrations in order, migrations[i] bringing the schema to version i+1.
+// Append new migrations; never change or reorder released ones.
+var migrations = []migration{
+	{"create rides table", func(tx *sql.Tx, _ string) error {
+		_, err := tx.Exec(`
+			CREATE TABLE IF NOT EXISTS rides (
+				id TEXT PRIMARY KEY,
+				start_time DATETIME,
+				end_time DATETIME,
+				duration_seconds INTEGER,
+				distance_meters REAL,
+				avg_power REAL,
+				max_power REAL,
+				avg_cadence REAL,
+				avg_speed REAL,
+				total_ascent REAL,
+				gpx_name TEXT,
+				metadata TEXT
+			)
+		`)
+		return err
+	}},
+	{"record rider FTP", func(tx *sql.Tx, _ string) error {
+		return addColumn(tx, "rides", "ftp", "REAL")
+	}},
+	{"record ride analytics", func(tx *sql.Tx, _ string) error {
+		for _, name := range []string{"normalized_power", "intensity_factor", "tss", "variability_index", "work_kj"} {
+			if err := addColumn(tx, "rides", name, "REAL"); err != nil {
+				return err
+			}
+		}
+		return nil
+	}},
+	{"add ride names", func(tx *sql.Tx, _ string) error {
+		return addColumn(tx, "rides", "name", "TEXT")
+	}},
+	{"store times as SQLite datetimes", rewriteTimes},
+	{"backfill ride analytics", backfillAnalytics},
+}
+
+// schemaVersion is the version the migrations bring a database to
+var schemaVersion = len(migrations)
+
+// migrate brings the database schema up to date, running each pending
+// migration in its own transaction and recording the version reached in
+// PRAGMA user_version
+func migrate(db *sql.DB, dataDir string) error {
+	version, err := userVersion(db)
+	if err != nil {
+		return err
+	}
+	if version > schemaVersion {
+		return fmt.Errorf("database schema version %d is newer than supported version %d", version, schemaVersion)
+	}
+
+	for v := version; v < schemaVersion; v++ {
+		m := migrations[v]
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		if err := m.apply(tx, dataDir); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d (%s): %w", v+1, m.description, err)
+		}
+		if _, err := tx.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, v+1)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d: set version: %w", v+1, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("migration %d: %w", v+1, err)
+		}
+	}
+	return nil
+}
+
+// userVersion returns the schema version recorded in the database
+func userVersion(db *sql.DB) (int, error) {
+	var version int
+	if err := db.QueryRow(`PRAGMA user_version`).Scan(&version); err != nil {
+		return 0, fmt.Errorf("read schema version: %w", err)
+	}
+	return version, nil
+}
+
+// rewriteTimes rewrites the ride times in sqliteTimeFormat. Earlier
+// versions stored them as Go formats them, which SQLite's date functions
+// can't read.
+func rewriteTimes(tx *sql.Tx, _ string) error {
+	type times struct {
+		id         string
+		start, end sql.NullTime
+	}
+
+	rows, err := tx.Query(`SELECT id, start_time, end_time FROM rides`)
+	if err != nil {
+		return err
+	}
+	var all []times
+	for rows.Next() {
+		var t times
+		if err := rows.Scan(&t.id, &t.start, &t.end); err != nil {
+			rows.Close()
+			return err
+		}
+		all = append(all, t)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, t := range all {
+		if _, err := tx.Exec(`UPDATE rides SET start_time = ?, end_time = ? WHERE id = ?`,
+			sqliteTime(t.start), sqliteTime(t.end), t.id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// backfillAnalytics analyses the rides saved before their analytics were
+// recorded from their ride files. Rides whose files are missing or
+// unreadable keep NULL analytics.
+func backfillAnalytics(tx *sql.Tx, dataDir string) error {
+	type pending struct {
+		id  string
+		ftp sql.NullFloat64
+	}
+
+	rows, err := tx.Query(`SELECT id, ftp FROM rides WHERE normalized_power IS NULL`)
+	if err != nil {
+		return err
+	}
+	var all []pending
+	for rows.Next() {
+		var p pending
+		if err := rows.Scan(&p.id, &p.ftp); err != nil {
+			rows.Close()
+			return err
+		}
+		all = append(all, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, p := range all {
+		ride, _, err := readRideFile(dataDir, p.id)
+		if err != nil {
+			continue
+		}
+		summary := analytics.Analyze(ride.PowerSeries(), p.ftp.Float64)
+		if _, err := tx.Exec(`
+			UPDATE rides SET normalized_power = ?, intensity_factor = ?, tss = ?,
+				variability_index = ?, work_kj = ?
+			WHERE id = ?`,
+			summary.NormalizedPower, summary.IntensityFactor, summary.TSS,
+			summary.VariabilityIndex, summary.Work, p.id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sqliteTime formats a time in sqliteTimeFormat, keeping NULL
+func sqliteTime(t sql.NullTime) any {
+	if !t.Valid {
+		return nil
+	}
+	return t.Time.Format(sqliteTimeFormat)
+}
+
+// addColumn adds a column to a table unless it already exists
+func addColumn(tx *sql.Tx, table, name, kind string) error {
+	rows, err := tx.Query(fmt.Sprintf(`PRAGMA table_info(%s)`, table))
+	if err != nil {
+		return err
+	}
+	exists := false
+	for rows.Next() {
+		var cid, notNull, pk int
+		var column, columnType string
+		var dflt sql.NullString
+		if err := rows.Scan(&cid, &column, &columnType, &notNull, &dflt, &pk); err != nil {
+			rows.Close()
+			return err
+		}
+		if column == name {
+			exists = true
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if exists {
+		return nil
+	}
+	if _, err := tx.Exec(fmt.Sprintf(`ALTER TABLE %s ADD COLUMN %s %s`, table, name, kind)); err != nil {
+		return fmt.Errorf("add column %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,203 @@
+package data
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thiemotorres/goc/internal/analytics"
+)
+
+// openFixture copies a history.db fixture and the ride files it refers
+// to into a fresh data directory
+func openFixture(t *testing.T, name string) string {
+	t.Helper()
+	dir := openFixtureDB(t, name)
+
+	rides, err := os.ReadDir("../../testdata/history/rides")
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "rides"), 0755))
+	for _, f := range rides {
+		data, err := os.ReadFile(filepath.Join("../../testdata/history/rides", f.Name()))
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "rides", f.Name()), data, 0644))
+	}
+	return dir
+}
+
+// openFixtureDB copies only a history.db fixture into a fresh data
+// directory
+func openFixtureDB(t *testing.T, name string) string {
+	t.Helper()
+	src, err := os.ReadFile(filepath.Join("../../testdata/history", name))
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "history.db"), src, 0644))
+	return dir
+}
+
+// columns lists the columns of the rides table, sorted
+func columns(t *testing.T, db *sql.DB) []string {
+	t.Helper()
+	rows, err := db.Query(`SELECT name FROM pragma_table_info('rides')`)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		require.NoError(t, rows.Scan(&name))
+		names = append(names, name)
+	}
+	require.NoError(t, rows.Err())
+	sort.Strings(names)
+	return names
+}
+
+func TestMigrate_Fixtures(t *testing.T) {
+	fresh, err := NewStore(t.TempDir())
+	require.NoError(t, err)
+	want := columns(t, fresh.db)
+	fresh.Close()
+
+	tests := []struct {
+		fixture  string
+		ftp      float64
+		np       float64 // 0 for analytics backfilled from the ride file
+		tss      float64
+		metadata string // JSON, empty for NULL
+	}{
+		{fixture: "v0-baseline.db"},
+		{fixture: "v0-analytics.db", ftp: 250, np: 200, tss: 64, metadata: `{"source":"fit-import"}`},
+		{fixture: "v2.db", ftp: 265},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			store, err := NewStore(openFixture(t, tt.fixture))
+			require.NoError(t, err)
+			defer store.Close()
+
+			version, err := userVersion(store.db)
+			require.NoError(t, err)
+			assert.Equal(t, schemaVersion, version)
+			assert.Equal(t, want, columns(t, store.db))
+
+			// Existing rides survive the upgrade
+			r, err := store.GetRide("2025-11-20-183000")
+			require.NoError(t, err)
+			assert.Equal(t, 2025, r.StartTime.Year())
+			assert.Equal(t, 30000.0, r.Distance)
+			assert.Equal(t, tt.ftp, r.FTP)
+			assert.Empty(t, r.Name)
+			if tt.np != 0 {
+				assert.Equal(t, tt.np, r.NormalizedPower)
+				assert.Equal(t, tt.tss, r.TSS)
+			} else {
+				ride, err := store.LoadRide(r.ID)
+				require.NoError(t, err)
+				summary := analytics.Analyze(ride.PowerSeries(), tt.ftp)
+				assert.Greater(t, r.NormalizedPower, 0.0)
+				assert.InDelta(t, summary.NormalizedPower, r.NormalizedPower, 1e-9)
+				assert.InDelta(t, summary.TSS, r.TSS, 1e-9)
+				assert.InDelta(t, summary.Work, r.Work, 1e-9)
+			}
+
+			var unix int64
+			require.NoError(t, store.db.QueryRow(`SELECT unixepoch(start_time) FROM rides`).Scan(&unix))
+			assert.Equal(t, r.StartTime.Unix(), unix)
+
+			var metadata sql.NullString
+			require.NoError(t, store.db.QueryRow(`SELECT metadata FROM rides`).Scan(&metadata))
+			if tt.metadata == "" {
+				assert.False(t, metadata.Valid)
+			} else {
+				assert.JSONEq(t, tt.metadata, metadata.String)
+			}
+
+			// New rides can be saved next to them
+			ride := NewRide()
+			ride.Name = "After upgrade"
+			ride.AddPoint(RidePoint{Timestamp: ride.StartTime, Power: 200})
+			ride.Finish()
+			require.NoError(t, store.SaveRide(ride))
+
+			rides, err := store.ListRides()
+			require.NoError(t, err)
+			assert.Len(t, rides, 2)
+		})
+	}
+}
+
+func TestMigrate_Idempotent(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewStore(dir)
+	require.NoError(t, err)
+	want := columns(t, store.db)
+
+	// Rerunning every migration, as for a database upgraded before
+	// versioning, changes nothing
+	_, err = store.db.Exec(`PRAGMA user_version = 0`)
+	require.NoError(t, err)
+	require.NoError(t, migrate(store.db, dir))
+	assert.Equal(t, want, columns(t, store.db))
+	require.NoError(t, store.Close())
+
+	// Reopening an up to date database is a no-op
+	store, err = NewStore(dir)
+	require.NoError(t, err)
+	defer store.Close()
+	assert.Equal(t, want, columns(t, store.db))
+}
+
+func TestMigrate_RewriteTimes(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewStore(dir)
+	require.NoError(t, err)
+	defer store.Close()
+
+	// As written by earlier versions, with Go's time formatting
+	_, err = store.db.Exec(`INSERT INTO rides (id, start_time, end_time) VALUES ('old', ?, NULL)`,
+		"2024-03-10 10:15:00.5 +0100 CET m=+0.012345678")
+	require.NoError(t, err)
+	_, err = store.db.Exec(`PRAGMA user_version = 4`)
+	require.NoError(t, err)
+	require.NoError(t, migrate(store.db, dir))
+
+	var start string
+	var unix int64
+	var end sql.NullString
+	require.NoError(t, store.db.QueryRow(`SELECT start_time || '', unixepoch(start_time), end_time FROM rides`).
+		Scan(&start, &unix, &end))
+	assert.Equal(t, "2024-03-10 10:15:00.5+01:00", start)
+	assert.Equal(t, int64(1710062100), unix)
+	assert.False(t, end.Valid)
+}
+
+func TestMigrate_BackfillWithoutRideFile(t *testing.T) {
+	store, err := NewStore(openFixtureDB(t, "v0-baseline.db"))
+	require.NoError(t, err)
+	defer store.Close()
+
+	// Nothing to analyse: the analytics stay unknown
+	var np sql.NullFloat64
+	require.NoError(t, store.db.QueryRow(`SELECT normalized_power FROM rides`).Scan(&np))
+	assert.False(t, np.Valid)
+}
+
+func TestMigrate_NewerVersion(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewStore(dir)
+	require.NoError(t, err)
+	_, err = store.db.Exec(`PRAGMA user_version = 1000`)
+	require.NoError(t, err)
+	require.NoError(t, store.Close())
+
+	_, err = NewStore(dir)
+	assert.ErrorContains(t, err, "newer than supported")
+}
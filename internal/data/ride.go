@@ -17,6 +17,7 @@ type RidePoint struct {
 	HeartRate  int // Optional, if HR monitor connected
 	Gradient   float64
 	GearString string
+	Step       int // Workout step index, a change starts a new lap
 }
 
 // RideStats contains computed statistics
@@ -38,10 +39,20 @@ type Ride struct {
 	EndTime   time.Time
 	Name      string
 	Points    []RidePoint
-	GPXName   string // Source GPX file name, if any
+	GPXName   string            // Source GPX file name, if any
+	Metadata  map[string]string // Free-form info such as import source
+	Gaps      []Gap             // Periods without trainer data
+	FTP       float64           // Rider FTP in effect, W, 0 if unknown
 	Paused    bool
 }
 
+// Gap is a period in which no data was recorded, such as a trainer
+// dropout. The ride continues across it.
+type Gap struct {
+	Start time.Time
+	End   time.Time
+}
+
 // NewRide creates a new ride recording
 func NewRide() *Ride {
 	return &Ride{
@@ -68,6 +79,13 @@ func (r *Ride) Resume() {
 	r.Paused = false
 }
 
+// MarkGap records a period without data
+func (r *Ride) MarkGap(start, end time.Time) {
+	if end.After(start) {
+		r.Gaps = append(r.Gaps, Gap{Start: start, End: end})
+	}
+}
+
 // Finish marks ride as complete
 func (r *Ride) Finish() {
 	r.EndTime = time.Now()
@@ -127,3 +145,71 @@ func (r *Ride) Stats() RideStats {
 		TotalAscent: totalAscent,
 	}
 }
+
+// maxHold is how long a power reading stands in for missing seconds.
+// Longer stretches without data, such as pauses and dropouts, are left
+// out of the power series.
+const maxHold = 5 * time.Second
+
+// PowerSeries returns the power for every second of the ride, for
+// analysis. Seconds without a point repeat the previous reading.
+func (r *Ride) PowerSeries() []float64 {
+	if len(r.Points) == 0 {
+		return nil
+	}
+
+	var watts []float64
+	j := 0
+	end := r.Points[len(r.Points)-1].Timestamp
+	for t := r.Points[0].Timestamp; !t.After(end); t = t.Add(time.Second) {
+		for j+1 < len(r.Points) && !r.Points[j+1].Timestamp.After(t) {
+			j++
+		}
+		if t.Sub(r.Points[j].Timestamp) > maxHold {
+			continue
+		}
+		watts = append(watts, r.Points[j].Power)
+	}
+	return watts
+}
+
+// Lap is a contiguous section of a ride
+type Lap struct {
+	StartTime time.Time
+	EndTime   time.Time
+	Points    []RidePoint
+}
+
+// Stats computes lap statistics
+func (l Lap) Stats() RideStats {
+	r := Ride{StartTime: l.StartTime, EndTime: l.EndTime, Points: l.Points}
+	return r.Stats()
+}
+
+// Laps splits the ride into laps, starting a new lap whenever the workout
+// step changes. A ride without workout steps is a single lap.
+func (r *Ride) Laps() []Lap {
+	if len(r.Points) == 0 {
+		return nil
+	}
+
+	end := r.EndTime
+	if end.IsZero() {
+		end = r.Points[len(r.Points)-1].Timestamp
+	}
+
+	var laps []Lap
+	lapStart, first := r.StartTime, 0
+	for i := 1; i <= len(r.Points); i++ {
+		if i < len(r.Points) && r.Points[i].Step == r.Points[first].Step {
+			continue
+		}
+		lapEnd := end
+		if i < len(r.Points) {
+			lapEnd = r.Points[i].Timestamp
+		}
+		laps = append(laps, Lap{StartTime: lapStart, EndTime: lapEnd, Points: r.Points[first:i]})
+		lapStart, first = lapEnd, i
+	}
+	return laps
+}
@@ -36,3 +36,51 @@ func TestRide_Stats(t *testing.T) {
 	assert.Equal(t, 30.0, stats.AvgSpeed)
 	assert.Equal(t, 250.0, stats.MaxPower)
 }
+
+func TestRide_MarkGap(t *testing.T) {
+	ride := NewRide()
+	start := time.Now()
+
+	ride.MarkGap(start, start.Add(10*time.Second))
+	ride.MarkGap(start, start) // empty, ignored
+
+	assert.Len(t, ride.Gaps, 1)
+	assert.Equal(t, 10*time.Second, ride.Gaps[0].End.Sub(ride.Gaps[0].Start))
+}
+
+func TestRide_Laps(t *testing.T) {
+	start := time.Date(2025, 1, 5, 8, 0, 0, 0, time.UTC)
+	ride := &Ride{StartTime: start, EndTime: start.Add(6 * time.Second)}
+	for i, step := range []int{0, 0, 1, 1, 1, 2} {
+		ride.AddPoint(RidePoint{Timestamp: start.Add(time.Duration(i) * time.Second), Power: float64(100 * (step + 1)), Step: step})
+	}
+
+	laps := ride.Laps()
+	assert.Len(t, laps, 3)
+	assert.Equal(t, start, laps[0].StartTime)
+	assert.Equal(t, start.Add(2*time.Second), laps[0].EndTime)
+	assert.Equal(t, laps[0].EndTime, laps[1].StartTime)
+	assert.Len(t, laps[1].Points, 3)
+	assert.Equal(t, 200.0, laps[1].Stats().AvgPower)
+	assert.Equal(t, ride.EndTime, laps[2].EndTime)
+
+	// Without workout steps the ride is one lap
+	for i := range ride.Points {
+		ride.Points[i].Step = 0
+	}
+	assert.Len(t, ride.Laps(), 1)
+}
+
+func TestRide_PowerSeries(t *testing.T) {
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	ride := &Ride{StartTime: start}
+	ride.AddPoint(RidePoint{Timestamp: start, Power: 100})
+	ride.AddPoint(RidePoint{Timestamp: start.Add(1 * time.Second), Power: 200})
+	// A missed second repeats the previous reading
+	ride.AddPoint(RidePoint{Timestamp: start.Add(3 * time.Second), Power: 300})
+	// A minute-long pause is left out
+	ride.AddPoint(RidePoint{Timestamp: start.Add(63 * time.Second), Power: 400})
+
+	assert.Equal(t, []float64{100, 200, 200, 300, 300, 300, 300, 300, 300, 400}, ride.PowerSeries())
+	assert.Nil(t, (&Ride{}).PowerSeries())
+}
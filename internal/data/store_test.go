@@ -1,12 +1,15 @@
 package data
 
 import (
+	"database/sql"
+	"os"
 	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/thiemotorres/goc/internal/analytics"
 )
 
 func TestStore_SaveAndLoad(t *testing.T) {
@@ -49,4 +52,249 @@ func TestStore_GetRide(t *testing.T) {
 	// Get FIT path
 	fitPath := store.GetFITPath(ride.ID)
 	assert.True(t, filepath.IsAbs(fitPath))
+
+	summary, err := store.GetRide(ride.ID)
+	require.NoError(t, err)
+	assert.Equal(t, ride.ID, summary.ID)
+
+	_, err = store.GetRide("missing")
+	assert.ErrorIs(t, err, ErrRideNotFound)
+}
+
+func TestStore_FTP(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// A database from before the ftp column
+	db, err := sql.Open("sqlite", filepath.Join(tmpDir, "history.db"))
+	require.NoError(t, err)
+	_, err = db.Exec(`CREATE TABLE rides (
+		id TEXT PRIMARY KEY, start_time DATETIME, end_time DATETIME,
+		duration_seconds INTEGER, distance_meters REAL, avg_power REAL,
+		max_power REAL, avg_cadence REAL, avg_speed REAL, total_ascent REAL,
+		gpx_name TEXT, metadata TEXT)`)
+	require.NoError(t, err)
+	_, err = db.Exec(`INSERT INTO rides (id, start_time, duration_seconds, distance_meters, avg_power)
+		VALUES ('old', ?, 60, 500, 150)`, time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	store, err := NewStore(tmpDir)
+	require.NoError(t, err)
+	defer store.Close()
+
+	ride := NewRide()
+	ride.FTP = 265
+	ride.AddPoint(RidePoint{Timestamp: time.Now(), Power: 200, Cadence: 90, Speed: 30})
+	ride.Finish()
+	require.NoError(t, store.SaveRide(ride))
+
+	rides, err := store.ListRides()
+	require.NoError(t, err)
+	require.Len(t, rides, 2)
+	assert.Equal(t, 265.0, rides[0].FTP)
+	assert.Equal(t, 0.0, rides[1].FTP)
+}
+
+func TestStore_Analytics(t *testing.T) {
+	store, err := NewStore(t.TempDir())
+	require.NoError(t, err)
+	defer store.Close()
+
+	// Ten minutes at FTP
+	ride := NewRide()
+	ride.FTP = 250
+	start := time.Now()
+	for i := range 600 {
+		ride.AddPoint(RidePoint{Timestamp: start.Add(time.Duration(i) * time.Second), Power: 250})
+	}
+	ride.Finish()
+	require.NoError(t, store.SaveRide(ride))
+
+	rides, err := store.ListRides()
+	require.NoError(t, err)
+	require.Len(t, rides, 1)
+	r := rides[0]
+	assert.InDelta(t, 250, r.NormalizedPower, 0.01)
+	assert.InDelta(t, 1, r.IntensityFactor, 0.001)
+	assert.InDelta(t, 16.7, r.TSS, 0.1)
+	assert.InDelta(t, 1, r.VariabilityIndex, 0.001)
+	assert.InDelta(t, 150, r.Work, 0.01)
+}
+
+func TestRideSummary_Workload(t *testing.T) {
+	start := time.Now()
+	r := RideSummary{StartTime: start, Duration: time.Hour, NormalizedPower: 200, FTP: 250, TSS: 64}
+	assert.Equal(t, analytics.Workload{Time: start, TSS: 64}, r.Workload(200))
+
+	// Without a recorded FTP the fallback is used
+	r.FTP, r.TSS = 0, 0
+	assert.InDelta(t, 100, r.Workload(200).TSS, 1e-9)
+}
+
+func TestRideSummary_WorkloadLegacy(t *testing.T) {
+	// Rides saved before FTP and analytics were recorded still count
+	// towards training load once their analytics are backfilled
+	store, err := NewStore(openFixture(t, "v0-baseline.db"))
+	require.NoError(t, err)
+	defer store.Close()
+
+	rides, err := store.ListRides()
+	require.NoError(t, err)
+	require.Len(t, rides, 1)
+	r := rides[0]
+	assert.Zero(t, r.FTP)
+	w := r.Workload(200)
+	assert.Greater(t, w.TSS, 0.0)
+	assert.InDelta(t, analytics.TSS(r.Duration, r.NormalizedPower, 200), w.TSS, 1e-9)
+}
+
+func TestStore_LoadRide(t *testing.T) {
+	store, err := NewStore(t.TempDir())
+	require.NoError(t, err)
+	defer store.Close()
+
+	ride := NewRide()
+	ride.FTP = 250
+	start := ride.StartTime
+	for i := range 5 {
+		ride.AddPoint(RidePoint{Timestamp: start.Add(time.Duration(i) * time.Second), Power: 200, HeartRate: 140})
+	}
+	ride.Finish()
+	require.NoError(t, store.SaveRide(ride))
+
+	loaded, err := store.LoadRide(ride.ID)
+	require.NoError(t, err)
+	assert.Equal(t, ride.ID, loaded.ID)
+	assert.Equal(t, 250.0, loaded.FTP)
+	assert.Len(t, loaded.Points, 5)
+
+	// Without the JSON record the FIT file is decoded
+	require.NoError(t, os.Remove(store.jsonPath(ride.ID)))
+	loaded, err = store.LoadRide(ride.ID)
+	require.NoError(t, err)
+	assert.Equal(t, ride.ID, loaded.ID)
+	assert.Len(t, loaded.Points, 5)
+
+	_, err = store.LoadRide("missing")
+	assert.ErrorIs(t, err, ErrRideNotFound)
+}
+
+func TestStore_DeleteRide(t *testing.T) {
+	store, err := NewStore(t.TempDir())
+	require.NoError(t, err)
+	defer store.Close()
+
+	ride := NewRide()
+	ride.AddPoint(RidePoint{Timestamp: time.Now(), Power: 200})
+	ride.Finish()
+	require.NoError(t, store.SaveRide(ride))
+
+	require.NoError(t, store.DeleteRide(ride.ID))
+
+	rides, err := store.ListRides()
+	require.NoError(t, err)
+	assert.Empty(t, rides)
+	assert.NoFileExists(t, store.GetFITPath(ride.ID))
+	assert.NoFileExists(t, store.jsonPath(ride.ID))
+
+	assert.ErrorIs(t, store.DeleteRide(ride.ID), ErrRideNotFound)
+}
+
+func TestStore_UpdateRide(t *testing.T) {
+	store, err := NewStore(t.TempDir())
+	require.NoError(t, err)
+	defer store.Close()
+
+	ride := NewRide()
+	ride.AddPoint(RidePoint{Timestamp: ride.StartTime, Power: 200})
+	ride.Finish()
+	require.NoError(t, store.SaveRide(ride))
+
+	ride.AddPoint(RidePoint{Timestamp: ride.StartTime.Add(time.Second), Power: 400})
+	require.NoError(t, store.UpdateRide(ride))
+
+	summary, err := store.GetRide(ride.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 300.0, summary.AvgPower)
+
+	loaded, err := store.LoadRide(ride.ID)
+	require.NoError(t, err)
+	assert.Len(t, loaded.Points, 2)
+
+	missing := NewRide()
+	missing.ID = "missing"
+	missing.AddPoint(RidePoint{Timestamp: time.Now(), Power: 100})
+	assert.ErrorIs(t, store.UpdateRide(missing), ErrRideNotFound)
+	assert.NoFileExists(t, store.GetFITPath("missing"))
+	assert.NoFileExists(t, store.jsonPath("missing"))
+}
+
+func TestStore_RenameRide(t *testing.T) {
+	store, err := NewStore(t.TempDir())
+	require.NoError(t, err)
+	defer store.Close()
+
+	ride := NewRide()
+	ride.AddPoint(RidePoint{Timestamp: ride.StartTime, Power: 200})
+	ride.Finish()
+	require.NoError(t, store.SaveRide(ride))
+
+	require.NoError(t, store.RenameRide(ride.ID, "Morning spin"))
+
+	summary, err := store.GetRide(ride.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Morning spin", summary.Name)
+
+	loaded, err := store.LoadRide(ride.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Morning spin", loaded.Name)
+
+	assert.ErrorIs(t, store.RenameRide("missing", "x"), ErrRideNotFound)
+}
+
+func TestStore_SetMetadata(t *testing.T) {
+	store, err := NewStore(t.TempDir())
+	require.NoError(t, err)
+	defer store.Close()
+
+	ride := NewRide()
+	ride.Metadata = map[string]string{"source": "fit-import"}
+	ride.AddPoint(RidePoint{Timestamp: ride.StartTime, Power: 200})
+	ride.Finish()
+	require.NoError(t, store.SaveRide(ride))
+
+	require.NoError(t, store.SetMetadata(ride.ID, "bike", "road"))
+	require.NoError(t, store.SetMetadata(ride.ID, "source", ""))
+
+	// Read from the database alone, without the JSON record
+	require.NoError(t, os.Remove(store.jsonPath(ride.ID)))
+	loaded, err := store.LoadRide(ride.ID)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"bike": "road"}, loaded.Metadata)
+}
+
+func TestStore_SaveRideRollback(t *testing.T) {
+	store, err := NewStore(t.TempDir())
+	require.NoError(t, err)
+	defer store.Close()
+
+	ride := NewRide()
+	ride.AddPoint(RidePoint{Timestamp: ride.StartTime, Power: 200})
+	ride.Finish()
+	require.NoError(t, store.SaveRide(ride))
+
+	// A second ride with the same ID fails and leaves the first intact
+	dup := NewRide()
+	dup.ID = ride.ID
+	dup.AddPoint(RidePoint{Timestamp: ride.StartTime, Power: 100})
+	dup.AddPoint(RidePoint{Timestamp: ride.StartTime.Add(time.Second), Power: 100})
+	require.Error(t, store.SaveRide(dup))
+
+	loaded, err := store.LoadRide(ride.ID)
+	require.NoError(t, err)
+	assert.Len(t, loaded.Points, 1)
+
+	entries, err := os.ReadDir(filepath.Dir(store.GetFITPath(ride.ID)))
+	require.NoError(t, err)
+	assert.Len(t, entries, 2, "no staged or backup files left")
 }
@@ -0,0 +1,172 @@
+package data
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// TCX namespaces
+const (
+	tcxNamespace = "http://www.garmin.com/xmlschemas/TrainingCenterDatabase/v2"
+	tcxExtension = "http://www.garmin.com/xmlschemas/ActivityExtension/v2"
+)
+
+type tcxDatabase struct {
+	XMLName    xml.Name `xml:"TrainingCenterDatabase"`
+	Xmlns      string   `xml:"xmlns,attr"`
+	XmlnsExt   string   `xml:"xmlns:ns3,attr"`
+	Activities struct {
+		Activity tcxActivity `xml:"Activity"`
+	} `xml:"Activities"`
+}
+
+type tcxActivity struct {
+	Sport string   `xml:"Sport,attr"`
+	ID    string   `xml:"Id"`
+	Laps  []tcxLap `xml:"Lap"`
+}
+
+// tcxLap fields follow the order required by the TCX schema
+type tcxLap struct {
+	StartTime        string          `xml:"StartTime,attr"`
+	TotalTimeSeconds float64         `xml:"TotalTimeSeconds"`
+	DistanceMeters   float64         `xml:"DistanceMeters"`
+	MaximumSpeed     float64         `xml:"MaximumSpeed"`
+	Calories         int             `xml:"Calories"`
+	AverageHeartRate *tcxHeartRate   `xml:"AverageHeartRateBpm,omitempty"`
+	MaximumHeartRate *tcxHeartRate   `xml:"MaximumHeartRateBpm,omitempty"`
+	Intensity        string          `xml:"Intensity"`
+	Cadence          int             `xml:"Cadence,omitempty"`
+	TriggerMethod    string          `xml:"TriggerMethod"`
+	Track            []tcxTrackpoint `xml:"Track>Trackpoint"`
+	Extensions       tcxLapExtension `xml:"Extensions>ns3:LX"`
+}
+
+type tcxHeartRate struct {
+	Value int `xml:"Value"`
+}
+
+type tcxPosition struct {
+	Latitude  float64 `xml:"LatitudeDegrees"`
+	Longitude float64 `xml:"LongitudeDegrees"`
+}
+
+type tcxTrackpoint struct {
+	Time       string                 `xml:"Time"`
+	Position   *tcxPosition           `xml:"Position,omitempty"`
+	Altitude   float64                `xml:"AltitudeMeters"`
+	Distance   float64                `xml:"DistanceMeters"`
+	HeartRate  *tcxHeartRate          `xml:"HeartRateBpm,omitempty"`
+	Cadence    int                    `xml:"Cadence"`
+	Extensions tcxTrackpointExtension `xml:"Extensions>ns3:TPX"`
+}
+
+type tcxTrackpointExtension struct {
+	Speed float64 `xml:"ns3:Speed"` // m/s
+	Watts int     `xml:"ns3:Watts"`
+}
+
+type tcxLapExtension struct {
+	AvgSpeed float64 `xml:"ns3:AvgSpeed"` // m/s
+	AvgWatts int     `xml:"ns3:AvgWatts"`
+	MaxWatts int     `xml:"ns3:MaxWatts"`
+}
+
+// tcxTime formats a timestamp as TCX expects
+func tcxTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// ExportTCX writes a ride as a Garmin Training Center (TCX) file
+func ExportTCX(ride *Ride, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := EncodeTCX(ride, f); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// EncodeTCX encodes a ride as a TCX activity with one lap per workout step
+func EncodeTCX(ride *Ride, w io.Writer) error {
+	if len(ride.Points) == 0 {
+		return fmt.Errorf("ride has no points")
+	}
+
+	db := tcxDatabase{Xmlns: tcxNamespace, XmlnsExt: tcxExtension}
+	act := &db.Activities.Activity
+	act.Sport = "Biking"
+	act.ID = tcxTime(ride.StartTime)
+
+	for _, lap := range ride.Laps() {
+		act.Laps = append(act.Laps, tcxLapFrom(lap))
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(db); err != nil {
+		return fmt.Errorf("encode tcx: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func tcxLapFrom(lap Lap) tcxLap {
+	stats := lap.Stats()
+	l := tcxLap{
+		StartTime:        tcxTime(lap.StartTime),
+		TotalTimeSeconds: lap.EndTime.Sub(lap.StartTime).Seconds(),
+		MaximumSpeed:     stats.MaxSpeed / 3.6,
+		Intensity:        "Active",
+		Cadence:          int(stats.AvgCadence),
+		TriggerMethod:    "Manual",
+		Extensions: tcxLapExtension{
+			AvgSpeed: stats.AvgSpeed / 3.6,
+			AvgWatts: int(stats.AvgPower),
+			MaxWatts: int(stats.MaxPower),
+		},
+	}
+	if n := len(lap.Points); n > 0 {
+		l.DistanceMeters = lap.Points[n-1].Distance - lap.Points[0].Distance
+	}
+
+	var hrSum, hrCount, hrMax int
+	for _, p := range lap.Points {
+		tp := tcxTrackpoint{
+			Time:     tcxTime(p.Timestamp),
+			Altitude: p.Elevation,
+			Distance: p.Distance,
+			Cadence:  min(int(p.Cadence), 254),
+			Extensions: tcxTrackpointExtension{
+				Speed: p.Speed / 3.6,
+				Watts: int(p.Power),
+			},
+		}
+		if p.Latitude != 0 || p.Longitude != 0 {
+			tp.Position = &tcxPosition{Latitude: p.Latitude, Longitude: p.Longitude}
+		}
+		if p.HeartRate > 0 {
+			tp.HeartRate = &tcxHeartRate{Value: p.HeartRate}
+			hrSum += p.HeartRate
+			hrCount++
+			hrMax = max(hrMax, p.HeartRate)
+		}
+		l.Track = append(l.Track, tp)
+	}
+	if hrCount > 0 {
+		l.AverageHeartRate = &tcxHeartRate{Value: hrSum / hrCount}
+		l.MaximumHeartRate = &tcxHeartRate{Value: hrMax}
+	}
+	return l
+}
@@ -0,0 +1,74 @@
+package data
+
+import (
+	"bytes"
+	"encoding/xml"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeTCX(t *testing.T) {
+	start := time.Date(2025, 11, 20, 18, 30, 0, 0, time.UTC)
+	ride := &Ride{ID: "2025-11-20-183000", StartTime: start, EndTime: start.Add(4 * time.Second)}
+	for i := range 4 {
+		ride.AddPoint(RidePoint{
+			Timestamp: start.Add(time.Duration(i) * time.Second),
+			Power:     200,
+			Cadence:   90,
+			Speed:     36,
+			HeartRate: 150,
+			Distance:  float64(i * 10),
+			Latitude:  45.0,
+			Longitude: 7.0,
+			Step:      i / 2, // two laps
+		})
+	}
+	ride.Points[3].Latitude, ride.Points[3].Longitude = 0, 0
+
+	var buf bytes.Buffer
+	require.NoError(t, EncodeTCX(ride, &buf))
+
+	var db struct {
+		Laps []struct {
+			StartTime string  `xml:"StartTime,attr"`
+			Seconds   float64 `xml:"TotalTimeSeconds"`
+			Points    []struct {
+				Time     string   `xml:"Time"`
+				Lat      *float64 `xml:"Position>LatitudeDegrees"`
+				HR       int      `xml:"HeartRateBpm>Value"`
+				Speed    float64  `xml:"Extensions>TPX>Speed"`
+				Watts    int      `xml:"Extensions>TPX>Watts"`
+				Distance float64  `xml:"DistanceMeters"`
+			} `xml:"Track>Trackpoint"`
+		} `xml:"Activities>Activity>Lap"`
+	}
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &db))
+
+	require.Len(t, db.Laps, 2)
+	assert.Equal(t, "2025-11-20T18:30:00Z", db.Laps[0].StartTime)
+	assert.Equal(t, 2.0, db.Laps[0].Seconds)
+	require.Len(t, db.Laps[1].Points, 2)
+
+	p := db.Laps[0].Points[1]
+	assert.Equal(t, "2025-11-20T18:30:01Z", p.Time)
+	assert.Equal(t, 150, p.HR)
+	assert.Equal(t, 200, p.Watts)
+	assert.InDelta(t, 10, p.Speed, 1e-9)
+	assert.Equal(t, 10.0, p.Distance)
+	require.NotNil(t, p.Lat)
+
+	// Points without a position leave it out
+	assert.Nil(t, db.Laps[1].Points[1].Lat)
+}
+
+func TestExportTCX_NoPoints(t *testing.T) {
+	ride := NewRide()
+	ride.Finish()
+
+	err := ExportTCX(ride, filepath.Join(t.TempDir(), "empty.tcx"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,128 @@
+package simulation
+
+import (
+	"fmt"
+	"math"
+	"slices"
+)
+
+// WheelSize is a tyre size with its rolling circumference in meters
+type WheelSize struct {
+	Name          string
+	Circumference float64
+}
+
+// WheelSizes are typical rolling circumferences by tyre size
+var WheelSizes = []WheelSize{
+	{"700x23c", 2.096},
+	{"700x25c", 2.105},
+	{"700x28c", 2.136},
+	{"700x32c", 2.155},
+	{"700x35c", 2.168},
+	{"700x40c", 2.200},
+	{"650bx47", 2.100},
+	{"26x2.1", 2.068},
+	{"27.5x2.2", 2.170},
+	{"29x2.2", 2.298},
+	{"29x2.4", 2.326},
+}
+
+// LookupWheelSize returns the circumference of the named tyre size
+func LookupWheelSize(name string) (float64, error) {
+	for _, w := range WheelSizes {
+		if w.Name == name {
+			return w.Circumference, nil
+		}
+	}
+	return 0, fmt.Errorf("%w %q", ErrUnknownPreset, name)
+}
+
+// MatchWheelSize returns the tyre size with the given circumference
+func MatchWheelSize(circumference float64) (WheelSize, bool) {
+	for _, w := range WheelSizes {
+		if math.Abs(w.Circumference-circumference) < 0.0005 {
+			return w, true
+		}
+	}
+	return WheelSize{}, false
+}
+
+// Drivetrain is a named chainring and cassette combination with its
+// wheel size. WheelCircumference, when set, overrides WheelSize.
+type Drivetrain struct {
+	Name               string
+	Chainrings         []int
+	Cassette           []int
+	WheelSize          string
+	WheelCircumference float64
+}
+
+var (
+	cassette11x28 = []int{11, 12, 13, 14, 15, 17, 19, 21, 23, 25, 28}
+	cassette11x25 = []int{11, 12, 13, 14, 15, 16, 17, 19, 21, 23, 25}
+	cassette11x30 = []int{11, 12, 13, 14, 15, 16, 17, 19, 21, 24, 27, 30}
+	cassette11x32 = []int{11, 12, 13, 14, 16, 18, 20, 22, 25, 28, 32}
+	cassette10x52 = []int{10, 12, 14, 16, 18, 21, 24, 28, 32, 36, 42, 52}
+)
+
+// DefaultDrivetrain is the preset a new config starts from
+const DefaultDrivetrain = "road-2x11"
+
+// Drivetrains are the built-in drivetrain presets
+var Drivetrains = []Drivetrain{
+	{Name: "road-2x11", Chainrings: []int{53, 39}, Cassette: cassette11x28, WheelSize: "700x25c"},
+	{Name: "road-2x12", Chainrings: []int{52, 36}, Cassette: cassette11x30, WheelSize: "700x28c"},
+	{Name: "compact", Chainrings: []int{50, 34}, Cassette: cassette11x32, WheelSize: "700x28c"},
+	{Name: "gravel-1x12", Chainrings: []int{40}, Cassette: cassette10x52, WheelSize: "700x40c"},
+	{Name: "mtb-1x12", Chainrings: []int{32}, Cassette: cassette10x52, WheelSize: "29x2.2"},
+	{Name: "tt", Chainrings: []int{55, 42}, Cassette: cassette11x25, WheelSize: "700x23c"},
+	{Name: "track", Chainrings: []int{48}, Cassette: []int{15}, WheelSize: "700x23c"},
+}
+
+// LookupDrivetrain returns the named drivetrain from the list
+func LookupDrivetrain(drivetrains []Drivetrain, name string) (Drivetrain, error) {
+	for _, d := range drivetrains {
+		if d.Name == name {
+			return d, nil
+		}
+	}
+	return Drivetrain{}, fmt.Errorf("%w %q", ErrUnknownPreset, name)
+}
+
+// Circumference returns the wheel circumference in meters
+func (d Drivetrain) Circumference() (float64, error) {
+	if d.WheelCircumference > 0 {
+		return d.WheelCircumference, nil
+	}
+	return LookupWheelSize(d.WheelSize)
+}
+
+// Matches reports whether the drivetrain has the given gearing and wheel
+func (d Drivetrain) Matches(chainrings, cassette []int, circumference float64) bool {
+	c, err := d.Circumference()
+	return err == nil && slices.Equal(d.Chainrings, chainrings) &&
+		slices.Equal(d.Cassette, cassette) && math.Abs(c-circumference) < 0.0005
+}
+
+// Validate checks that the drivetrain can be ridden
+func (d Drivetrain) Validate() error {
+	if d.Name == "" {
+		return fmt.Errorf("drivetrain: name required")
+	}
+	if len(d.Chainrings) == 0 || len(d.Cassette) == 0 {
+		return fmt.Errorf("drivetrain %s: chainrings and cassette required", d.Name)
+	}
+	for _, teeth := range append(slices.Clone(d.Chainrings), d.Cassette...) {
+		if teeth <= 0 {
+			return fmt.Errorf("drivetrain %s: %d teeth out of range", d.Name, teeth)
+		}
+	}
+	c, err := d.Circumference()
+	if err != nil {
+		return fmt.Errorf("drivetrain %s: wheel size: %w", d.Name, err)
+	}
+	if c > 3.5 {
+		return fmt.Errorf("drivetrain %s: wheel circumference %.3f m out of range (0-3.5)", d.Name, c)
+	}
+	return nil
+}
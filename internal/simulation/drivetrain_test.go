@@ -0,0 +1,58 @@
+package simulation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDrivetrains_Valid(t *testing.T) {
+	for _, d := range Drivetrains {
+		assert.NoError(t, d.Validate(), d.Name)
+	}
+}
+
+func TestLookupDrivetrain(t *testing.T) {
+	d, err := LookupDrivetrain(Drivetrains, "gravel-1x12")
+	require.NoError(t, err)
+	assert.Equal(t, []int{40}, d.Chainrings)
+	assert.Equal(t, 10, d.Cassette[0])
+	assert.Equal(t, 52, d.Cassette[len(d.Cassette)-1])
+
+	c, err := d.Circumference()
+	require.NoError(t, err)
+	assert.Equal(t, 2.2, c)
+
+	_, err = LookupDrivetrain(Drivetrains, "penny-farthing")
+	assert.ErrorIs(t, err, ErrUnknownPreset)
+}
+
+func TestDrivetrain_Circumference(t *testing.T) {
+	d := Drivetrain{Name: "custom", Chainrings: []int{46}, Cassette: []int{11, 34}, WheelSize: "700x28c", WheelCircumference: 2.25}
+	c, err := d.Circumference()
+	require.NoError(t, err)
+	assert.Equal(t, 2.25, c, "explicit circumference wins over wheel size")
+
+	d.WheelCircumference = 0
+	d.WheelSize = "fat"
+	assert.ErrorIs(t, d.Validate(), ErrUnknownPreset)
+}
+
+func TestDrivetrain_Matches(t *testing.T) {
+	d, err := LookupDrivetrain(Drivetrains, "track")
+	require.NoError(t, err)
+
+	assert.True(t, d.Matches([]int{48}, []int{15}, 2.096))
+	assert.False(t, d.Matches([]int{48}, []int{16}, 2.096))
+	assert.False(t, d.Matches([]int{48}, []int{15}, 2.105))
+}
+
+func TestMatchWheelSize(t *testing.T) {
+	w, ok := MatchWheelSize(2.105)
+	require.True(t, ok)
+	assert.Equal(t, "700x25c", w.Name)
+
+	_, ok = MatchWheelSize(2.0)
+	assert.False(t, ok)
+}
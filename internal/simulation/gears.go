@@ -2,12 +2,19 @@ package simulation
 
 import "fmt"
 
+// crossChainCogs is how many of the outermost cogs are avoided on the
+// outer chainrings when cross-chaining is prevented
+const crossChainCogs = 2
+
 // GearSystem manages virtual drivetrain
 type GearSystem struct {
 	chainrings []int
 	cassette   []int
 	frontIndex int
 	rearIndex  int
+
+	synchroCogs       int  // rear cogs to move on a front shift, 0 = off
+	preventCrossChain bool // keep off big-big and small-small combinations
 }
 
 // NewGearSystem creates a gear system with given chainrings and cassette
@@ -39,20 +46,101 @@ func (g *GearSystem) SetRear(index int) {
 	}
 }
 
+// SetSynchroShift makes each front shift move the rear the given number
+// of cogs the other way, like Shimano's semi-synchro shift. 0 turns it off.
+func (g *GearSystem) SetSynchroShift(cogs int) {
+	g.synchroCogs = max(cogs, 0)
+}
+
+// SetPreventCrossChain keeps the chain off the largest cogs on the big
+// chainring and off the smallest cogs on the small chainring
+func (g *GearSystem) SetPreventCrossChain(prevent bool) {
+	g.preventCrossChain = prevent
+	g.rearIndex = g.clampRear(g.rearIndex)
+}
+
 // ShiftUp shifts to a harder gear (smaller cog)
 func (g *GearSystem) ShiftUp() {
-	if g.rearIndex > 0 {
+	if g.rearIndex > 0 && !g.crossesChain(g.rearIndex-1) {
 		g.rearIndex--
 	}
 }
 
 // ShiftDown shifts to an easier gear (larger cog)
 func (g *GearSystem) ShiftDown() {
-	if g.rearIndex < len(g.cassette)-1 {
+	if g.rearIndex < len(g.cassette)-1 && !g.crossesChain(g.rearIndex+1) {
 		g.rearIndex++
 	}
 }
 
+// crossesChain reports whether shifting to rear index i would move the
+// chain into a cross-chained combination
+func (g *GearSystem) crossesChain(i int) bool {
+	return g.clampRear(i) != i && g.clampRear(g.rearIndex) == g.rearIndex
+}
+
+// FrontShiftUp shifts to the next larger chainring (harder)
+func (g *GearSystem) FrontShiftUp() {
+	g.shiftFront(g.neighbourRing(1))
+}
+
+// FrontShiftDown shifts to the next smaller chainring (easier)
+func (g *GearSystem) FrontShiftDown() {
+	g.shiftFront(g.neighbourRing(-1))
+}
+
+// shiftFront moves to chainring i, then moves the rear for synchro shift
+// and cross-chain prevention
+func (g *GearSystem) shiftFront(i int) {
+	if i < 0 {
+		return
+	}
+	harder := g.chainrings[i] > g.chainrings[g.frontIndex]
+	g.frontIndex = i
+
+	// Move the rear the other way to soften the jump in ratio
+	rear := g.rearIndex
+	if harder {
+		rear += g.synchroCogs
+	} else {
+		rear -= g.synchroCogs
+	}
+	rear = max(0, min(rear, len(g.cassette)-1))
+	g.rearIndex = g.clampRear(rear)
+}
+
+// neighbourRing returns the index of the chainring next in size to the
+// current one, larger for dir > 0 and smaller for dir < 0, or -1 if none.
+// Chainrings may be listed in any order.
+func (g *GearSystem) neighbourRing(dir int) int {
+	current := g.chainrings[g.frontIndex]
+	best := -1
+	for i, teeth := range g.chainrings {
+		if dir > 0 && teeth > current && (best < 0 || teeth < g.chainrings[best]) {
+			best = i
+		}
+		if dir < 0 && teeth < current && (best < 0 || teeth > g.chainrings[best]) {
+			best = i
+		}
+	}
+	return best
+}
+
+// clampRear returns the rear index closest to i that doesn't cross-chain
+// on the current chainring
+func (g *GearSystem) clampRear(i int) int {
+	if !g.preventCrossChain || len(g.chainrings) < 2 || len(g.cassette) <= 2*crossChainCogs {
+		return i
+	}
+	switch {
+	case g.neighbourRing(1) < 0: // big ring, stay off the largest cogs
+		return min(i, len(g.cassette)-crossChainCogs-1)
+	case g.neighbourRing(-1) < 0: // small ring, stay off the smallest cogs
+		return max(i, crossChainCogs)
+	}
+	return i
+}
+
 // FrontIndex returns current front chainring index
 func (g *GearSystem) FrontIndex() int {
 	return g.frontIndex
@@ -50,3 +50,78 @@ func TestGearSystem_String(t *testing.T) {
 
 	assert.Equal(t, "50x15", gs.String())
 }
+
+func TestGearSystem_FrontShift(t *testing.T) {
+	gs := NewGearSystem([]int{50, 34}, []int{11, 13, 15, 17, 19, 21, 24, 28})
+	gs.SetRear(3)
+
+	gs.FrontShiftDown()
+	assert.Equal(t, 34, gs.Chainring())
+	assert.Equal(t, 3, gs.RearIndex()) // rear untouched without synchro shift
+
+	gs.FrontShiftDown() // already on the small ring
+	assert.Equal(t, 34, gs.Chainring())
+
+	gs.FrontShiftUp()
+	assert.Equal(t, 50, gs.Chainring())
+
+	// Chainrings listed small to large shift the same way
+	gs = NewGearSystem([]int{30, 39, 53}, []int{11, 13, 15})
+	gs.FrontShiftUp()
+	assert.Equal(t, 39, gs.Chainring())
+	gs.FrontShiftUp()
+	assert.Equal(t, 53, gs.Chainring())
+}
+
+func TestGearSystem_SynchroShift(t *testing.T) {
+	gs := NewGearSystem([]int{50, 34}, []int{11, 13, 15, 17, 19, 21, 24, 28})
+	gs.SetSynchroShift(2)
+	gs.SetFront(1)
+	gs.SetRear(3) // 34x17
+
+	// Onto the big ring the rear moves two cogs easier
+	gs.FrontShiftUp()
+	assert.Equal(t, "50x21", gs.String())
+
+	// and back two cogs harder
+	gs.FrontShiftDown()
+	assert.Equal(t, "34x17", gs.String())
+
+	// Limited by the end of the cassette
+	gs.SetRear(7)
+	gs.FrontShiftUp()
+	assert.Equal(t, "50x28", gs.String())
+}
+
+func TestGearSystem_PreventCrossChain(t *testing.T) {
+	gs := NewGearSystem([]int{50, 34}, []int{11, 13, 15, 17, 19, 21, 24, 28})
+	gs.SetPreventCrossChain(true)
+
+	// Big ring stops short of the two largest cogs
+	gs.SetFront(0)
+	gs.SetRear(5)
+	gs.ShiftDown()
+	assert.Equal(t, "50x21", gs.String())
+
+	// Small ring stops short of the two smallest cogs
+	gs.SetFront(1)
+	gs.SetRear(2)
+	gs.ShiftUp()
+	assert.Equal(t, "34x15", gs.String())
+
+	// A front shift into a cross-chained gear moves the rear out of it
+	gs.SetRear(7)
+	gs.FrontShiftUp()
+	assert.Equal(t, "50x21", gs.String())
+
+	// Already cross-chained, shifting out is still possible
+	gs.SetRear(7)
+	gs.ShiftUp()
+	assert.Equal(t, "50x24", gs.String())
+
+	// Turning prevention on moves the rear out of a cross-chained gear
+	gs.SetPreventCrossChain(false)
+	gs.SetRear(7)
+	gs.SetPreventCrossChain(true)
+	assert.Equal(t, "50x21", gs.String())
+}
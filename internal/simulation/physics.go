@@ -1,6 +1,144 @@
 package simulation
 
-import "math"
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Default rider and bike parameters of the force model
+const (
+	DefaultCdA          = 0.30  // drag area (m²), riding on the hoods
+	DefaultCrr          = 0.005 // rolling resistance coefficient, road tyres on asphalt
+	DefaultBikeMass     = 10.0  // kg
+	DefaultFlywheelMass = 1.5   // kg, mass equivalent of the spinning wheels
+	SeaLevelAirDensity  = 1.225 // kg/m³ at 15 °C
+)
+
+// Physics holds the rider and bike parameters of the force model. They
+// are also handed to trainers that run their own simulation.
+type Physics struct {
+	CdA        float64 // drag coefficient × frontal area (m²)
+	Crr        float64 // rolling resistance coefficient
+	BikeMass   float64 // kg
+	AirDensity float64 // kg/m³
+
+	// FlywheelMass is the mass (kg) that stores as much kinetic energy as
+	// the rotating wheels. It adds inertia but not weight.
+	FlywheelMass float64
+}
+
+// DefaultPhysics returns the parameters of a road bike ridden on the
+// hoods at sea level
+func DefaultPhysics() Physics {
+	return Physics{
+		CdA:        DefaultCdA,
+		Crr:        DefaultCrr,
+		BikeMass:   DefaultBikeMass,
+		AirDensity: SeaLevelAirDensity,
+
+		FlywheelMass: DefaultFlywheelMass,
+	}
+}
+
+// withDefaults fills unset parameters with their defaults
+func (p Physics) withDefaults() Physics {
+	d := DefaultPhysics()
+	if p.CdA == 0 {
+		p.CdA = d.CdA
+	}
+	if p.Crr == 0 {
+		p.Crr = d.Crr
+	}
+	if p.BikeMass == 0 {
+		p.BikeMass = d.BikeMass
+	}
+	if p.AirDensity == 0 {
+		p.AirDensity = d.AirDensity
+	}
+	if p.FlywheelMass == 0 {
+		p.FlywheelMass = d.FlywheelMass
+	}
+	return p
+}
+
+// CW returns the wind resistance coefficient (kg/m): ½ × ρ × CdA
+func (p Physics) CW() float64 {
+	return 0.5 * p.AirDensity * p.CdA
+}
+
+// Validate checks that all parameters are physically plausible
+func (p Physics) Validate() error {
+	switch {
+	case p.CdA <= 0 || p.CdA > 1:
+		return fmt.Errorf("CdA %.3f m² out of range (0-1)", p.CdA)
+	case p.Crr <= 0 || p.Crr > 0.05:
+		return fmt.Errorf("Crr %.4f out of range (0-0.05)", p.Crr)
+	case p.BikeMass <= 0 || p.BikeMass > 50:
+		return fmt.Errorf("bike mass %.1f kg out of range (0-50)", p.BikeMass)
+	case p.AirDensity < 0.5 || p.AirDensity > 1.5:
+		return fmt.Errorf("air density %.3f kg/m³ out of range (0.5-1.5)", p.AirDensity)
+	case p.FlywheelMass < 0 || p.FlywheelMass > 20:
+		return fmt.Errorf("flywheel mass %.1f kg out of range (0-20)", p.FlywheelMass)
+	}
+	return nil
+}
+
+// Preset is a named parameter value
+type Preset struct {
+	Name  string
+	Value float64
+}
+
+// PositionPresets are typical drag areas (CdA, m²) by riding position
+var PositionPresets = []Preset{
+	{"tops", 0.40},
+	{"hoods", 0.30},
+	{"drops", 0.29},
+	{"tt", 0.24},
+}
+
+// TyrePresets are typical rolling resistance coefficients by tyre and surface
+var TyrePresets = []Preset{
+	{"race", 0.0035},
+	{"road", 0.005},
+	{"gravel", 0.008},
+	{"mtb", 0.012},
+}
+
+// ErrUnknownPreset is returned by LookupPreset for names not in the list
+var ErrUnknownPreset = errors.New("unknown preset")
+
+// LookupPreset returns the value of the named preset
+func LookupPreset(presets []Preset, name string) (float64, error) {
+	for _, p := range presets {
+		if p.Name == name {
+			return p.Value, nil
+		}
+	}
+	return 0, fmt.Errorf("%w %q", ErrUnknownPreset, name)
+}
+
+// AirDensity returns the density of dry air (kg/m³) at an altitude in
+// meters and a temperature in °C, using the standard barometric formula
+// for pressure
+func AirDensity(altitude, temperature float64) float64 {
+	pressure := 101325 * math.Pow(1-2.25577e-5*altitude, 5.25588) // Pa
+	return pressure / (287.05 * (temperature + 273.15))
+}
+
+// gravity is the standard acceleration due to gravity (m/s²)
+const gravity = 9.81
+
+// Integration limits for AdvanceSpeed
+const (
+	maxIntegrationStep = 0.1 // seconds
+	minDriveSpeed      = 1.0 // m/s
+)
+
+// referenceGearRatio is the physical gear the trainer is assumed to be
+// ridden in; virtual gears are expressed relative to it
+const referenceGearRatio = 2.5
 
 // CalculateSpeed computes speed in km/h from cadence, gear ratio, and wheel circumference
 // cadence: RPM
@@ -17,9 +155,9 @@ func CalculateSpeed(cadence, gearRatio, wheelCircumference float64) float64 {
 
 // CalculateResistance computes trainer resistance level (0-100) based on
 // speed, gradient, rider weight, and gear ratio using force-based physics model
-func CalculateResistance(speedKmh, gradientPercent, weightKg, gearRatio, scalingFactor float64) float64 {
+func CalculateResistance(speedKmh, gradientPercent, weightKg, gearRatio, scalingFactor float64, p Physics) float64 {
 	// Calculate total resistance force at wheel (Newtons)
-	wheelForce := CalculateWheelForce(speedKmh, gradientPercent, weightKg)
+	wheelForce := CalculateWheelForce(speedKmh, gradientPercent, weightKg, p)
 
 	// Apply gear ratio mechanical disadvantage
 	pedalForce := CalculatePedalForce(wheelForce, gearRatio)
@@ -34,25 +172,22 @@ func CalculateResistance(speedKmh, gradientPercent, weightKg, gearRatio, scaling
 // speedKmh: speed in km/h
 // gradientPercent: gradient in percent (positive = uphill)
 // weightKg: rider weight in kg
-func CalculateWheelForce(speedKmh, gradientPercent, weightKg float64) float64 {
+// p: drag area, rolling resistance, bike mass and air density
+func CalculateWheelForce(speedKmh, gradientPercent, weightKg float64, p Physics) float64 {
 	// Convert speed to m/s
 	speedMs := speedKmh / 3.6
 
 	// Air drag: F = 0.5 × ρ × Cd × A × v²
-	// ρ = 1.225 kg/m³ (air density at sea level)
-	// Cd × A ≈ 0.3 (drag coefficient × frontal area for cycling)
-	airDrag := 0.5 * 1.225 * 0.3 * speedMs * speedMs
+	airDrag := p.CW() * speedMs * speedMs
 
 	// Rolling resistance: F = Crr × m × g
-	// Crr = 0.005 (rolling coefficient for road tires)
-	// m = rider + bike mass (assume 10kg bike)
-	// g = 9.81 m/s²
-	totalMass := weightKg + 10.0
-	rollingForce := 0.005 * totalMass * 9.81
+	// m = rider + bike mass
+	totalMass := weightKg + p.BikeMass
+	rollingForce := p.Crr * totalMass * gravity
 
 	// Gradient resistance: F = m × g × sin(θ) ≈ m × g × (gradient/100)
 	// Using small angle approximation: sin(θ) ≈ tan(θ) = gradient/100
-	gradientForce := totalMass * 9.81 * (gradientPercent / 100.0)
+	gradientForce := totalMass * gravity * (gradientPercent / 100.0)
 
 	return airDrag + rollingForce + gradientForce
 }
@@ -84,3 +219,64 @@ func MapForceToResistance(pedalForce, scalingFactor float64) float64 {
 	resistance := pedalForce * scalingFactor
 	return math.Max(0, math.Min(100, resistance))
 }
+
+// CalculateEffectiveGrade computes the grade (percent) to send to a trainer
+// running its own simulation so that the current virtual gear is felt.
+// cadence: RPM
+// gradientPercent: route gradient in percent
+// weightKg: rider weight in kg
+// gearRatio: current virtual gear ratio
+// wheelCircumference: meters
+//
+// The trainer computes wheel force as if ridden in the reference gear. The
+// returned grade makes that force match the force of the virtual gear:
+// F_trainer(v_ref, grade') = F_wheel(v_virtual, grade) × gearRatio / referenceGearRatio
+func CalculateEffectiveGrade(cadence, gradientPercent, weightKg, gearRatio, wheelCircumference float64, p Physics) float64 {
+	virtualSpeed := CalculateSpeed(cadence, gearRatio, wheelCircumference)
+	trainerSpeed := CalculateSpeed(cadence, referenceGearRatio, wheelCircumference)
+
+	targetForce := CalculateWheelForce(virtualSpeed, gradientPercent, weightKg, p) * gearRatio / referenceGearRatio
+	flatForce := CalculateWheelForce(trainerSpeed, 0, weightKg, p)
+
+	totalMass := weightKg + p.BikeMass
+	grade := (targetForce - flatForce) / (totalMass * gravity) * 100
+
+	// Trainers cannot reproduce more than about ±25%
+	return math.Max(-25, math.Min(25, grade))
+}
+
+// AdvanceSpeed integrates the bike's equation of motion over dt seconds
+// and returns the new speed in km/h.
+// speedKmh: current speed in km/h
+// power: rider power in watts
+// gradientPercent: gradient in percent (positive = uphill)
+// weightKg: rider weight in kg
+//
+// Physics: the rider's driving force P/v minus the resisting forces
+// accelerates the total mass, so kinetic energy is gained or lost
+// (m + m_fw) × dv/dt = P/v − (air drag + rolling resistance + m × g × sin(θ))
+// where m_fw is the flywheel-equivalent mass of the rotating wheels. With
+// no power the bike coasts: it slows on the flat, speeds up downhill and
+// carries its speed into the next rise.
+// The driving force is capped below minDriveSpeed, where P/v diverges.
+func AdvanceSpeed(speedKmh, power, gradientPercent, weightKg, dt float64, p Physics) float64 {
+	totalMass := weightKg + p.BikeMass
+	theta := math.Atan(gradientPercent / 100)
+	rollingForce := p.Crr * totalMass * gravity * math.Cos(theta)
+	gradientForce := totalMass * gravity * math.Sin(theta)
+	inertialMass := totalMass + p.FlywheelMass
+
+	v := speedKmh / 3.6
+	for dt > 0 {
+		step := math.Min(dt, maxIntegrationStep)
+		dt -= step
+
+		driveForce := power / math.Max(v, minDriveSpeed)
+		airDrag := p.CW() * v * v
+		v += (driveForce - airDrag - rollingForce - gradientForce) / inertialMass * step
+
+		// The bike doesn't roll backwards
+		v = math.Max(0, v)
+	}
+	return v * 3.6
+}
@@ -21,7 +21,7 @@ func TestCalculateSpeed_Zero(t *testing.T) {
 
 func TestCalculateResistance_Flat(t *testing.T) {
 	// Flat ground, 30 km/h, 75kg rider, medium gear ratio
-	resistance := CalculateResistance(30, 0, 75, 2.5, 0.2)
+	resistance := CalculateResistance(30, 0, 75, 2.5, 0.2, DefaultPhysics())
 	// Should be moderate resistance from air/rolling
 	assert.Greater(t, resistance, 0.0)
 	assert.Less(t, resistance, 50.0) // FTMS resistance is 0-100 scale
@@ -29,26 +29,26 @@ func TestCalculateResistance_Flat(t *testing.T) {
 
 func TestCalculateResistance_Climb(t *testing.T) {
 	// 5% climb should increase resistance significantly
-	resistanceFlat := CalculateResistance(20, 0, 75, 2.5, 0.2)
-	resistanceClimb := CalculateResistance(20, 5, 75, 2.5, 0.2)
+	resistanceFlat := CalculateResistance(20, 0, 75, 2.5, 0.2, DefaultPhysics())
+	resistanceClimb := CalculateResistance(20, 5, 75, 2.5, 0.2, DefaultPhysics())
 
 	assert.Greater(t, resistanceClimb, resistanceFlat)
 }
 
 func TestCalculateResistance_Descent(t *testing.T) {
 	// Descent should reduce resistance
-	resistanceFlat := CalculateResistance(30, 0, 75, 2.5, 0.2)
-	resistanceDescent := CalculateResistance(30, -5, 75, 2.5, 0.2)
+	resistanceFlat := CalculateResistance(30, 0, 75, 2.5, 0.2, DefaultPhysics())
+	resistanceDescent := CalculateResistance(30, -5, 75, 2.5, 0.2, DefaultPhysics())
 
 	assert.Less(t, resistanceDescent, resistanceFlat)
 }
 
 func TestCalculateResistance_Clamped(t *testing.T) {
 	// Extreme values should be clamped to 0-100
-	resistanceSteep := CalculateResistance(5, 20, 100, 2.5, 0.2)
+	resistanceSteep := CalculateResistance(5, 20, 100, 2.5, 0.2, DefaultPhysics())
 	assert.LessOrEqual(t, resistanceSteep, 100.0)
 
-	resistanceDownhill := CalculateResistance(50, -15, 75, 2.5, 0.2)
+	resistanceDownhill := CalculateResistance(50, -15, 75, 2.5, 0.2, DefaultPhysics())
 	assert.GreaterOrEqual(t, resistanceDownhill, 0.0)
 }
 
@@ -89,7 +89,7 @@ func TestCalculateWheelForce(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			force := CalculateWheelForce(tt.speedKmh, tt.gradientPercent, tt.weightKg)
+			force := CalculateWheelForce(tt.speedKmh, tt.gradientPercent, tt.weightKg, DefaultPhysics())
 			if force < tt.wantMin || force > tt.wantMax {
 				t.Errorf("CalculateWheelForce() = %.2f, want between %.2f and %.2f",
 					force, tt.wantMin, tt.wantMax)
@@ -225,7 +225,7 @@ func TestCalculateResistance_WithGearRatio(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := CalculateResistance(tt.speedKmh, tt.gradientPercent, tt.weightKg, tt.gearRatio, 0.2)
+			got := CalculateResistance(tt.speedKmh, tt.gradientPercent, tt.weightKg, tt.gearRatio, 0.2, DefaultPhysics())
 			if got < tt.wantMin || got > tt.wantMax {
 				t.Errorf("CalculateResistance() = %.2f, want between %.2f and %.2f",
 					got, tt.wantMin, tt.wantMax)
@@ -245,8 +245,8 @@ func TestCalculateResistance_GearRatioEffect(t *testing.T) {
 	gradientPercent := 2.0
 	weightKg := 75.0
 
-	easyGear := CalculateResistance(speedKmh, gradientPercent, weightKg, 2.0, 0.2)
-	hardGear := CalculateResistance(speedKmh, gradientPercent, weightKg, 3.0, 0.2)
+	easyGear := CalculateResistance(speedKmh, gradientPercent, weightKg, 2.0, 0.2, DefaultPhysics())
+	hardGear := CalculateResistance(speedKmh, gradientPercent, weightKg, 3.0, 0.2, DefaultPhysics())
 
 	if hardGear <= easyGear {
 		t.Errorf("Hard gear (3.0) resistance %.2f should be > easy gear (2.0) resistance %.2f",
@@ -260,3 +260,145 @@ func TestCalculateResistance_GearRatioEffect(t *testing.T) {
 		t.Errorf("Resistance ratio %.2f not close to gear ratio %.2f", actualRatio, expectedRatio)
 	}
 }
+
+func TestCalculateEffectiveGrade(t *testing.T) {
+	// In the reference gear the trainer feels the route grade
+	grade := CalculateEffectiveGrade(90, 4, 75, referenceGearRatio, 2.1, DefaultPhysics())
+	assert.InDelta(t, 4.0, grade, 0.01)
+
+	// Harder virtual gear feels steeper, easier gear flatter
+	hard := CalculateEffectiveGrade(90, 4, 75, 4.0, 2.1, DefaultPhysics())
+	easy := CalculateEffectiveGrade(90, 4, 75, 1.5, 2.1, DefaultPhysics())
+	assert.Greater(t, hard, grade)
+	assert.Less(t, easy, grade)
+
+	// Gears matter on the flat too
+	flatHard := CalculateEffectiveGrade(90, 0, 75, 4.0, 2.1, DefaultPhysics())
+	assert.Greater(t, flatHard, 0.0)
+}
+
+func TestCalculateEffectiveGrade_Clamped(t *testing.T) {
+	assert.LessOrEqual(t, CalculateEffectiveGrade(90, 30, 75, 4.5, 2.1, DefaultPhysics()), 25.0)
+	assert.GreaterOrEqual(t, CalculateEffectiveGrade(90, -30, 75, 1.2, 2.1, DefaultPhysics()), -25.0)
+}
+
+func TestAdvanceSpeed(t *testing.T) {
+	// 200 W on the flat settles where drive and resisting forces balance
+	speed := 0.0
+	for i := 0; i < 120; i++ {
+		speed = AdvanceSpeed(speed, 200, 0, 75, 1, DefaultPhysics())
+	}
+	steady := speed / 3.6
+	resisting := CalculateWheelForce(speed, 0, 75, DefaultPhysics()) * steady
+	assert.InDelta(t, 200, resisting, 2)
+
+	// Acceleration is gradual, the bike has mass
+	assert.Less(t, AdvanceSpeed(0, 200, 0, 75, 1, DefaultPhysics()), 10.0)
+
+	// Coasting on the flat slows down, but not to a halt at once
+	coast := AdvanceSpeed(speed, 0, 0, 75, 1, DefaultPhysics())
+	assert.Less(t, coast, speed)
+	assert.Greater(t, coast, speed-3)
+
+	// Steep descent speeds up from standstill without pedaling
+	assert.Greater(t, AdvanceSpeed(0, 0, -8, 75, 5, DefaultPhysics()), 10.0)
+
+	// No pedaling on a climb comes to a stop and stays there
+	assert.Equal(t, 0.0, AdvanceSpeed(10, 0, 8, 75, 10, DefaultPhysics()))
+}
+
+func TestAdvanceSpeed_Gradient(t *testing.T) {
+	steady := func(gradient float64) float64 {
+		speed := 0.0
+		for i := 0; i < 300; i++ {
+			speed = AdvanceSpeed(speed, 250, gradient, 75, 1, DefaultPhysics())
+		}
+		return speed
+	}
+
+	flat, climb, descent := steady(0), steady(10), steady(-5)
+	assert.Greater(t, flat, climb*3)
+	assert.Greater(t, descent, flat)
+	assert.False(t, math.IsNaN(climb))
+}
+
+func TestCalculateWheelForce_Physics(t *testing.T) {
+	base := DefaultPhysics()
+
+	aero := base
+	aero.CdA = 0.24
+	assert.Less(t, CalculateWheelForce(40, 0, 75, aero), CalculateWheelForce(40, 0, 75, base))
+
+	gravel := base
+	gravel.Crr = 0.008
+	assert.InDelta(t, 0.003*85*gravity, CalculateWheelForce(0, 0, 75, gravel)-CalculateWheelForce(0, 0, 75, base), 1e-9)
+
+	heavy := base
+	heavy.BikeMass = 15
+	assert.Greater(t, CalculateWheelForce(15, 5, 75, heavy), CalculateWheelForce(15, 5, 75, base))
+
+	// Thinner air at altitude means less drag at the same speed
+	thin := base
+	thin.AirDensity = AirDensity(2000, 15)
+	assert.Less(t, CalculateWheelForce(40, 0, 75, thin), CalculateWheelForce(40, 0, 75, base))
+}
+
+func TestAirDensity(t *testing.T) {
+	assert.InDelta(t, SeaLevelAirDensity, AirDensity(0, 15), 0.001)
+	assert.InDelta(t, 1.007, AirDensity(2000, 2), 0.01)
+	assert.Greater(t, AirDensity(0, 0), AirDensity(0, 30))
+}
+
+func TestLookupPreset(t *testing.T) {
+	cda, err := LookupPreset(PositionPresets, "drops")
+	assert.NoError(t, err)
+	assert.Equal(t, 0.29, cda)
+
+	// The default position keeps the drag area goc has always used
+	cda, err = LookupPreset(PositionPresets, "hoods")
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultCdA, cda)
+	assert.Equal(t, 0.3, DefaultCdA)
+
+	crr, err := LookupPreset(TyrePresets, "road")
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultCrr, crr)
+
+	_, err = LookupPreset(PositionPresets, "superman")
+	assert.ErrorIs(t, err, ErrUnknownPreset)
+}
+
+func TestPhysics_Validate(t *testing.T) {
+	assert.NoError(t, DefaultPhysics().Validate())
+
+	for _, p := range []Physics{
+		{CdA: 0, Crr: 0.005, BikeMass: 10, AirDensity: 1.225},
+		{CdA: 0.3, Crr: 0.5, BikeMass: 10, AirDensity: 1.225},
+		{CdA: 0.3, Crr: 0.005, BikeMass: -1, AirDensity: 1.225},
+		{CdA: 0.3, Crr: 0.005, BikeMass: 10, AirDensity: 12},
+	} {
+		assert.Error(t, p.Validate(), "%+v", p)
+	}
+}
+
+func TestAdvanceSpeed_FlywheelMass(t *testing.T) {
+	light := DefaultPhysics()
+	light.FlywheelMass = 0.1
+
+	heavy := DefaultPhysics()
+	heavy.FlywheelMass = 10
+
+	// More rotating mass accelerates and slows down more gradually
+	assert.Greater(t, AdvanceSpeed(10, 300, 0, 75, 2, light), AdvanceSpeed(10, 300, 0, 75, 2, heavy))
+	assert.Less(t, AdvanceSpeed(40, 0, 0, 75, 2, light), AdvanceSpeed(40, 0, 0, 75, 2, heavy))
+
+	// but weighs nothing: the steady speed on a climb is the same
+	steady := func(p Physics) float64 {
+		speed := 0.0
+		for i := 0; i < 600; i++ {
+			speed = AdvanceSpeed(speed, 250, 6, 75, 1, p)
+		}
+		return speed
+	}
+	assert.InDelta(t, steady(light), steady(heavy), 0.01)
+}
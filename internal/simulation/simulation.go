@@ -1,5 +1,10 @@
 package simulation
 
+import (
+	"fmt"
+	"math"
+)
+
 // Mode represents the training mode
 type Mode int
 
@@ -22,6 +27,37 @@ func (m Mode) String() string {
 	}
 }
 
+// SpeedModel selects how speed is derived in SIM mode
+type SpeedModel int
+
+const (
+	SpeedFromPower   SpeedModel = iota // Integrate the equation of motion from power
+	SpeedFromCadence                   // Cadence × gear ratio × wheel circumference
+)
+
+func (m SpeedModel) String() string {
+	switch m {
+	case SpeedFromPower:
+		return "power"
+	case SpeedFromCadence:
+		return "cadence"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseSpeedModel returns the speed model named s ("power" or "cadence")
+func ParseSpeedModel(s string) (SpeedModel, error) {
+	switch s {
+	case "power", "":
+		return SpeedFromPower, nil
+	case "cadence":
+		return SpeedFromCadence, nil
+	default:
+		return SpeedFromPower, fmt.Errorf("unknown speed model %q", s)
+	}
+}
+
 // EngineConfig holds simulation parameters
 type EngineConfig struct {
 	Chainrings         []int
@@ -30,6 +66,11 @@ type EngineConfig struct {
 	RiderWeight        float64
 	ResistanceScaling  float64
 	GradientSmoothing  float64
+	TrainerSimulation  bool // Send effective grade to trainers with native SIM support
+	SpeedModel         SpeedModel
+	Physics            Physics // Unset parameters use their defaults
+	SynchroShift       int     // Rear cogs to move on a front shift, 0 = off
+	PreventCrossChain  bool
 }
 
 // State represents current simulation state
@@ -45,6 +86,11 @@ type State struct {
 	TargetPower  float64 // For ERG mode
 	Distance     float64 // Cumulative meters
 	ElapsedTime  float64 // Seconds
+
+	// TrainerSimulation is set in SIM mode when the trainer should receive
+	// EffectiveGrade instead of Resistance
+	TrainerSimulation bool
+	EffectiveGrade    float64 // Percent, includes virtual gear
 }
 
 // Engine handles physics calculations
@@ -58,6 +104,9 @@ type Engine struct {
 	elapsedTime      float64
 	smoothedGradient float64 // EMA-smoothed gradient
 	smoothingFactor  float64 // alpha value for EMA
+	trainerSim       bool    // use trainer's native simulation in SIM mode
+	speed            float64 // km/h, integrated from power in SIM mode
+	power            float64 // last power, drives the speed integration
 }
 
 // NewEngine creates a new simulation engine
@@ -68,13 +117,20 @@ func NewEngine(cfg EngineConfig) *Engine {
 		smoothing = 0.85
 	}
 
+	cfg.Physics = cfg.Physics.withDefaults()
+
+	gears := NewGearSystem(cfg.Chainrings, cfg.Cassette)
+	gears.SetSynchroShift(cfg.SynchroShift)
+	gears.SetPreventCrossChain(cfg.PreventCrossChain)
+
 	return &Engine{
 		config:           cfg,
-		gears:            NewGearSystem(cfg.Chainrings, cfg.Cassette),
+		gears:            gears,
 		mode:             ModeSIM,
 		manualResistance: 20, // Default for FREE mode
 		smoothingFactor:  smoothing,
 		smoothedGradient: 0.0, // initialize at flat
+		trainerSim:       cfg.TrainerSimulation,
 	}
 }
 
@@ -86,9 +142,20 @@ func (e *Engine) Update(cadence, power, gradient float64) State {
 	// Apply exponential moving average to gradient
 	e.smoothedGradient = e.smoothingFactor*e.smoothedGradient + (1-e.smoothingFactor)*gradient
 
-	speed := CalculateSpeed(cadence, e.gears.Ratio(), e.config.WheelCircumference)
+	// Speed follows power unless configured otherwise
+	gearSpeed := CalculateSpeed(cadence, e.gears.Ratio(), e.config.WheelCircumference)
+	speed := gearSpeed
+	resistanceSpeed := gearSpeed
+	e.power = power
+	if e.physicsSpeed() {
+		speed = e.speed
+		// Momentum carries the bike, so resist at its actual speed, but
+		// never below what the pedals drive: getting up to speed still
+		// takes effort
+		resistanceSpeed = math.Max(gearSpeed, e.speed)
+	}
 
-	var resistance float64
+	var resistance, effectiveGrade float64
 	switch e.mode {
 	case ModeSIM:
 		scaling := e.config.ResistanceScaling
@@ -96,7 +163,8 @@ func (e *Engine) Update(cadence, power, gradient float64) State {
 			scaling = 0.2 // Fallback default
 		}
 		// Use smoothed gradient instead of raw gradient
-		resistance = CalculateResistance(speed, e.smoothedGradient, e.config.RiderWeight, e.gears.Ratio(), scaling)
+		resistance = CalculateResistance(resistanceSpeed, e.smoothedGradient, e.config.RiderWeight, e.gears.Ratio(), scaling, e.config.Physics)
+		effectiveGrade = CalculateEffectiveGrade(cadence, e.smoothedGradient, e.config.RiderWeight, e.gears.Ratio(), e.config.WheelCircumference, e.config.Physics)
 	case ModeERG:
 		resistance = 0 // ERG mode uses target power, not resistance
 	case ModeFREE:
@@ -105,8 +173,7 @@ func (e *Engine) Update(cadence, power, gradient float64) State {
 		// Scale by gear ratio to get pedal resistance
 		baseResistance := e.manualResistance
 		// Convert to approximate force, apply gear ratio, convert back
-		// Simplified: directly scale by gear ratio relative to reference (2.5)
-		const referenceGearRatio = 2.5
+		// Simplified: directly scale by gear ratio relative to reference
 		resistance = baseResistance * (e.gears.Ratio() / referenceGearRatio)
 		// Clamp to valid range
 		if resistance < 0 {
@@ -129,13 +196,31 @@ func (e *Engine) Update(cadence, power, gradient float64) State {
 		TargetPower: e.targetPower,
 		Distance:    e.distance,
 		ElapsedTime: e.elapsedTime,
+
+		TrainerSimulation: e.mode == ModeSIM && e.trainerSim,
+		EffectiveGrade:    effectiveGrade,
 	}
 }
 
-// Tick advances time and distance
+// Tick advances time and distance. In SIM mode with the power speed model
+// it also integrates speed from the last power and gradient.
 func (e *Engine) Tick(deltaSeconds float64, speedKmh float64) {
 	e.elapsedTime += deltaSeconds
 	e.distance += (speedKmh / 3.6) * deltaSeconds // km/h to m/s
+
+	if e.physicsSpeed() {
+		e.speed = AdvanceSpeed(e.speed, e.power, e.smoothedGradient, e.config.RiderWeight, deltaSeconds, e.config.Physics)
+	}
+}
+
+// physicsSpeed reports whether speed comes from the power integrator
+func (e *Engine) physicsSpeed() bool {
+	return e.mode == ModeSIM && e.config.SpeedModel == SpeedFromPower
+}
+
+// Physics returns the force model parameters in use
+func (e *Engine) Physics() Physics {
+	return e.config.Physics
 }
 
 // Mode returns current training mode
@@ -148,6 +233,12 @@ func (e *Engine) SetMode(m Mode) {
 	e.mode = m
 }
 
+// SetTrainerSimulation enables or disables sending the effective grade to
+// the trainer in SIM mode. Disable it when the trainer lacks support.
+func (e *Engine) SetTrainerSimulation(enabled bool) {
+	e.trainerSim = enabled
+}
+
 // SetTargetPower sets ERG mode target
 func (e *Engine) SetTargetPower(watts float64) {
 	e.targetPower = watts
@@ -179,6 +270,16 @@ func (e *Engine) ShiftDown() {
 	e.gears.ShiftDown()
 }
 
+// FrontShiftUp shifts to the next larger chainring
+func (e *Engine) FrontShiftUp() {
+	e.gears.FrontShiftUp()
+}
+
+// FrontShiftDown shifts to the next smaller chainring
+func (e *Engine) FrontShiftDown() {
+	e.gears.FrontShiftDown()
+}
+
 // GearRatio returns current gear ratio
 func (e *Engine) GearRatio() float64 {
 	return e.gears.Ratio()
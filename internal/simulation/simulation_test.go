@@ -19,6 +19,8 @@ func TestEngine_Update(t *testing.T) {
 
 	// Simulate pedaling at 90 RPM with 200W
 	state := engine.Update(90, 200, 0) // 0% gradient
+	engine.Tick(1, state.Speed)
+	state = engine.Update(90, 200, 0)
 
 	assert.Greater(t, state.Speed, 0.0)
 	assert.Equal(t, 90.0, state.Cadence)
@@ -66,6 +68,24 @@ func TestEngine_Shifting(t *testing.T) {
 	assert.InDelta(t, initialRatio, engine.GearRatio(), 0.01)
 }
 
+func TestEngine_FrontShifting(t *testing.T) {
+	engine := NewEngine(EngineConfig{
+		Chainrings:         []int{50, 34},
+		Cassette:           []int{11, 13, 15, 17, 19, 21},
+		WheelCircumference: 2.1,
+		RiderWeight:        75,
+		SynchroShift:       1,
+	})
+
+	assert.Equal(t, "50x17", engine.GearString())
+
+	engine.FrontShiftDown()
+	assert.Equal(t, "34x15", engine.GearString())
+
+	engine.FrontShiftUp()
+	assert.Equal(t, "50x17", engine.GearString())
+}
+
 func TestEngine_Update_GearAffectsResistance(t *testing.T) {
 	cfg := EngineConfig{
 		Chainrings:         []int{50, 34},
@@ -73,6 +93,7 @@ func TestEngine_Update_GearAffectsResistance(t *testing.T) {
 		WheelCircumference: 2.105,
 		RiderWeight:        75.0,
 		ResistanceScaling:  0.2,
+		SpeedModel:         SpeedFromCadence,
 	}
 	engine := NewEngine(cfg)
 	engine.SetMode(ModeSIM)
@@ -285,3 +306,137 @@ func TestEngine_GradientSmoothing_Integration(t *testing.T) {
 
 	t.Logf("Resistance progression: flat=%.2f → climb=%.2f → end=%.2f", resistanceFlat, resistanceClimb, resistanceEnd)
 }
+
+func TestEngine_TrainerSimulation(t *testing.T) {
+	engine := NewEngine(EngineConfig{
+		Chainrings:         []int{50, 34},
+		Cassette:           []int{11, 13, 15, 17, 19, 21, 24, 28},
+		WheelCircumference: 2.1,
+		RiderWeight:        75,
+		ResistanceScaling:  0.2,
+		GradientSmoothing:  0.01, // near-instant response for the test
+		TrainerSimulation:  true,
+	})
+
+	state := engine.Update(90, 200, 5)
+	assert.True(t, state.TrainerSimulation)
+	assert.NotZero(t, state.EffectiveGrade)
+	assert.Greater(t, state.Resistance, 0.0) // still computed for fallback
+
+	// Only SIM mode uses the trainer's simulation
+	engine.SetMode(ModeFREE)
+	assert.False(t, engine.Update(90, 200, 5).TrainerSimulation)
+
+	// Fallback when the trainer lacks support
+	engine.SetMode(ModeSIM)
+	engine.SetTrainerSimulation(false)
+	assert.False(t, engine.Update(90, 200, 5).TrainerSimulation)
+}
+
+func TestEngine_PowerSpeedModel(t *testing.T) {
+	newEngine := func() *Engine {
+		return NewEngine(EngineConfig{
+			Chainrings:         []int{50, 34},
+			Cassette:           []int{11, 13, 15, 17, 19, 21, 24, 28},
+			WheelCircumference: 2.1,
+			RiderWeight:        75,
+			ResistanceScaling:  0.2,
+			GradientSmoothing:  0.01,
+		})
+	}
+
+	ride := func(e *Engine, gradient float64) State {
+		for i := 0; i < 600; i++ {
+			state := e.Update(90, 200, gradient)
+			e.Tick(0.5, state.Speed)
+		}
+		return e.Update(90, 200, gradient)
+	}
+
+	flat := ride(newEngine(), 0)
+	climb := ride(newEngine(), 10)
+
+	// Same power and cadence, much slower uphill
+	assert.InDelta(t, 35, flat.Speed, 2)
+	assert.InDelta(t, 8.5, climb.Speed, 1)
+
+	// Cadence model ignores the gradient
+	e := newEngine()
+	e.config.SpeedModel = SpeedFromCadence
+	assert.Equal(t, ride(e, 0).Speed, ride(e, 10).Speed)
+
+	// Other modes keep cadence based speed
+	e = newEngine()
+	e.SetMode(ModeERG)
+	assert.Equal(t, CalculateSpeed(90, e.GearRatio(), 2.1), ride(e, 10).Speed)
+}
+
+func TestParseSpeedModel(t *testing.T) {
+	m, err := ParseSpeedModel("cadence")
+	assert.NoError(t, err)
+	assert.Equal(t, SpeedFromCadence, m)
+
+	m, err = ParseSpeedModel("")
+	assert.NoError(t, err)
+	assert.Equal(t, SpeedFromPower, m)
+
+	_, err = ParseSpeedModel("gears")
+	assert.Error(t, err)
+}
+
+func TestEngine_Momentum(t *testing.T) {
+	engine := NewEngine(EngineConfig{
+		Chainrings:         []int{50, 34},
+		Cassette:           []int{11, 13, 15, 17, 19, 21, 24, 28},
+		WheelCircumference: 2.1,
+		RiderWeight:        75,
+		ResistanceScaling:  0.2,
+		GradientSmoothing:  0.01,
+	})
+
+	ride := func(cadence, power, gradient, seconds float64) State {
+		var state State
+		for elapsed := 0.0; elapsed < seconds; elapsed += 0.25 {
+			state = engine.Update(cadence, power, gradient)
+			engine.Tick(0.25, state.Speed)
+		}
+		return engine.Update(cadence, power, gradient)
+	}
+
+	cruising := ride(90, 200, 0, 120)
+
+	// Stop pedaling on the flat: speed decays, distance keeps growing
+	distance := engine.distance
+	coasting := ride(0, 0, 0, 5)
+	assert.Less(t, coasting.Speed, cruising.Speed)
+	assert.Greater(t, coasting.Speed, cruising.Speed*0.7)
+	assert.Greater(t, engine.distance, distance+40)
+
+	// Freewheeling downhill builds speed
+	descent := ride(0, 0, -6, 60)
+	assert.Greater(t, descent.Speed, cruising.Speed)
+
+	// and carries into the next rise, above the steady climbing speed
+	rise := ride(90, 200, 4, 3)
+	steady := ride(90, 200, 4, 300)
+	assert.Greater(t, rise.Speed, steady.Speed+5)
+}
+
+func TestEngine_MomentumResistance(t *testing.T) {
+	engine := NewEngine(EngineConfig{
+		Chainrings:         []int{50},
+		Cassette:           []int{11, 13, 15, 17, 19, 21},
+		WheelCircumference: 2.1,
+		RiderWeight:        75,
+		ResistanceScaling:  0.2,
+		GradientSmoothing:  0.01,
+	})
+	engine.gears.SetRear(5)
+
+	standing := engine.Update(90, 200, 0)
+
+	// Once fast, pedaling meets the drag of the actual speed
+	engine.speed = 45
+	fast := engine.Update(90, 200, 0)
+	assert.Greater(t, fast.Resistance, standing.Resistance)
+}
@@ -6,6 +6,8 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/thiemotorres/goc/internal/config"
+	"github.com/thiemotorres/goc/internal/data"
+	"github.com/thiemotorres/goc/internal/zones"
 )
 
 // Screen represents the current screen
@@ -16,15 +18,18 @@ const (
 	ScreenStartRide
 	ScreenBrowseRoutes
 	ScreenRoutePreview
+	ScreenBrowseWorkouts
 	ScreenHistory
 	ScreenRideDetail
 	ScreenSettings
-	ScreenTrainerSettings
+	ScreenDeviceSettings
 	ScreenBikeSettings
+	ScreenRiderSettings
 	ScreenRoutesSettings
 	ScreenRide
 	ScreenScanner
 	ScreenConnecting
+	ScreenRecover
 )
 
 // App is the main application model
@@ -40,15 +45,19 @@ type App struct {
 	startRideMenu   *StartRideMenu
 	routesBrowser   *RoutesBrowser
 	routePreview    *RoutePreview
+	workoutsBrowser *WorkoutsBrowser
 	selectedRoute   *RouteInfo
 	settingsMenu    *SettingsMenu
-	trainerSettings *TrainerSettings
+	deviceSettings  *DeviceSettings
 	bikeSettings    *BikeSettings
+	riderSettings   *RiderSettings
 	historyView       *HistoryView
+	rideDetail        *RideDetail
 	rideScreen        *RideScreen
 	rideSession       *RideSession
 	scannerScreen     *ScannerScreen
 	connectingScreen  *ConnectingScreen
+	recoverScreen     *RecoverScreen
 	connectStatus     string
 
 	// Config
@@ -57,14 +66,22 @@ type App struct {
 
 // NewApp creates a new application
 func NewApp(cfg *config.Config) *App {
-	return &App{
-		screen:        ScreenMainMenu,
-		mainMenu:      NewMainMenu(),
-		startRideMenu: NewStartRideMenu(),
-		routesBrowser: NewRoutesBrowser(cfg.Routes.Folder),
-		settingsMenu:  NewSettingsMenu(cfg),
-		config:        cfg,
+	a := &App{
+		screen:          ScreenMainMenu,
+		mainMenu:        NewMainMenu(),
+		startRideMenu:   NewStartRideMenu(),
+		routesBrowser:   NewRoutesBrowser(cfg.Routes.Folder),
+		workoutsBrowser: NewWorkoutsBrowser(cfg.Workouts.Folder, cfg.Rider.EffectiveFTP()),
+		settingsMenu:    NewSettingsMenu(cfg),
+		config:          cfg,
 	}
+
+	// Offer rides left behind by a crash first
+	if unfinished := NewRecoverScreen(); !unfinished.Empty() {
+		a.recoverScreen = unfinished
+		a.screen = ScreenRecover
+	}
+	return a
 }
 
 func (a *App) Init() tea.Cmd {
@@ -76,6 +93,9 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.WindowSizeMsg:
 		a.width = msg.Width
 		a.height = msg.Height
+		if a.rideDetail != nil {
+			a.rideDetail.SetSize(msg.Width, msg.Height)
+		}
 
 	case tea.KeyMsg:
 		if msg.String() == "ctrl+c" {
@@ -91,6 +111,7 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return a, nil
 
 	case RideConnectedMsg:
+		a.startRideMenu.SetCapabilities(msg.Capabilities)
 		a.screen = ScreenRide
 		// Initialize ride screen dimensions
 		if a.rideScreen != nil && a.width > 0 && a.height > 0 {
@@ -104,6 +125,9 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			a.rideScreen.UpdateMetrics(msg.Power, msg.Cadence, msg.Speed)
 			a.rideScreen.UpdateStats(msg.Elapsed, msg.Distance, msg.AvgPower, msg.AvgCadence, msg.AvgSpeed, msg.Elevation)
 			a.rideScreen.UpdateStatus(msg.Gear, msg.Gradient, msg.Mode, msg.Paused)
+			a.rideScreen.SetWarning(msg.Warning)
+			a.rideScreen.UpdateHeartRate(msg.HeartRate)
+			a.rideScreen.UpdateWorkout(msg.Workout, msg.StepNotice)
 		}
 		// Continue data loop
 		if a.rideSession != nil {
@@ -111,6 +135,36 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		return a, nil
 
+	case HeartRateMsg:
+		// Shown with the next ride update, keep the loop running
+		if a.rideSession != nil {
+			return a, a.rideSession.StartDataLoop()
+		}
+		return a, nil
+
+	case RideLinkMsg:
+		if a.rideScreen != nil {
+			a.rideScreen.SetWarning(msg.Notice)
+		}
+		if a.rideSession != nil {
+			return a, a.rideSession.StartDataLoop()
+		}
+		return a, nil
+
+	case SensorMsg:
+		// Used with the next trainer update, keep the loop running
+		if a.rideSession != nil {
+			return a, a.rideSession.StartDataLoop()
+		}
+		return a, nil
+
+	case TrainerStatusMsg:
+		// Status is applied by the session, keep the loop running
+		if a.rideSession != nil {
+			return a, a.rideSession.StartDataLoop()
+		}
+		return a, nil
+
 	case RideErrorMsg:
 		a.connectStatus = msg.Error.Error()
 		// Return to menu after error
@@ -122,6 +176,12 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		a.rideSession = nil
 		a.rideScreen = nil
 		a.screen = ScreenMainMenu
+		if msg.Err != nil {
+			// The journal is kept, offer to recover it
+			a.recoverScreen = NewRecoverScreen()
+			a.recoverScreen.SetMessage(fmt.Sprintf("Saving the ride failed: %v", msg.Err))
+			a.screen = ScreenRecover
+		}
 		return a, nil
 
 	case ScanResultMsg:
@@ -132,13 +192,13 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case DeviceSelectedMsg:
 		// Save the selected device
-		a.config.Bluetooth.TrainerAddress = msg.Address
+		msg.Kind.SetAddress(a.config, msg.Address)
 		config.Save(a.config, config.DefaultConfigDir())
 		// Update trainer settings display
-		if a.trainerSettings != nil {
-			a.trainerSettings.address = msg.Address
+		if a.deviceSettings != nil {
+			a.deviceSettings.address = msg.Address
 		}
-		a.screen = ScreenTrainerSettings
+		a.screen = ScreenDeviceSettings
 		return a, nil
 	}
 
@@ -152,20 +212,28 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return a.updateBrowseRoutes(msg)
 	case ScreenRoutePreview:
 		return a.updateRoutePreview(msg)
+	case ScreenBrowseWorkouts:
+		return a.updateBrowseWorkouts(msg)
 	case ScreenSettings:
 		return a.updateSettings(msg)
-	case ScreenTrainerSettings:
-		return a.updateTrainerSettings(msg)
+	case ScreenDeviceSettings:
+		return a.updateDeviceSettings(msg)
 	case ScreenBikeSettings:
 		return a.updateBikeSettings(msg)
+	case ScreenRiderSettings:
+		return a.updateRiderSettings(msg)
 	case ScreenHistory:
 		return a.updateHistory(msg)
+	case ScreenRideDetail:
+		return a.updateRideDetail(msg)
 	case ScreenRide:
 		return a.updateRide(msg)
 	case ScreenScanner:
 		return a.updateScanner(msg)
 	case ScreenConnecting:
 		return a.updateConnecting(msg)
+	case ScreenRecover:
+		return a.updateRecover(msg)
 	}
 
 	return a, nil
@@ -194,11 +262,13 @@ func (a *App) View() string {
 			return a.routePreview.View()
 		}
 		return "No route selected"
+	case ScreenBrowseWorkouts:
+		return a.workoutsBrowser.View()
 	case ScreenSettings:
 		return a.settingsMenu.View()
-	case ScreenTrainerSettings:
-		if a.trainerSettings != nil {
-			return a.trainerSettings.View()
+	case ScreenDeviceSettings:
+		if a.deviceSettings != nil {
+			return a.deviceSettings.View()
 		}
 		return "Settings not loaded"
 	case ScreenBikeSettings:
@@ -206,11 +276,21 @@ func (a *App) View() string {
 			return a.bikeSettings.View()
 		}
 		return "Settings not loaded"
+	case ScreenRiderSettings:
+		if a.riderSettings != nil {
+			return a.riderSettings.View()
+		}
+		return "Settings not loaded"
 	case ScreenHistory:
 		if a.historyView != nil {
 			return a.historyView.View()
 		}
 		return "History not loaded"
+	case ScreenRideDetail:
+		if a.rideDetail != nil {
+			return a.rideDetail.View()
+		}
+		return "Ride not loaded"
 	case ScreenRide:
 		if a.rideScreen != nil {
 			return a.rideScreen.View()
@@ -226,6 +306,11 @@ func (a *App) View() string {
 			return a.connectingScreen.View()
 		}
 		return "Connecting..."
+	case ScreenRecover:
+		if a.recoverScreen != nil {
+			return a.recoverScreen.View()
+		}
+		return "Nothing to recover"
 	default:
 		return "Unknown screen"
 	}
@@ -248,12 +333,15 @@ func (a *App) updateMainMenu(msg tea.Msg) (tea.Model, tea.Cmd) {
 				a.screen = ScreenStartRide
 			case 1: // Browse Routes
 				a.screen = ScreenBrowseRoutes
-			case 2: // History
-				a.historyView = NewHistoryView()
+			case 2: // Browse Workouts
+				a.prevScreen = ScreenMainMenu
+				a.screen = ScreenBrowseWorkouts
+			case 3: // History
+				a.historyView = NewHistoryView(a.config.Rider.EffectiveFTP())
 				a.screen = ScreenHistory
-			case 3: // Settings
+			case 4: // Settings
 				a.screen = ScreenSettings
-			case 4: // Quit
+			case 5: // Quit
 				a.quitting = true
 				return a, tea.Quit
 			}
@@ -275,15 +363,22 @@ func (a *App) updateStartRide(msg tea.Msg) (tea.Model, tea.Cmd) {
 			a.startRideMenu.MoveDown()
 		case "enter":
 			a.connectStatus = "" // Clear previous error
+			if a.startRideMenu.IsDisabled(a.startRideMenu.Selected()) {
+				a.connectStatus = "not supported by your trainer"
+				return a, nil
+			}
 			switch a.startRideMenu.Selected() {
 			case 0: // Free Ride
-				return a, a.startRide(RideFree, nil)
+				return a, a.startRide(RideFree, nil, nil)
 			case 1: // ERG Mode
 				// TODO: Show ERG watts input, for now start with 150W
-				return a, a.startRide(RideERG, nil)
+				return a, a.startRide(RideERG, nil, nil)
 			case 2: // Ride a Route
 				a.screen = ScreenBrowseRoutes
-			case 3: // Back
+			case 3: // Structured Workout
+				a.prevScreen = ScreenStartRide
+				a.screen = ScreenBrowseWorkouts
+			case 4: // Back
 				a.screen = ScreenMainMenu
 			}
 		}
@@ -315,6 +410,27 @@ func (a *App) updateBrowseRoutes(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return a, nil
 }
 
+func (a *App) updateBrowseWorkouts(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc":
+			a.screen = a.prevScreen
+		case "up", "k":
+			a.workoutsBrowser.MoveUp()
+		case "down", "j":
+			a.workoutsBrowser.MoveDown()
+		case "enter":
+			if w := a.workoutsBrowser.SelectedWorkout(); w != nil {
+				return a, a.startRide(RideWorkout, nil, w)
+			}
+			// Back selected
+			a.screen = a.prevScreen
+		}
+	}
+	return a, nil
+}
+
 func (a *App) updateRoutePreview(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
@@ -328,7 +444,7 @@ func (a *App) updateRoutePreview(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "enter":
 			if a.routePreview.Selected() == 0 {
 				// Start ride with route
-				return a, a.startRide(RideRoute, a.selectedRoute)
+				return a, a.startRide(RideRoute, a.selectedRoute, nil)
 			} else {
 				a.screen = ScreenBrowseRoutes
 			}
@@ -349,15 +465,25 @@ func (a *App) updateSettings(msg tea.Msg) (tea.Model, tea.Cmd) {
 			a.settingsMenu.MoveDown()
 		case "enter":
 			switch a.settingsMenu.Selected() {
-			case 0: // Trainer Connection
-				a.trainerSettings = NewTrainerSettings(a.config.Bluetooth.TrainerAddress)
-				a.screen = ScreenTrainerSettings
-			case 1: // Bike Settings
+			case 0, 1, 2, 3: // Trainer, Heart Rate Monitor, Power Meter, Cadence Sensor
+				kind := settingsDevices[a.settingsMenu.Selected()]
+				a.deviceSettings = NewDeviceSettings(kind, kind.Address(a.config))
+				a.screen = ScreenDeviceSettings
+			case 4: // Power Source
+				a.config.Bluetooth.PowerSource = nextSource(powerSources, a.config.Bluetooth.PowerSource)
+				config.Save(a.config, config.DefaultConfigDir())
+			case 5: // Cadence Source
+				a.config.Bluetooth.CadenceSource = nextSource(cadenceSources, a.config.Bluetooth.CadenceSource)
+				config.Save(a.config, config.DefaultConfigDir())
+			case 6: // Bike Settings
 				a.bikeSettings = NewBikeSettings(a.config)
 				a.screen = ScreenBikeSettings
-			case 2: // Routes Folder
-				// TODO: Allow editing routes folder
-			case 3: // Back
+			case 7: // Rider Profile
+				a.riderSettings = NewRiderSettings(a.config)
+				a.screen = ScreenRiderSettings
+			case 8, 9: // Routes and Workouts Folder
+				// TODO: Allow editing folders
+			case 10: // Back
 				a.screen = ScreenMainMenu
 			}
 		}
@@ -365,25 +491,25 @@ func (a *App) updateSettings(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return a, nil
 }
 
-func (a *App) updateTrainerSettings(msg tea.Msg) (tea.Model, tea.Cmd) {
+func (a *App) updateDeviceSettings(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "esc":
 			a.screen = ScreenSettings
 		case "up", "k":
-			a.trainerSettings.MoveUp()
+			a.deviceSettings.MoveUp()
 		case "down", "j":
-			a.trainerSettings.MoveDown()
+			a.deviceSettings.MoveDown()
 		case "enter":
-			switch a.trainerSettings.Selected() {
-			case 0: // Scan for Trainers
-				a.scannerScreen = NewScannerScreen(a.config)
+			switch a.deviceSettings.Selected() {
+			case 0: // Scan for devices
+				a.scannerScreen = NewScannerScreen(a.config, a.deviceSettings.kind)
 				a.screen = ScreenScanner
 				return a, a.scannerScreen.StartScan()
-			case 1: // Forget Saved Trainer
-				a.config.Bluetooth.TrainerAddress = ""
-				a.trainerSettings.address = ""
+			case 1: // Forget saved device
+				a.deviceSettings.kind.SetAddress(a.config, "")
+				a.deviceSettings.address = ""
 				config.Save(a.config, config.DefaultConfigDir())
 			case 2: // Back
 				a.screen = ScreenSettings
@@ -411,12 +537,47 @@ func (a *App) updateBikeSettings(msg tea.Msg) (tea.Model, tea.Cmd) {
 			a.bikeSettings.MoveDown()
 		case "enter":
 			switch a.bikeSettings.Selected() {
-			case 0, 1, 2, 3: // Editable fields
-				a.bikeSettings.StartEdit()
-			case 4: // Back
+			case 0, 3, 8, 10: // Drivetrain, wheel size, position and tyre presets
+				a.bikeSettings.CyclePreset()
+			case 14: // Back
 				// Save config before leaving
 				config.Save(a.config, config.DefaultConfigDir())
 				a.screen = ScreenSettings
+			default: // Editable fields
+				a.bikeSettings.StartEdit()
+			}
+		}
+	}
+	return a, nil
+}
+
+func (a *App) updateRiderSettings(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		// If editing, delegate to rider settings
+		if a.riderSettings.IsEditing() {
+			a.riderSettings.HandleKey(msg.String())
+			return a, nil
+		}
+
+		switch msg.String() {
+		case "esc":
+			a.screen = ScreenSettings
+		case "up", "k":
+			a.riderSettings.MoveUp()
+		case "down", "j":
+			a.riderSettings.MoveDown()
+		case "enter":
+			switch a.riderSettings.Selected() {
+			case 4: // Zone Model
+				a.riderSettings.CycleZoneModel()
+			case 6: // Back
+				// Save config before leaving, FTP changes workout targets
+				config.Save(a.config, config.DefaultConfigDir())
+				a.workoutsBrowser = NewWorkoutsBrowser(a.config.Workouts.Folder, a.config.Rider.EffectiveFTP())
+				a.screen = ScreenSettings
+			default: // Editable fields
+				a.riderSettings.StartEdit()
 			}
 		}
 	}
@@ -429,13 +590,26 @@ func (a *App) updateHistory(msg tea.Msg) (tea.Model, tea.Cmd) {
 		switch msg.String() {
 		case "esc":
 			a.screen = ScreenMainMenu
+		case "tab":
+			a.historyView.ToggleTab()
 		case "up", "k":
 			a.historyView.MoveUp()
 		case "down", "j":
 			a.historyView.MoveDown()
 		case "enter":
+			if !a.historyView.ShowingRides() {
+				break
+			}
 			if ride := a.historyView.SelectedRide(); ride != nil {
-				// TODO: Show ride detail
+				model, err := a.config.Rider.Zones()
+				if err != nil {
+					model = zones.Coggan
+				}
+				a.rideDetail = NewRideDetail(ride.ID, a.config.Rider.EffectiveFTP(), model)
+				if a.width > 0 && a.height > 0 {
+					a.rideDetail.SetSize(a.width, a.height)
+				}
+				a.screen = ScreenRideDetail
 			} else {
 				// Back selected
 				a.screen = ScreenMainMenu
@@ -445,6 +619,75 @@ func (a *App) updateHistory(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return a, nil
 }
 
+func (a *App) updateRideDetail(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		key := msg.String()
+		if a.rideDetail.ConfirmingDelete() {
+			if key == "y" {
+				a.rideDetail.Delete()
+			} else {
+				a.rideDetail.CancelDelete()
+			}
+			if a.rideDetail.Deleted() {
+				// Reload the list without the deleted ride
+				a.historyView = NewHistoryView(a.config.Rider.EffectiveFTP())
+				a.rideDetail = nil
+				a.screen = ScreenHistory
+			}
+			return a, nil
+		}
+
+		switch key {
+		case "esc", "q":
+			a.rideDetail = nil
+			a.screen = ScreenHistory
+		case "tab":
+			a.rideDetail.NextPage()
+		case "d":
+			a.rideDetail.RequestDelete()
+		default:
+			a.rideDetail.Export(key)
+		}
+	}
+	return a, nil
+}
+
+func (a *App) updateRecover(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		key := msg.String()
+		if a.recoverScreen.ConfirmingDiscard() {
+			if key == "y" {
+				a.recoverScreen.Discard()
+			} else {
+				a.recoverScreen.CancelDiscard()
+			}
+			return a, nil
+		}
+
+		switch key {
+		case "esc", "q":
+			// Unfinished rides are offered again on the next start
+			a.recoverScreen = nil
+			a.screen = ScreenMainMenu
+		case "up", "k":
+			a.recoverScreen.MoveUp()
+		case "down", "j":
+			a.recoverScreen.MoveDown()
+		case "s":
+			a.recoverScreen.Save()
+		case "d":
+			a.recoverScreen.RequestDiscard()
+		case "c":
+			if u := a.recoverScreen.SelectedRide(); u != nil {
+				return a, a.resumeRide(*u)
+			}
+		}
+	}
+	return a, nil
+}
+
 func (a *App) updateRide(msg tea.Msg) (tea.Model, tea.Cmd) {
 	if a.rideScreen != nil {
 		return a, a.rideScreen.Update(msg)
@@ -464,27 +707,27 @@ func (a *App) updateScanner(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		switch msg.String() {
 		case "esc":
-			a.screen = ScreenTrainerSettings
+			a.screen = ScreenDeviceSettings
 		case "up", "k":
 			a.scannerScreen.MoveUp()
 		case "down", "j":
 			a.scannerScreen.MoveDown()
 		case "r":
 			// Retry scan
-			a.scannerScreen = NewScannerScreen(a.config)
+			a.scannerScreen = NewScannerScreen(a.config, a.scannerScreen.kind)
 			return a, a.scannerScreen.StartScan()
 		case "enter":
 			if device := a.scannerScreen.SelectDevice(); device != nil {
 				// Save selected device
-				a.config.Bluetooth.TrainerAddress = device.Address
+				a.scannerScreen.kind.SetAddress(a.config, device.Address)
 				config.Save(a.config, config.DefaultConfigDir())
-				if a.trainerSettings != nil {
-					a.trainerSettings.address = device.Address
+				if a.deviceSettings != nil {
+					a.deviceSettings.address = device.Address
 				}
-				a.screen = ScreenTrainerSettings
+				a.screen = ScreenDeviceSettings
 			} else {
 				// Back selected
-				a.screen = ScreenTrainerSettings
+				a.screen = ScreenDeviceSettings
 			}
 		}
 	}
@@ -518,33 +761,57 @@ func (a *App) updateConnecting(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return a, cmd
 }
 
-func (a *App) startRide(rideType RideType, route *RouteInfo) tea.Cmd {
+func (a *App) startRide(rideType RideType, route *RouteInfo, w *WorkoutInfo) tea.Cmd {
 	// Create ride session with real Bluetooth
 	// Set mock=false to use actual trainer, mock=true for development testing
-	session, err := NewRideSession(a.config, rideType, route, false)
+	session, err := NewRideSession(a.config, rideType, route, w, false)
 	if err != nil {
 		a.connectStatus = err.Error()
 		return nil
 	}
+	return a.beginRide(session, route)
+}
 
+// resumeRide continues an unfinished ride on its route or workout
+func (a *App) resumeRide(u data.UnfinishedRide) tea.Cmd {
+	session, route, err := ResumeRideSession(a.config, u, false)
+	if err != nil {
+		a.recoverScreen.SetMessage(fmt.Sprintf("Cannot continue: %v", err))
+		return nil
+	}
+	return a.beginRide(session, route)
+}
+
+// beginRide shows the ride screen for session and connects to the trainer
+func (a *App) beginRide(session *RideSession, route *RouteInfo) tea.Cmd {
 	a.rideSession = session
 	a.rideScreen = NewRideScreen(route)
+	zoneModel, _ := a.config.Rider.Zones() // checked when the config was loaded
+	a.rideScreen.SetZones(zoneModel, a.config.Rider.EffectiveFTP())
 
 	// Set up callbacks
 	a.rideScreen.SetCallbacks(
 		func() { session.ShiftUp() },
 		func() { session.ShiftDown() },
+		func() { session.FrontShiftUp() },
+		func() { session.FrontShiftDown() },
 		func() { session.AdjustResistance(5) },
 		func() { session.AdjustResistance(-5) },
 		func() { session.TogglePause() },
-		func() {
-			// Stop ride and return to menu
-			session.Stop()
+		func() tea.Cmd {
+			// Stop ride and return to menu, saving it in the background
 			a.screen = ScreenMainMenu
 			a.rideScreen = nil
 			a.rideSession = nil
+			return session.Stop()
 		},
 	)
+	a.rideScreen.SetWorkoutCallbacks(
+		func() { session.SkipStep() },
+		func() { session.ExtendStep(workoutExtendStep) },
+		func() { session.AdjustIntensity(-workoutIntensityStep) },
+		func() { session.AdjustIntensity(workoutIntensityStep) },
+	)
 
 	// Create connecting screen
 	a.connectingScreen = NewConnectingScreen()
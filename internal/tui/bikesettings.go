@@ -2,10 +2,13 @@ package tui
 
 import (
 	"fmt"
+	"math"
 	"strconv"
 	"strings"
 
+	"github.com/charmbracelet/lipgloss"
 	"github.com/thiemotorres/goc/internal/config"
+	"github.com/thiemotorres/goc/internal/simulation"
 )
 
 // BikeSettings shows bike configuration options
@@ -16,15 +19,26 @@ type BikeSettings struct {
 	editing     bool
 	editField   int
 	editBuffer  string
+	err         string // last rejected edit
 }
 
 func NewBikeSettings(cfg *config.Config) *BikeSettings {
 	return &BikeSettings{
 		items: []string{
+			"Preset",
 			"Chainrings",
 			"Cassette",
+			"Wheel Size",
 			"Wheel Circumference",
 			"Rider Weight",
+			"Bike Weight",
+			"Flywheel Mass",
+			"Position",
+			"CdA",
+			"Tyres",
+			"Crr",
+			"Altitude",
+			"Temperature",
 			"← Back",
 		},
 		config: cfg,
@@ -57,17 +71,103 @@ func (m *BikeSettings) StartEdit() {
 
 	// Pre-fill with current value
 	switch m.selected {
-	case 0: // Chainrings
+	case 1: // Chainrings
 		m.editBuffer = intsToString(m.config.Bike.Chainrings)
-	case 1: // Cassette
+	case 2: // Cassette
 		m.editBuffer = intsToString(m.config.Bike.Cassette)
-	case 2: // Wheel Circumference
+	case 4: // Wheel Circumference
 		m.editBuffer = fmt.Sprintf("%.3f", m.config.Bike.WheelCircumference)
-	case 3: // Rider Weight
-		m.editBuffer = fmt.Sprintf("%.1f", m.config.Bike.RiderWeight)
+	case 5: // Rider Weight
+		m.editBuffer = fmt.Sprintf("%.1f", m.config.Rider.Weight)
+	case 6: // Bike Weight
+		m.editBuffer = fmt.Sprintf("%.1f", m.config.Bike.BikeWeight)
+	case 7: // Flywheel Mass
+		m.editBuffer = fmt.Sprintf("%.1f", m.config.Bike.FlywheelMass)
+	case 9: // CdA, empty uses the position preset
+		m.editBuffer = formatOverride(m.config.Bike.CdA, "%.3f")
+	case 11: // Crr, empty uses the tyre preset
+		m.editBuffer = formatOverride(m.config.Bike.Crr, "%.4f")
+	case 12: // Altitude
+		m.editBuffer = fmt.Sprintf("%.0f", m.config.Bike.Altitude)
+	case 13: // Temperature
+		m.editBuffer = fmt.Sprintf("%.0f", m.config.Bike.Temperature)
 	}
 }
 
+// CyclePreset selects the next drivetrain, wheel size, position or tyre
+// preset. Position and tyre presets drop the matching CdA or Crr override.
+func (m *BikeSettings) CyclePreset() {
+	m.err = ""
+	switch m.selected {
+	case 0: // Drivetrain preset
+		name := nextDrivetrain(m.config.Bike.Drivetrains(), m.config.Bike.Preset)
+		if err := m.config.Bike.ApplyPreset(name); err != nil {
+			m.err = err.Error()
+		}
+		return
+	case 3: // Wheel Size
+		m.config.Bike.WheelCircumference = nextWheelSize(m.config.Bike.WheelCircumference).Circumference
+	case 8: // Position
+		m.config.Bike.Position = nextPreset(simulation.PositionPresets, m.config.Bike.Position)
+		m.config.Bike.CdA = 0
+	case 10: // Tyres
+		m.config.Bike.Tyres = nextPreset(simulation.TyrePresets, m.config.Bike.Tyres)
+		m.config.Bike.Crr = 0
+	}
+}
+
+// nextDrivetrain returns the name of the drivetrain after current
+func nextDrivetrain(drivetrains []simulation.Drivetrain, current string) string {
+	for i, d := range drivetrains {
+		if d.Name == current {
+			return drivetrains[(i+1)%len(drivetrains)].Name
+		}
+	}
+	return drivetrains[0].Name
+}
+
+// nextWheelSize returns the wheel size after the one matching circumference
+func nextWheelSize(circumference float64) simulation.WheelSize {
+	for i, w := range simulation.WheelSizes {
+		if math.Abs(w.Circumference-circumference) < 0.0005 {
+			return simulation.WheelSizes[(i+1)%len(simulation.WheelSizes)]
+		}
+	}
+	return simulation.WheelSizes[0]
+}
+
+// nextPreset returns the name of the preset after current
+func nextPreset(presets []simulation.Preset, current string) string {
+	for i, p := range presets {
+		if p.Name == current {
+			return presets[(i+1)%len(presets)].Name
+		}
+	}
+	return presets[0].Name
+}
+
+// formatOverride formats an optional value, empty when unset
+func formatOverride(v float64, format string) string {
+	if v == 0 {
+		return ""
+	}
+	return fmt.Sprintf(format, v)
+}
+
+// presetName describes the drivetrain preset, noting edits made since it
+// was applied
+func (m *BikeSettings) presetName() string {
+	b := m.config.Bike
+	if b.Preset == "" {
+		return "custom"
+	}
+	d, err := simulation.LookupDrivetrain(b.Drivetrains(), b.Preset)
+	if err != nil || !d.Matches(b.Chainrings, b.Cassette, b.WheelCircumference) {
+		return b.Preset + ", modified"
+	}
+	return b.Preset
+}
+
 func (m *BikeSettings) CancelEdit() {
 	m.editing = false
 	m.editBuffer = ""
@@ -92,7 +192,7 @@ func (m *BikeSettings) HandleKey(key string) bool {
 		return true
 	default:
 		// Only accept valid characters
-		if len(key) == 1 && (key[0] >= '0' && key[0] <= '9' || key[0] == '.' || key[0] == ',' || key[0] == ' ') {
+		if len(key) == 1 && (key[0] >= '0' && key[0] <= '9' || key[0] == '.' || key[0] == ',' || key[0] == ' ' || key[0] == '-') {
 			m.editBuffer += key
 		}
 	}
@@ -100,24 +200,62 @@ func (m *BikeSettings) HandleKey(key string) bool {
 }
 
 func (m *BikeSettings) applyEdit() {
+	previous := m.config.Bike
+	value, parseErr := strconv.ParseFloat(strings.TrimSpace(m.editBuffer), 64)
+
 	switch m.editField {
-	case 0: // Chainrings
+	case 1: // Chainrings
 		if ints := parseInts(m.editBuffer); len(ints) > 0 {
 			m.config.Bike.Chainrings = ints
 		}
-	case 1: // Cassette
+	case 2: // Cassette
 		if ints := parseInts(m.editBuffer); len(ints) > 0 {
 			m.config.Bike.Cassette = ints
 		}
-	case 2: // Wheel Circumference
+	case 4: // Wheel Circumference
 		if f, err := strconv.ParseFloat(strings.TrimSpace(m.editBuffer), 64); err == nil && f > 0 {
 			m.config.Bike.WheelCircumference = f
 		}
-	case 3: // Rider Weight
+	case 5: // Rider Weight
 		if f, err := strconv.ParseFloat(strings.TrimSpace(m.editBuffer), 64); err == nil && f > 0 {
-			m.config.Bike.RiderWeight = f
+			m.config.Rider.Weight = f
+		}
+	case 6: // Bike Weight
+		if parseErr == nil {
+			m.config.Bike.BikeWeight = value
+		}
+	case 7: // Flywheel Mass
+		if parseErr == nil {
+			m.config.Bike.FlywheelMass = value
+		}
+	case 9: // CdA
+		if strings.TrimSpace(m.editBuffer) == "" {
+			m.config.Bike.CdA = 0
+		} else if parseErr == nil {
+			m.config.Bike.CdA = value
+		}
+	case 11: // Crr
+		if strings.TrimSpace(m.editBuffer) == "" {
+			m.config.Bike.Crr = 0
+		} else if parseErr == nil {
+			m.config.Bike.Crr = value
+		}
+	case 12: // Altitude
+		if parseErr == nil {
+			m.config.Bike.Altitude = value
+		}
+	case 13: // Temperature
+		if parseErr == nil {
+			m.config.Bike.Temperature = value
 		}
 	}
+
+	// Keep the previous value if the new one is out of range
+	m.err = ""
+	if err := m.config.Validate(); err != nil {
+		m.config.Bike = previous
+		m.err = err.Error()
+	}
 }
 
 func (m *BikeSettings) View() string {
@@ -127,6 +265,8 @@ func (m *BikeSettings) View() string {
 	b.WriteString(title)
 	b.WriteString("\n\n")
 
+	physics, _ := m.config.Bike.Physics()
+
 	for i, item := range m.items {
 		cursor := "  "
 		style := normalStyle
@@ -138,14 +278,46 @@ func (m *BikeSettings) View() string {
 		// Add current value
 		var value string
 		switch i {
-		case 0: // Chainrings
+		case 0: // Preset
+			value = " (" + m.presetName() + ")"
+		case 1: // Chainrings
 			value = fmt.Sprintf(" [%s]", intsToString(m.config.Bike.Chainrings))
-		case 1: // Cassette
+		case 2: // Cassette
 			value = fmt.Sprintf(" [%s]", intsToString(m.config.Bike.Cassette))
-		case 2: // Wheel Circumference
+		case 3: // Wheel Size
+			if w, ok := simulation.MatchWheelSize(m.config.Bike.WheelCircumference); ok {
+				value = " (" + w.Name + ")"
+			} else {
+				value = " (custom)"
+			}
+		case 4: // Wheel Circumference
 			value = fmt.Sprintf(" (%.3fm)", m.config.Bike.WheelCircumference)
-		case 3: // Rider Weight
-			value = fmt.Sprintf(" (%.1f kg)", m.config.Bike.RiderWeight)
+		case 5: // Rider Weight
+			value = fmt.Sprintf(" (%.1f kg)", m.config.Rider.Weight)
+		case 6: // Bike Weight
+			value = fmt.Sprintf(" (%.1f kg)", m.config.Bike.BikeWeight)
+		case 7: // Flywheel Mass
+			value = fmt.Sprintf(" (%.1f kg)", m.config.Bike.FlywheelMass)
+		case 8: // Position
+			value = fmt.Sprintf(" (%s)", m.config.Bike.Position)
+		case 9: // CdA
+			value = fmt.Sprintf(" (%.3f m²", physics.CdA)
+			if m.config.Bike.CdA == 0 {
+				value += ", from position"
+			}
+			value += ")"
+		case 10: // Tyres
+			value = fmt.Sprintf(" (%s)", m.config.Bike.Tyres)
+		case 11: // Crr
+			value = fmt.Sprintf(" (%.4f", physics.Crr)
+			if m.config.Bike.Crr == 0 {
+				value += ", from tyres"
+			}
+			value += ")"
+		case 12: // Altitude
+			value = fmt.Sprintf(" (%.0f m)", m.config.Bike.Altitude)
+		case 13: // Temperature
+			value = fmt.Sprintf(" (%.0f °C)", m.config.Bike.Temperature)
 		}
 
 		line := item + value
@@ -158,11 +330,17 @@ func (m *BikeSettings) View() string {
 		b.WriteString(cursor + style.Render(line) + "\n")
 	}
 
+	b.WriteString(helpStyle.Render(fmt.Sprintf("\nAir density: %.3f kg/m³", physics.AirDensity)) + "\n")
+	if m.err != "" {
+		errStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+		b.WriteString(errStyle.Render(m.err) + "\n")
+	}
+
 	var help string
 	if m.editing {
 		help = helpStyle.Render("\nenter: save • esc: cancel")
 	} else {
-		help = helpStyle.Render("\n↑/↓: navigate • enter: edit • esc: back")
+		help = helpStyle.Render("\n↑/↓: navigate • enter: edit/next preset • esc: back")
 	}
 	b.WriteString(help)
 
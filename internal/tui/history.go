@@ -5,18 +5,44 @@ import (
 	"strings"
 	"time"
 
+	"github.com/NimbleMarkets/ntcharts/linechart/timeserieslinechart"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/thiemotorres/goc/internal/analytics"
 	"github.com/thiemotorres/goc/internal/data"
 )
 
-// HistoryView shows past rides
+// historyTab is a page of the history screen
+type historyTab int
+
+const (
+	historyRides   historyTab = iota // ride list
+	historyFitness                   // performance management chart
+)
+
+// PMC chart settings
+const (
+	pmcChartDays   = 90 // days of history shown
+	pmcProjectDays = 14 // days of projected form
+	pmcChartWidth  = 70
+	pmcChartHeight = 14
+)
+
+// HistoryView shows past rides and the training load they add up to
 type HistoryView struct {
 	rides    []data.RideSummary
 	selected int
+	tab      historyTab
+	ftp      float64 // scores rides saved without an FTP
 	err      error
+
+	// Performance management chart
+	pmc        []analytics.Day
+	projection []analytics.Day
+	load       float64 // recent daily TSS the projection assumes
 }
 
-func NewHistoryView() *HistoryView {
-	hv := &HistoryView{}
+func NewHistoryView(ftp float64) *HistoryView {
+	hv := &HistoryView{ftp: ftp}
 	hv.loadRides()
 	return hv
 }
@@ -30,6 +56,31 @@ func (hv *HistoryView) loadRides() {
 	defer store.Close()
 
 	hv.rides, hv.err = store.ListRides()
+	if hv.err != nil || len(hv.rides) == 0 {
+		return
+	}
+
+	workloads := make([]analytics.Workload, len(hv.rides))
+	for i, r := range hv.rides {
+		workloads[i] = r.Workload(hv.ftp)
+	}
+	hv.pmc = analytics.PMC(workloads, time.Now())
+	hv.load = analytics.AverageTSS(hv.pmc, analytics.ATLDays)
+	hv.projection = analytics.Project(hv.pmc, pmcProjectDays, hv.load)
+}
+
+// ToggleTab switches between the ride list and the fitness chart
+func (hv *HistoryView) ToggleTab() {
+	if hv.tab == historyRides {
+		hv.tab = historyFitness
+	} else {
+		hv.tab = historyRides
+	}
+}
+
+// ShowingRides reports whether the ride list is shown
+func (hv *HistoryView) ShowingRides() bool {
+	return hv.tab == historyRides
 }
 
 func (hv *HistoryView) MoveUp() {
@@ -61,7 +112,14 @@ func (hv *HistoryView) View() string {
 
 	title := titleStyle.Render("Ride History")
 	b.WriteString(title)
-	b.WriteString("\n\n")
+	b.WriteString("\n")
+	b.WriteString(hv.tabsView() + "\n\n")
+
+	if hv.tab == historyFitness {
+		b.WriteString(hv.fitnessView())
+		b.WriteString(helpStyle.Render("\ntab: rides • esc: back"))
+		return centerView(menuStyle.Render(b.String()))
+	}
 
 	if hv.err != nil {
 		b.WriteString(fmt.Sprintf("Error: %v\n", hv.err))
@@ -78,16 +136,16 @@ func (hv *HistoryView) View() string {
 
 			date := ride.StartTime.Format("Jan 02")
 			duration := formatDuration(ride.Duration)
-			name := ride.GPXName
-			if name == "" {
-				name = "Free Ride"
-			}
+			name := rideName(ride.Name, ride.GPXName)
 
-			line := fmt.Sprintf("%-6s  %-16s  %8s  %4.0fW avg",
+			line := fmt.Sprintf("%-6s  %-16s  %8s  %4.0fW avg  %5s NP  %4s IF  %3s TSS",
 				date,
 				truncate(name, 16),
 				duration,
 				ride.AvgPower,
+				formatMetric(ride.NormalizedPower, "%.0fW"),
+				formatMetric(ride.IntensityFactor, "%.2f"),
+				formatMetric(ride.TSS, "%.0f"),
 			)
 			b.WriteString(cursor + style.Render(line) + "\n")
 		}
@@ -102,12 +160,113 @@ func (hv *HistoryView) View() string {
 	}
 	b.WriteString("\n" + cursor + style.Render("← Back") + "\n")
 
-	help := helpStyle.Render("\n↑/↓: navigate • enter: view • esc: back")
+	help := helpStyle.Render("\n↑/↓: navigate • enter: view • tab: fitness • esc: back")
 	b.WriteString(help)
 
 	return centerView(menuStyle.Render(b.String()))
 }
 
+// tabsView shows the tab names with the current one highlighted
+func (hv *HistoryView) tabsView() string {
+	names := []string{"Rides", "Fitness"}
+	for i, name := range names {
+		if historyTab(i) == hv.tab {
+			names[i] = selectedStyle.Render("[" + name + "]")
+		} else {
+			names[i] = disabledStyle.Render(" " + name + " ")
+		}
+	}
+	return strings.Join(names, " ")
+}
+
+// Colours of the PMC lines
+var (
+	ctlColor = lipgloss.Color("39")
+	atlColor = lipgloss.Color("205")
+	tsbColor = lipgloss.Color("226")
+)
+
+// fitnessView shows fitness, fatigue and form over recent months with
+// projected form at the current load
+func (hv *HistoryView) fitnessView() string {
+	if hv.err != nil {
+		return fmt.Sprintf("Error: %v\n", hv.err)
+	}
+	if len(hv.pmc) == 0 {
+		return "No rides recorded yet.\n"
+	}
+
+	var b strings.Builder
+
+	days := hv.pmc[max(len(hv.pmc)-pmcChartDays, 0):]
+	b.WriteString(buildPMCChart(days, hv.projection) + "\n")
+
+	ctlStyle := lipgloss.NewStyle().Foreground(ctlColor)
+	atlStyle := lipgloss.NewStyle().Foreground(atlColor)
+	tsbStyle := lipgloss.NewStyle().Foreground(tsbColor)
+
+	today := hv.pmc[len(hv.pmc)-1]
+	b.WriteString(fmt.Sprintf("%s %.0f   %s %.0f   %s %+.0f\n",
+		ctlStyle.Render("Fitness (CTL)"), today.CTL,
+		atlStyle.Render("Fatigue (ATL)"), today.ATL,
+		tsbStyle.Render("Form (TSB)"), today.TSB))
+
+	if len(hv.projection) > 0 {
+		end := hv.projection[len(hv.projection)-1]
+		b.WriteString(helpStyle.Render(fmt.Sprintf("Form in %d days at %.0f TSS/day: %+.0f (dim line)",
+			pmcProjectDays, hv.load, end.TSB)) + "\n")
+	}
+
+	return b.String()
+}
+
+// buildPMCChart draws CTL, ATL and TSB, and the projected TSB after them
+func buildPMCChart(days, projection []analytics.Day) string {
+	chart := timeserieslinechart.New(pmcChartWidth, pmcChartHeight)
+
+	for _, d := range days {
+		chart.PushDataSet("ctl", timeserieslinechart.TimePoint{Time: d.Date, Value: d.CTL})
+		chart.PushDataSet("atl", timeserieslinechart.TimePoint{Time: d.Date, Value: d.ATL})
+		chart.PushDataSet("tsb", timeserieslinechart.TimePoint{Time: d.Date, Value: d.TSB})
+	}
+	if len(days) > 0 && len(projection) > 0 {
+		// Start the projection where form ends so the lines join
+		last := days[len(days)-1]
+		chart.PushDataSet("projected", timeserieslinechart.TimePoint{Time: last.Date, Value: last.TSB})
+	}
+	for _, d := range projection {
+		chart.PushDataSet("projected", timeserieslinechart.TimePoint{Time: d.Date, Value: d.TSB})
+	}
+
+	chart.SetDataSetStyle("ctl", lipgloss.NewStyle().Foreground(ctlColor))
+	chart.SetDataSetStyle("atl", lipgloss.NewStyle().Foreground(atlColor))
+	chart.SetDataSetStyle("tsb", lipgloss.NewStyle().Foreground(tsbColor))
+	chart.SetDataSetStyle("projected", lipgloss.NewStyle().Foreground(tsbColor).Faint(true))
+
+	chart.DrawBrailleAll()
+	return chart.View()
+}
+
+// rideName is the name a ride is listed under: its own, else its route
+func rideName(name, gpxName string) string {
+	switch {
+	case name != "":
+		return name
+	case gpxName != "":
+		return gpxName
+	default:
+		return "Free Ride"
+	}
+}
+
+// formatMetric formats a ride metric, "-" when it was not recorded
+func formatMetric(v float64, format string) string {
+	if v == 0 {
+		return "-"
+	}
+	return fmt.Sprintf(format, v)
+}
+
 func formatDuration(d time.Duration) string {
 	h := int(d.Hours())
 	m := int(d.Minutes()) % 60
@@ -18,6 +18,7 @@ func NewMainMenu() *MainMenu {
 		items: []string{
 			"Start Ride",
 			"Browse Routes",
+			"Browse Workouts",
 			"Ride History",
 			"Settings",
 			"Quit",
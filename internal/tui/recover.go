@@ -0,0 +1,179 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/thiemotorres/goc/internal/data"
+)
+
+// RecoverScreen offers the rides left unfinished by a crash: save them
+// as they are, continue riding or discard them
+type RecoverScreen struct {
+	rides          []data.UnfinishedRide
+	selected       int
+	confirmDiscard bool
+	message        string // result of the last action
+	err            error
+}
+
+func NewRecoverScreen() *RecoverScreen {
+	rs := &RecoverScreen{}
+	rs.loadRides()
+	return rs
+}
+
+func (rs *RecoverScreen) loadRides() {
+	store, err := data.NewStore(data.DefaultDataDir())
+	if err != nil {
+		rs.err = err
+		return
+	}
+	defer store.Close()
+
+	rs.rides, rs.err = store.UnfinishedRides()
+	rs.selected = min(rs.selected, max(len(rs.rides)-1, 0))
+}
+
+// Empty reports whether there is nothing left to recover
+func (rs *RecoverScreen) Empty() bool {
+	return rs.err == nil && len(rs.rides) == 0
+}
+
+// SetMessage shows a note above the list, such as why a ride wasn't saved
+func (rs *RecoverScreen) SetMessage(msg string) {
+	rs.message = msg
+}
+
+func (rs *RecoverScreen) MoveUp() {
+	if rs.selected > 0 {
+		rs.selected--
+	}
+}
+
+func (rs *RecoverScreen) MoveDown() {
+	if rs.selected < len(rs.rides)-1 {
+		rs.selected++
+	}
+}
+
+// SelectedRide returns the highlighted ride, nil if there is none
+func (rs *RecoverScreen) SelectedRide() *data.UnfinishedRide {
+	if rs.selected < len(rs.rides) {
+		return &rs.rides[rs.selected]
+	}
+	return nil
+}
+
+// Save stores the selected ride as it is
+func (rs *RecoverScreen) Save() {
+	u := rs.SelectedRide()
+	if u == nil {
+		return
+	}
+
+	store, err := data.NewStore(data.DefaultDataDir())
+	if err != nil {
+		rs.message = fmt.Sprintf("Save failed: %v", err)
+		return
+	}
+	defer store.Close()
+
+	if _, err := store.RecoverRide(u.Ride.ID); err != nil {
+		rs.message = fmt.Sprintf("Save failed: %v", err)
+		return
+	}
+	rs.message = fmt.Sprintf("Saved ride %s", u.Ride.ID)
+	rs.loadRides()
+}
+
+// RequestDiscard asks for confirmation before discarding the ride
+func (rs *RecoverScreen) RequestDiscard() {
+	if rs.SelectedRide() != nil {
+		rs.confirmDiscard = true
+		rs.message = ""
+	}
+}
+
+// ConfirmingDiscard reports whether a discard is waiting for confirmation
+func (rs *RecoverScreen) ConfirmingDiscard() bool {
+	return rs.confirmDiscard
+}
+
+// CancelDiscard keeps the ride
+func (rs *RecoverScreen) CancelDiscard() {
+	rs.confirmDiscard = false
+}
+
+// Discard drops the selected ride
+func (rs *RecoverScreen) Discard() {
+	rs.confirmDiscard = false
+	u := rs.SelectedRide()
+	if u == nil {
+		return
+	}
+
+	store, err := data.NewStore(data.DefaultDataDir())
+	if err != nil {
+		rs.message = fmt.Sprintf("Discard failed: %v", err)
+		return
+	}
+	defer store.Close()
+
+	if err := store.DiscardJournal(u.Ride.ID); err != nil {
+		rs.message = fmt.Sprintf("Discard failed: %v", err)
+		return
+	}
+	rs.message = fmt.Sprintf("Discarded ride %s", u.Ride.ID)
+	rs.loadRides()
+}
+
+func (rs *RecoverScreen) View() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("Unfinished Rides"))
+	b.WriteString("\n")
+
+	if rs.message != "" {
+		b.WriteString(rs.message + "\n\n")
+	}
+
+	switch {
+	case rs.err != nil:
+		b.WriteString(fmt.Sprintf("Error: %v\n", rs.err))
+	case len(rs.rides) == 0:
+		b.WriteString("No unfinished rides.\n")
+	default:
+		b.WriteString("These rides ended without being saved.\n\n")
+		for i, u := range rs.rides {
+			cursor := "  "
+			style := normalStyle
+			if i == rs.selected {
+				cursor = "> "
+				style = selectedStyle
+			}
+
+			stats := u.Ride.Stats()
+			line := fmt.Sprintf("%-12s  %-16s  %8s  %6.1f km",
+				u.Ride.StartTime.Format("Jan 02 15:04"),
+				truncate(rideName(u.Ride.Name, u.Ride.GPXName), 16),
+				formatDuration(stats.Duration),
+				stats.Distance/1000)
+			b.WriteString(cursor + style.Render(line) + "\n")
+		}
+	}
+
+	if rs.confirmDiscard {
+		errStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("196")).Bold(true)
+		b.WriteString("\n" + errStyle.Render("Discard this ride? [y] Yes  [any key] No") + "\n")
+	}
+
+	help := helpStyle.Render("\n↑/↓: navigate • s: save • c: continue riding • d: discard • esc: later")
+	if len(rs.rides) == 0 {
+		help = helpStyle.Render("\nesc: back")
+	}
+	b.WriteString(help)
+
+	return centerView(menuStyle.Render(b.String()))
+}
@@ -7,8 +7,11 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/NimbleMarkets/ntcharts/canvas/runes"
 	"github.com/NimbleMarkets/ntcharts/linechart/streamlinechart"
 	"github.com/thiemotorres/goc/internal/gpx"
+	"github.com/thiemotorres/goc/internal/workout"
+	"github.com/thiemotorres/goc/internal/zones"
 )
 
 // RideScreen is the active ride display
@@ -20,16 +23,28 @@ type RideScreen struct {
 	route     *RouteInfo
 	routeView *RouteView
 
+	// Workout, nil outside structured workouts
+	workout    *workout.Status
+	stepNotice string // workout step change being announced
+
 	// Charts
 	powerChart   streamlinechart.Model
 	cadenceChart streamlinechart.Model
 	speedChart   streamlinechart.Model
+	hrChart      streamlinechart.Model
 	maxPoints    int
 
 	// Current values
-	power   float64
-	cadence float64
-	speed   float64
+	power     float64
+	cadence   float64
+	speed     float64
+	heartRate int
+	hasHR     bool // a heart rate has been seen, show the HR panel
+
+	// Power zones
+	zones zones.Model
+	ftp   float64
+	zone  int // current power zone, -1 without zones
 
 	// State
 	elapsed    time.Duration
@@ -42,14 +57,21 @@ type RideScreen struct {
 	gear       string
 	mode       string
 	paused     bool
+	warning    string
 
 	// Callbacks
 	onShiftUp   func()
 	onShiftDown func()
+	onFrontUp   func()
+	onFrontDown func()
 	onResUp     func()
 	onResDown   func()
 	onPause     func()
-	onQuit      func()
+	onQuit      func() tea.Cmd
+	onSkip      func()
+	onExtend    func()
+	onEasier    func()
+	onHarder    func()
 }
 
 func NewRideScreen(route *RouteInfo) *RideScreen {
@@ -58,6 +80,7 @@ func NewRideScreen(route *RouteInfo) *RideScreen {
 	powerChart := streamlinechart.New(60, 15)
 	cadenceChart := streamlinechart.New(60, 15)
 	speedChart := streamlinechart.New(60, 15)
+	hrChart := streamlinechart.New(60, 15)
 
 	// Load GPX route if provided
 	var routeView *RouteView
@@ -74,19 +97,38 @@ func NewRideScreen(route *RouteInfo) *RideScreen {
 		powerChart:   powerChart,
 		cadenceChart: cadenceChart,
 		speedChart:   speedChart,
+		hrChart:      hrChart,
 		maxPoints:    300, // ~5 minutes of data at 1 update/sec
+		zone:         -1,
 	}
 }
 
-func (rs *RideScreen) SetCallbacks(shiftUp, shiftDown, resUp, resDown, pause, quit func()) {
+// SetZones sets the power zones used to label and colour power
+func (rs *RideScreen) SetZones(model zones.Model, ftp float64) {
+	rs.zones = model
+	rs.ftp = ftp
+}
+
+func (rs *RideScreen) SetCallbacks(shiftUp, shiftDown, frontUp, frontDown, resUp, resDown, pause func(), quit func() tea.Cmd) {
 	rs.onShiftUp = shiftUp
 	rs.onShiftDown = shiftDown
+	rs.onFrontUp = frontUp
+	rs.onFrontDown = frontDown
 	rs.onResUp = resUp
 	rs.onResDown = resDown
 	rs.onPause = pause
 	rs.onQuit = quit
 }
 
+// SetWorkoutCallbacks sets the workout controls: skip the step, extend it
+// and make the rest of the workout easier or harder
+func (rs *RideScreen) SetWorkoutCallbacks(skip, extend, easier, harder func()) {
+	rs.onSkip = skip
+	rs.onExtend = extend
+	rs.onEasier = easier
+	rs.onHarder = harder
+}
+
 func (rs *RideScreen) Update(msg tea.Msg) tea.Cmd {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
@@ -99,6 +141,14 @@ func (rs *RideScreen) Update(msg tea.Msg) tea.Cmd {
 			if rs.onShiftDown != nil {
 				rs.onShiftDown()
 			}
+		case "shift+up", "K":
+			if rs.onFrontUp != nil {
+				rs.onFrontUp()
+			}
+		case "shift+down", "J":
+			if rs.onFrontDown != nil {
+				rs.onFrontDown()
+			}
 		case "right", "l":
 			if rs.onResUp != nil {
 				rs.onResUp()
@@ -111,13 +161,29 @@ func (rs *RideScreen) Update(msg tea.Msg) tea.Cmd {
 			if rs.onPause != nil {
 				rs.onPause()
 			}
+		case "n":
+			if rs.onSkip != nil {
+				rs.onSkip()
+			}
+		case "e":
+			if rs.onExtend != nil {
+				rs.onExtend()
+			}
+		case "-":
+			if rs.onEasier != nil {
+				rs.onEasier()
+			}
+		case "+", "=":
+			if rs.onHarder != nil {
+				rs.onHarder()
+			}
 		case "tab":
 			if rs.routeView != nil {
 				rs.routeView.ToggleMode()
 			}
 		case "q":
 			if rs.onQuit != nil {
-				rs.onQuit()
+				return rs.onQuit()
 			}
 		}
 	case tea.WindowSizeMsg:
@@ -138,12 +204,30 @@ func (rs *RideScreen) UpdateMetrics(power, cadence, speed float64) {
 	rs.cadence = cadence
 	rs.speed = speed
 
+	// Colour the power line by the current zone
+	if zone := rs.zones.Zone(power, rs.ftp); zone != rs.zone {
+		rs.zone = zone
+		rs.powerChart.SetStyles(runes.ArcLineStyle, lipgloss.NewStyle().Foreground(zoneColor(zone, len(rs.zones.Zones))))
+	}
+
 	// Push new data to charts
 	rs.powerChart.Push(power)
 	rs.cadenceChart.Push(cadence)
 	rs.speedChart.Push(speed)
 }
 
+// UpdateHeartRate sets the current heart rate, 0 if unavailable.
+// The HR panel appears once a heart rate has been seen.
+func (rs *RideScreen) UpdateHeartRate(hr int) {
+	rs.heartRate = hr
+	if hr > 0 {
+		rs.hasHR = true
+	}
+	if rs.hasHR {
+		rs.hrChart.Push(float64(hr))
+	}
+}
+
 func (rs *RideScreen) UpdateStats(elapsed time.Duration, distance, avgPower, avgCadence, avgSpeed, elevation float64) {
 	rs.elapsed = elapsed
 	rs.distance = distance
@@ -165,6 +249,18 @@ func (rs *RideScreen) UpdateStatus(gear string, gradient float64, mode string, p
 	rs.paused = paused
 }
 
+// SetWarning shows a trainer problem in the status panel, empty clears it
+func (rs *RideScreen) SetWarning(warning string) {
+	rs.warning = warning
+}
+
+// UpdateWorkout sets the workout state shown in place of the route and
+// the step change to announce, if any
+func (rs *RideScreen) UpdateWorkout(status *workout.Status, stepNotice string) {
+	rs.workout = status
+	rs.stepNotice = stepNotice
+}
+
 func (rs *RideScreen) View() string {
 	if rs.width == 0 || rs.height == 0 {
 		return "Initializing..."
@@ -199,16 +295,21 @@ func (rs *RideScreen) View() string {
 }
 
 func (rs *RideScreen) buildLeftColumn(width, height int) string {
-	// Route view (top 60% of left column)
+	// Route or workout view (top 60% of left column)
 	routeHeight := int(float64(height) * 0.6)
+	routeTitle := "┤ Route ├\n"
 	routeView := rs.buildRouteView(width-4, routeHeight-4)
+	if rs.workout != nil {
+		routeTitle = "┤ Workout ├\n"
+		routeView = buildWorkoutView(rs.workout, rs.stepNotice, rs.power, width-8)
+	}
 	routePanel := lipgloss.NewStyle().
 		Border(lipgloss.RoundedBorder()).
 		BorderForeground(lipgloss.Color("240")).
 		Padding(1).
 		Width(width - 4).
 		Height(routeHeight - 2).
-		Render("┤ Route ├\n" + routeView)
+		Render(routeTitle + routeView)
 
 	// Stats view (bottom 40% of left column)
 	statsHeight := height - routeHeight
@@ -225,7 +326,11 @@ func (rs *RideScreen) buildLeftColumn(width, height int) string {
 }
 
 func (rs *RideScreen) buildRightColumn(width, height int) string {
-	chartHeight := int(float64(height) * 0.25)
+	panels := 4
+	if rs.hasHR {
+		panels++
+	}
+	chartHeight := height / panels
 
 	// Update chart dimensions
 	rs.powerChart.Resize(width-8, chartHeight-4)
@@ -237,14 +342,23 @@ func (rs *RideScreen) buildRightColumn(width, height int) string {
 	rs.cadenceChart.Draw()
 	rs.speedChart.Draw()
 
-	// Power chart
+	// Power chart, with the zone and the target during a workout
+	powerTitle := fmt.Sprintf("Power: %.0f W", rs.power)
+	if rs.workout != nil && rs.workout.TargetPower > 0 {
+		powerTitle += fmt.Sprintf(" / %.0f W", rs.workout.TargetPower)
+	}
+	if rs.zone >= 0 {
+		zoneStyle := lipgloss.NewStyle().Bold(true).Foreground(zoneColor(rs.zone, len(rs.zones.Zones)))
+		powerTitle += " " + zoneStyle.Render(zoneLabel(rs.zones, rs.zone))
+	}
+	powerTitle = "┤ " + powerTitle + " ├"
 	powerPanel := lipgloss.NewStyle().
 		Border(lipgloss.RoundedBorder()).
 		BorderForeground(lipgloss.Color("212")).
 		Padding(1).
 		Width(width - 4).
 		Height(chartHeight - 2).
-		Render(fmt.Sprintf("┤ Power: %.0f W ├\n%s", rs.power, rs.powerChart.View()))
+		Render(powerTitle + "\n" + rs.powerChart.View())
 
 	// Cadence chart
 	cadencePanel := lipgloss.NewStyle().
@@ -274,12 +388,28 @@ func (rs *RideScreen) buildRightColumn(width, height int) string {
 		Height(chartHeight - 2).
 		Render("┤ Status ├\n" + statusView)
 
-	return lipgloss.JoinVertical(lipgloss.Left,
-		powerPanel,
-		cadencePanel,
-		speedPanel,
-		statusPanel,
-	)
+	column := []string{powerPanel, cadencePanel, speedPanel}
+
+	// Heart rate chart
+	if rs.hasHR {
+		rs.hrChart.Resize(width-8, chartHeight-4)
+		rs.hrChart.Draw()
+
+		hr := "--"
+		if rs.heartRate > 0 {
+			hr = fmt.Sprintf("%d", rs.heartRate)
+		}
+		hrPanel := lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(lipgloss.Color("196")).
+			Padding(1).
+			Width(width - 4).
+			Height(chartHeight - 2).
+			Render(fmt.Sprintf("┤ Heart Rate: %s bpm ├\n%s", hr, rs.hrChart.View()))
+		column = append(column, hrPanel)
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left, append(column, statusPanel)...)
 }
 
 func (rs *RideScreen) buildRouteView(width, height int) string {
@@ -316,7 +446,11 @@ func (rs *RideScreen) buildStatusView(width, height int) string {
 	b.WriteString(fmt.Sprintf("Gear:     %s\n", gearStyle.Render(rs.gear)))
 	b.WriteString(fmt.Sprintf("Gradient: %+.1f%%\n", rs.gradient))
 	b.WriteString(fmt.Sprintf("Mode:     %s\n\n", rs.mode))
-	b.WriteString(helpStyle.Render("[↑↓] Shift  [←→] Resistance  [Space] Pause  [q] Quit"))
+	if rs.warning != "" {
+		warningStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("196")).Bold(true)
+		b.WriteString(warningStyle.Render(rs.warning) + "\n")
+	}
+	b.WriteString(helpStyle.Render("[↑↓] Shift  [Shift+↑↓] Front  [←→] Resistance  [Space] Pause  [q] Quit"))
 
 	return b.String()
 }
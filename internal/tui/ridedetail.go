@@ -0,0 +1,458 @@
+package tui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/NimbleMarkets/ntcharts/canvas"
+	"github.com/NimbleMarkets/ntcharts/linechart"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/thiemotorres/goc/internal/analytics"
+	"github.com/thiemotorres/goc/internal/data"
+	"github.com/thiemotorres/goc/internal/zones"
+)
+
+// detailPage is a page of the ride detail screen
+type detailPage int
+
+const (
+	detailSummary detailPage = iota // stats, best efforts and zones
+	detailCharts                    // data over the whole ride
+	detailLaps                      // lap table
+	detailPages
+)
+
+// rideExporters are the export formats offered on the ride detail screen
+var rideExporters = []struct {
+	key    string
+	ext    string
+	export func(*data.Ride, string) error
+}{
+	{"f", "fit", data.ExportFIT},
+	{"t", "tcx", data.ExportTCX},
+	{"c", "csv", data.ExportCSV},
+}
+
+// Series colours on the charts page
+var (
+	powerColor     = lipgloss.Color("214")
+	cadenceColor   = lipgloss.Color("39")
+	speedColor     = lipgloss.Color("42")
+	heartRateColor = lipgloss.Color("196")
+	elevationColor = lipgloss.Color("255")
+)
+
+// RideDetail shows a stored ride: its stats, charts over the whole ride,
+// time in zone, best efforts and laps
+type RideDetail struct {
+	id      string
+	ride    *data.Ride
+	stats   data.RideStats
+	summary analytics.Summary
+	zones   zones.Model
+	inZone  []time.Duration
+	ftp     float64 // FTP the ride is scored against
+	err     error
+
+	page          detailPage
+	width         int
+	height        int
+	confirmDelete bool
+	deleted       bool
+	message       string // result of the last export or delete
+}
+
+// NewRideDetail loads ride id. Rides saved without an FTP are scored
+// against ftp.
+func NewRideDetail(id string, ftp float64, model zones.Model) *RideDetail {
+	rd := &RideDetail{id: id, ftp: ftp, zones: model, width: 80, height: 24}
+
+	store, err := data.NewStore(data.DefaultDataDir())
+	if err != nil {
+		rd.err = err
+		return rd
+	}
+	defer store.Close()
+
+	rd.ride, rd.err = store.LoadRide(id)
+	if rd.err != nil {
+		return rd
+	}
+	if rd.ride.FTP > 0 {
+		rd.ftp = rd.ride.FTP
+	}
+
+	watts := rd.ride.PowerSeries()
+	rd.stats = rd.ride.Stats()
+	rd.summary = analytics.Analyze(watts, rd.ftp)
+	rd.inZone = analytics.TimeInZone(watts, rd.ftp, model)
+	return rd
+}
+
+// SetSize sets the terminal size the charts fill
+func (rd *RideDetail) SetSize(width, height int) {
+	rd.width, rd.height = width, height
+}
+
+// NextPage shows the next page
+func (rd *RideDetail) NextPage() {
+	rd.page = (rd.page + 1) % detailPages
+}
+
+// Export writes the ride in the format bound to key to the current
+// directory. It reports whether key is an export key.
+func (rd *RideDetail) Export(key string) bool {
+	for _, e := range rideExporters {
+		if e.key != key {
+			continue
+		}
+		if rd.ride == nil {
+			return true
+		}
+		path := rd.id + "." + e.ext
+		if err := e.export(rd.ride, path); err != nil {
+			rd.message = fmt.Sprintf("Export failed: %v", err)
+		} else if abs, err := filepath.Abs(path); err == nil {
+			rd.message = "Exported to " + abs
+		} else {
+			rd.message = "Exported to " + path
+		}
+		return true
+	}
+	return false
+}
+
+// RequestDelete asks for confirmation before deleting the ride
+func (rd *RideDetail) RequestDelete() {
+	if rd.ride != nil {
+		rd.confirmDelete = true
+		rd.message = ""
+	}
+}
+
+// ConfirmingDelete reports whether a delete is waiting for confirmation
+func (rd *RideDetail) ConfirmingDelete() bool {
+	return rd.confirmDelete
+}
+
+// CancelDelete keeps the ride
+func (rd *RideDetail) CancelDelete() {
+	rd.confirmDelete = false
+}
+
+// Delete removes the ride from history
+func (rd *RideDetail) Delete() {
+	rd.confirmDelete = false
+
+	store, err := data.NewStore(data.DefaultDataDir())
+	if err != nil {
+		rd.message = fmt.Sprintf("Delete failed: %v", err)
+		return
+	}
+	defer store.Close()
+
+	if err := store.DeleteRide(rd.id); err != nil {
+		rd.message = fmt.Sprintf("Delete failed: %v", err)
+		return
+	}
+	rd.deleted = true
+}
+
+// Deleted reports whether the ride was deleted
+func (rd *RideDetail) Deleted() bool {
+	return rd.deleted
+}
+
+func (rd *RideDetail) View() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render(rd.title()))
+	b.WriteString("\n")
+	b.WriteString(rd.pagesView() + "\n\n")
+
+	switch {
+	case rd.err != nil:
+		b.WriteString(fmt.Sprintf("Error: %v\n", rd.err))
+	case rd.page == detailCharts:
+		b.WriteString(rd.chartsView())
+	case rd.page == detailLaps:
+		b.WriteString(rd.lapsView())
+	default:
+		b.WriteString(rd.summaryView())
+	}
+
+	if rd.confirmDelete {
+		errStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("196")).Bold(true)
+		b.WriteString("\n" + errStyle.Render("Delete this ride? [y] Yes  [any key] No") + "\n")
+	} else if rd.message != "" {
+		b.WriteString("\n" + rd.message + "\n")
+	}
+
+	b.WriteString(helpStyle.Render("\ntab: next page • f/t/c: export FIT/TCX/CSV • d: delete • esc: back"))
+
+	return lipgloss.Place(rd.width, rd.height, lipgloss.Center, lipgloss.Center, menuStyle.Render(b.String()))
+}
+
+func (rd *RideDetail) title() string {
+	if rd.ride == nil {
+		return "Ride " + rd.id
+	}
+	name := rideName(rd.ride.Name, rd.ride.GPXName)
+	return fmt.Sprintf("%s - %s", name, rd.ride.StartTime.Format("Mon Jan 02 2006 15:04"))
+}
+
+// pagesView shows the page names with the current one highlighted
+func (rd *RideDetail) pagesView() string {
+	names := []string{"Summary", "Charts", "Laps"}
+	for i, name := range names {
+		if detailPage(i) == rd.page {
+			names[i] = selectedStyle.Render("[" + name + "]")
+		} else {
+			names[i] = disabledStyle.Render(" " + name + " ")
+		}
+	}
+	return strings.Join(names, " ")
+}
+
+// summaryView shows the ride stats, training metrics, best efforts and
+// time in zone
+func (rd *RideDetail) summaryView() string {
+	var b strings.Builder
+
+	labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	row := func(label, value string) {
+		b.WriteString(fmt.Sprintf("%s %s\n", labelStyle.Render(fmt.Sprintf("%-14s", label)), value))
+	}
+
+	s, sum := rd.stats, rd.summary
+	avgHR, maxHR := heartRateStats(rd.ride.Points)
+
+	row("Duration", formatDuration(s.Duration))
+	row("Distance", fmt.Sprintf("%.2f km", s.Distance/1000))
+	row("Ascent", fmt.Sprintf("%.0f m", s.TotalAscent))
+	row("Power", fmt.Sprintf("%.0f W avg, %.0f W max", s.AvgPower, s.MaxPower))
+	row("Cadence", fmt.Sprintf("%.0f rpm avg", s.AvgCadence))
+	row("Speed", fmt.Sprintf("%.1f km/h avg, %.1f km/h max", s.AvgSpeed, s.MaxSpeed))
+	if maxHR > 0 {
+		row("Heart Rate", fmt.Sprintf("%d bpm avg, %d bpm max", avgHR, maxHR))
+	}
+	b.WriteString("\n")
+
+	row("NP", formatMetric(sum.NormalizedPower, "%.0f W"))
+	row("IF", formatMetric(sum.IntensityFactor, "%.2f"))
+	row("TSS", formatMetric(sum.TSS, "%.0f"))
+	row("VI", formatMetric(sum.VariabilityIndex, "%.2f"))
+	row("Work", formatMetric(sum.Work, "%.0f kJ"))
+	ftp := fmt.Sprintf("%.0f W", rd.ftp)
+	if rd.ride.FTP == 0 {
+		ftp += " (current, not recorded)"
+	}
+	row("FTP", ftp)
+
+	// Best efforts
+	var efforts []string
+	for _, e := range sum.PowerCurve {
+		if e.Power > 0 {
+			efforts = append(efforts, fmt.Sprintf("%s %.0f W", e.Label(), e.Power))
+		}
+	}
+	if len(efforts) > 0 {
+		b.WriteString("\n" + labelStyle.Render("Best efforts") + "\n")
+		b.WriteString("  " + strings.Join(efforts, "  ") + "\n")
+	}
+
+	// Time in zone
+	if sum.Duration > 0 && len(rd.inZone) > 0 {
+		const barWidth = 30
+		b.WriteString("\n" + labelStyle.Render("Time in zone") + "\n")
+		for i, d := range rd.inZone {
+			share := float64(d) / float64(sum.Duration)
+			bar := strings.Repeat("█", int(share*barWidth+0.5))
+			zoneStyle := lipgloss.NewStyle().Foreground(zoneColor(i, len(rd.inZone)))
+			b.WriteString(fmt.Sprintf("  %-16s %s %s\n",
+				truncate(zoneLabel(rd.zones, i), 16),
+				zoneStyle.Render(fmt.Sprintf("%-*s", barWidth, bar)),
+				fmt.Sprintf("%7s %3.0f%%", formatDuration(d), share*100)))
+		}
+	}
+
+	return b.String()
+}
+
+// chartsView draws power, cadence, speed and heart rate over the whole
+// ride, and the elevation profile for route rides
+func (rd *RideDetail) chartsView() string {
+	points := rd.ride.Points
+	if len(points) < 2 {
+		return "Not enough data to chart.\n"
+	}
+
+	series := func(value func(data.RidePoint) float64) []float64 {
+		out := make([]float64, len(points))
+		for i, p := range points {
+			out[i] = value(p)
+		}
+		return out
+	}
+
+	// Two charts per row, leaving room for the border, titles and help
+	width := max((rd.width-12)/2, 20)
+	rows := 2
+	elevation := rd.hasElevation()
+	if elevation {
+		rows = 3
+	}
+	height := max((rd.height-14)/rows-1, 4)
+
+	chart := func(title string, values []float64, color lipgloss.Color) string {
+		titleStyle := lipgloss.NewStyle().Foreground(color).Bold(true)
+		return titleStyle.Render(title) + "\n" + buildSeriesChart(values, width, height, color)
+	}
+
+	power := chart("Power (W)", series(func(p data.RidePoint) float64 { return p.Power }), powerColor)
+	cadence := chart("Cadence (rpm)", series(func(p data.RidePoint) float64 { return p.Cadence }), cadenceColor)
+	speed := chart("Speed (km/h)", series(func(p data.RidePoint) float64 { return p.Speed }), speedColor)
+
+	var hr string
+	if _, maxHR := heartRateStats(points); maxHR > 0 {
+		hr = chart("Heart Rate (bpm)", series(func(p data.RidePoint) float64 { return float64(p.HeartRate) }), heartRateColor)
+	} else {
+		hr = chart("Heart Rate (bpm)", nil, heartRateColor)
+	}
+
+	gap := "  "
+	var b strings.Builder
+	b.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, power, gap, cadence) + "\n")
+	b.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, speed, gap, hr) + "\n")
+
+	if elevation {
+		titleStyle := lipgloss.NewStyle().Foreground(elevationColor).Bold(true)
+		b.WriteString(titleStyle.Render("Elevation (m) over distance (km)") + "\n")
+		b.WriteString(buildElevationProfile(points, 2*width+len(gap), height) + "\n")
+	}
+
+	return b.String()
+}
+
+// hasElevation reports whether the ride followed a route or climbed
+func (rd *RideDetail) hasElevation() bool {
+	if rd.ride.GPXName != "" {
+		return true
+	}
+	first := rd.ride.Points[0].Elevation
+	for _, p := range rd.ride.Points {
+		if p.Elevation != first {
+			return true
+		}
+	}
+	return false
+}
+
+// lapsView lists the laps of the ride, one per workout step
+func (rd *RideDetail) lapsView() string {
+	laps := rd.ride.Laps()
+	if len(laps) == 0 {
+		return "No laps recorded.\n"
+	}
+
+	var b strings.Builder
+	header := fmt.Sprintf("%3s  %8s  %8s  %6s  %6s  %5s  %6s  %5s",
+		"Lap", "Time", "Distance", "Avg W", "Max W", "Cad", "km/h", "HR")
+	b.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color("240")).Render(header) + "\n")
+
+	for i, lap := range laps {
+		s := lap.Stats()
+		var distance float64
+		if n := len(lap.Points); n > 0 {
+			distance = lap.Points[n-1].Distance - lap.Points[0].Distance
+		}
+		hr := "-"
+		if avg, _ := heartRateStats(lap.Points); avg > 0 {
+			hr = fmt.Sprintf("%d", avg)
+		}
+		b.WriteString(fmt.Sprintf("%3d  %8s  %6.2fkm  %6.0f  %6.0f  %5.0f  %6.1f  %5s\n",
+			i+1, formatDuration(lap.EndTime.Sub(lap.StartTime)), distance/1000,
+			s.AvgPower, s.MaxPower, s.AvgCadence, s.AvgSpeed, hr))
+	}
+
+	return b.String()
+}
+
+// buildSeriesChart draws values over the ride as a braille line
+func buildSeriesChart(values []float64, width, height int, color lipgloss.Color) string {
+	maxY := 1.0
+	for _, v := range values {
+		maxY = max(maxY, v)
+	}
+
+	axisStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	chart := linechart.New(
+		width, height,
+		0, float64(max(len(values)-1, 1)),
+		0, maxY*1.1,
+		linechart.WithStyles(axisStyle, axisStyle, lipgloss.NewStyle().Foreground(color)),
+	)
+
+	// Two braille dots per cell
+	if n := min(len(values), 2*width); n > 1 {
+		sampled := resample(values, n)
+		scale := float64(len(values)-1) / float64(n-1)
+		for i := 1; i < n; i++ {
+			chart.DrawBrailleLine(
+				canvas.Float64Point{X: float64(i-1) * scale, Y: sampled[i-1]},
+				canvas.Float64Point{X: float64(i) * scale, Y: sampled[i]},
+			)
+		}
+	}
+
+	chart.DrawXYAxisAndLabel()
+	return chart.View()
+}
+
+// buildElevationProfile draws elevation against distance in km
+func buildElevationProfile(points []data.RidePoint, width, height int) string {
+	minEle, maxEle := points[0].Elevation, points[0].Elevation
+	for _, p := range points {
+		minEle = min(minEle, p.Elevation)
+		maxEle = max(maxEle, p.Elevation)
+	}
+	padding := max((maxEle-minEle)*0.1, 5)
+	distance := max(points[len(points)-1].Distance/1000, 0.01)
+
+	axisStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	chart := linechart.New(
+		width, height,
+		0, distance,
+		minEle-padding, maxEle+padding,
+		linechart.WithStyles(axisStyle, axisStyle, lipgloss.NewStyle().Foreground(elevationColor)),
+	)
+
+	step := max(len(points)/(2*width), 1)
+	prev := canvas.Float64Point{X: points[0].Distance / 1000, Y: points[0].Elevation}
+	for i := step; i < len(points); i += step {
+		point := canvas.Float64Point{X: points[i].Distance / 1000, Y: points[i].Elevation}
+		chart.DrawBrailleLine(prev, point)
+		prev = point
+	}
+
+	chart.DrawXYAxisAndLabel()
+	return chart.View()
+}
+
+// heartRateStats averages the points with a heart rate reading
+func heartRateStats(points []data.RidePoint) (avg, maxHR int) {
+	var sum, n int
+	for _, p := range points {
+		if p.HeartRate > 0 {
+			sum += p.HeartRate
+			n++
+			maxHR = max(maxHR, p.HeartRate)
+		}
+	}
+	if n == 0 {
+		return 0, 0
+	}
+	return sum / n, maxHR
+}
@@ -0,0 +1,269 @@
+package tui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/thiemotorres/goc/internal/config"
+	"github.com/thiemotorres/goc/internal/zones"
+)
+
+// RiderSettings shows the rider profile: FTP, weight, heart rate and
+// power zones
+type RiderSettings struct {
+	items      []string
+	selected   int
+	config     *config.Config
+	editing    bool
+	editField  int
+	editBuffer string
+	err        string // last rejected edit
+}
+
+func NewRiderSettings(cfg *config.Config) *RiderSettings {
+	return &RiderSettings{
+		items: []string{
+			"FTP",
+			"Weight",
+			"Max Heart Rate",
+			"Threshold Heart Rate",
+			"Zone Model",
+			"Custom Zones",
+			"← Back",
+		},
+		config: cfg,
+	}
+}
+
+func (m *RiderSettings) MoveUp() {
+	if !m.editing && m.selected > 0 {
+		m.selected--
+	}
+}
+
+func (m *RiderSettings) MoveDown() {
+	if !m.editing && m.selected < len(m.items)-1 {
+		m.selected++
+	}
+}
+
+func (m *RiderSettings) Selected() int {
+	return m.selected
+}
+
+func (m *RiderSettings) IsEditing() bool {
+	return m.editing
+}
+
+func (m *RiderSettings) StartEdit() {
+	m.editing = true
+	m.editField = m.selected
+
+	// Pre-fill with current value
+	switch m.selected {
+	case 0: // FTP, empty when unknown
+		m.editBuffer = formatOverride(m.config.Rider.FTP, "%.0f")
+	case 1: // Weight
+		m.editBuffer = fmt.Sprintf("%.1f", m.config.Rider.Weight)
+	case 2: // Max Heart Rate
+		m.editBuffer = formatOverride(float64(m.config.Rider.MaxHR), "%.0f")
+	case 3: // Threshold Heart Rate
+		m.editBuffer = formatOverride(float64(m.config.Rider.ThresholdHR), "%.0f")
+	case 5: // Custom Zones
+		m.editBuffer = floatsToString(m.config.Rider.CustomZones)
+	}
+}
+
+// CycleZoneModel selects the next zone model
+func (m *RiderSettings) CycleZoneModel() {
+	previous := m.config.Rider
+	m.config.Rider.ZoneModel = nextSource(zones.Models, m.config.Rider.ZoneModel)
+
+	m.err = ""
+	if err := m.config.Validate(); err != nil {
+		m.config.Rider = previous
+		m.err = err.Error()
+	}
+}
+
+func (m *RiderSettings) CancelEdit() {
+	m.editing = false
+	m.editBuffer = ""
+}
+
+func (m *RiderSettings) HandleKey(key string) bool {
+	if !m.editing {
+		return false
+	}
+
+	switch key {
+	case "backspace":
+		if len(m.editBuffer) > 0 {
+			m.editBuffer = m.editBuffer[:len(m.editBuffer)-1]
+		}
+	case "enter":
+		m.applyEdit()
+		m.editing = false
+		return true
+	case "esc":
+		m.CancelEdit()
+		return true
+	default:
+		// Only accept valid characters
+		if len(key) == 1 && (key[0] >= '0' && key[0] <= '9' || key[0] == '.' || key[0] == ',' || key[0] == ' ') {
+			m.editBuffer += key
+		}
+	}
+	return true
+}
+
+func (m *RiderSettings) applyEdit() {
+	previous := *m.config
+	text := strings.TrimSpace(m.editBuffer)
+	value, parseErr := strconv.ParseFloat(text, 64)
+	if text == "" {
+		// Clears optional values
+		value, parseErr = 0, nil
+	}
+
+	switch m.editField {
+	case 0: // FTP
+		if parseErr == nil {
+			m.config.Rider.FTP = value
+		}
+	case 1: // Weight
+		if parseErr == nil {
+			m.config.Rider.Weight = value
+		}
+	case 2: // Max Heart Rate
+		if parseErr == nil {
+			m.config.Rider.MaxHR = int(value)
+		}
+	case 3: // Threshold Heart Rate
+		if parseErr == nil {
+			m.config.Rider.ThresholdHR = int(value)
+		}
+	case 5: // Custom Zones
+		if bounds := parseFloats(m.editBuffer); len(bounds) > 0 {
+			m.config.Rider.CustomZones = bounds
+		}
+	}
+
+	// Keep the previous value if the new one is out of range
+	m.err = ""
+	if err := m.config.Validate(); err != nil {
+		m.config.Rider = previous.Rider
+		m.config.Bike = previous.Bike
+		m.err = err.Error()
+	}
+}
+
+func (m *RiderSettings) View() string {
+	var b strings.Builder
+
+	title := titleStyle.Render("Rider Profile")
+	b.WriteString(title)
+	b.WriteString("\n\n")
+
+	r := m.config.Rider
+	for i, item := range m.items {
+		cursor := "  "
+		style := normalStyle
+		if i == m.selected {
+			cursor = "> "
+			style = selectedStyle
+		}
+
+		// Add current value
+		var value string
+		switch i {
+		case 0: // FTP
+			if r.FTP > 0 {
+				value = fmt.Sprintf(" (%.0f W, %.1f W/kg)", r.FTP, r.FTP/m.config.Rider.Weight)
+			} else {
+				value = fmt.Sprintf(" (not set, using %.0f W)", r.EffectiveFTP())
+			}
+		case 1: // Weight
+			value = fmt.Sprintf(" (%.1f kg)", m.config.Rider.Weight)
+		case 2: // Max Heart Rate
+			value = formatHeartRate(r.MaxHR)
+		case 3: // Threshold Heart Rate
+			value = formatHeartRate(r.ThresholdHR)
+		case 4: // Zone Model
+			value = fmt.Sprintf(" (%s)", r.ZoneModel)
+		case 5: // Custom Zones
+			value = fmt.Sprintf(" [%s]", floatsToString(r.CustomZones))
+		}
+
+		line := item + value
+
+		// Show edit mode
+		if m.editing && i == m.editField {
+			line = item + ": " + m.editBuffer + "█"
+		}
+
+		b.WriteString(cursor + style.Render(line) + "\n")
+	}
+
+	// Zone table for the current FTP
+	if model, err := r.Zones(); err == nil {
+		b.WriteString("\n")
+		ftp := r.EffectiveFTP()
+		for i := range model.Zones {
+			lo, hi := model.Watts(i, ftp)
+			watts := fmt.Sprintf("%4.0f+ W", lo)
+			if hi > 0 {
+				watts = fmt.Sprintf("%4.0f-%.0f W", lo, hi-1)
+			}
+			zoneStyle := lipgloss.NewStyle().Foreground(zoneColor(i, len(model.Zones)))
+			b.WriteString(zoneStyle.Render(fmt.Sprintf("  %-20s %s", zoneLabel(model, i), watts)) + "\n")
+		}
+	}
+
+	if m.err != "" {
+		errStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+		b.WriteString("\n" + errStyle.Render(m.err) + "\n")
+	}
+
+	var help string
+	if m.editing {
+		help = helpStyle.Render("\nenter: save • esc: cancel")
+	} else {
+		help = helpStyle.Render("\n↑/↓: navigate • enter: edit/next model • esc: back")
+	}
+	b.WriteString(help)
+
+	return centerView(menuStyle.Render(b.String()))
+}
+
+// formatHeartRate formats an optional heart rate setting
+func formatHeartRate(bpm int) string {
+	if bpm == 0 {
+		return " (not set)"
+	}
+	return fmt.Sprintf(" (%d bpm)", bpm)
+}
+
+func floatsToString(floats []float64) string {
+	strs := make([]string, len(floats))
+	for i, v := range floats {
+		strs[i] = strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return strings.Join(strs, ", ")
+}
+
+func parseFloats(s string) []float64 {
+	// Accept comma or space separated
+	s = strings.ReplaceAll(s, ",", " ")
+	parts := strings.Fields(s)
+
+	var result []float64
+	for _, p := range parts {
+		if v, err := strconv.ParseFloat(p, 64); err == nil && v > 0 {
+			result = append(result, v)
+		}
+	}
+	return result
+}
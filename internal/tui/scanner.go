@@ -10,8 +10,89 @@ import (
 	"github.com/thiemotorres/goc/internal/config"
 )
 
+// DeviceKind selects which kind of device a screen scans for or configures
+type DeviceKind int
+
+const (
+	DeviceTrainer DeviceKind = iota
+	DeviceHeartRate
+	DevicePowerMeter
+	DeviceCadenceSensor
+)
+
+// Name returns the singular device name
+func (k DeviceKind) Name() string {
+	switch k {
+	case DeviceHeartRate:
+		return "Monitor"
+	case DevicePowerMeter:
+		return "Power Meter"
+	case DeviceCadenceSensor:
+		return "Sensor"
+	default:
+		return "Trainer"
+	}
+}
+
+// Plural returns the plural device name
+func (k DeviceKind) Plural() string {
+	switch k {
+	case DeviceHeartRate:
+		return "Heart Rate Monitors"
+	case DevicePowerMeter:
+		return "Power Meters"
+	case DeviceCadenceSensor:
+		return "Cadence Sensors"
+	default:
+		return "Trainers"
+	}
+}
+
+// Title returns the settings screen title
+func (k DeviceKind) Title() string {
+	switch k {
+	case DeviceHeartRate:
+		return "Heart Rate Monitor"
+	case DevicePowerMeter:
+		return "Power Meter"
+	case DeviceCadenceSensor:
+		return "Cadence Sensor"
+	default:
+		return "Trainer Connection"
+	}
+}
+
+// Address returns the saved address for this kind of device
+func (k DeviceKind) Address(cfg *config.Config) string {
+	switch k {
+	case DeviceHeartRate:
+		return cfg.Bluetooth.HeartRateAddress
+	case DevicePowerMeter:
+		return cfg.Bluetooth.PowerMeterAddress
+	case DeviceCadenceSensor:
+		return cfg.Bluetooth.CadenceSensorAddress
+	default:
+		return cfg.Bluetooth.TrainerAddress
+	}
+}
+
+// SetAddress saves the address for this kind of device in cfg
+func (k DeviceKind) SetAddress(cfg *config.Config, address string) {
+	switch k {
+	case DeviceHeartRate:
+		cfg.Bluetooth.HeartRateAddress = address
+	case DevicePowerMeter:
+		cfg.Bluetooth.PowerMeterAddress = address
+	case DeviceCadenceSensor:
+		cfg.Bluetooth.CadenceSensorAddress = address
+	default:
+		cfg.Bluetooth.TrainerAddress = address
+	}
+}
+
 // ScannerScreen handles Bluetooth device scanning
 type ScannerScreen struct {
+	kind     DeviceKind
 	devices  []bluetooth.DeviceInfo
 	selected int
 	scanning bool
@@ -30,12 +111,14 @@ type ScanResultMsg struct {
 
 // DeviceSelectedMsg indicates a device was selected
 type DeviceSelectedMsg struct {
+	Kind    DeviceKind
 	Address string
 	Name    string
 }
 
-func NewScannerScreen(cfg *config.Config) *ScannerScreen {
+func NewScannerScreen(cfg *config.Config, kind DeviceKind) *ScannerScreen {
 	return &ScannerScreen{
+		kind:     kind,
 		config:   cfg,
 		scanning: true,
 	}
@@ -44,8 +127,18 @@ func NewScannerScreen(cfg *config.Config) *ScannerScreen {
 func (s *ScannerScreen) StartScan() tea.Cmd {
 	return func() tea.Msg {
 		// Use the Scanner directly
-		scanner := bluetooth.NewScanner()
-		devices, err := scanner.Scan(10 * time.Second)
+		var devices []bluetooth.DeviceInfo
+		var err error
+		switch s.kind {
+		case DeviceHeartRate:
+			devices, err = bluetooth.ScanHeartRateMonitors(10 * time.Second)
+		case DevicePowerMeter:
+			devices, err = bluetooth.ScanPowerMeters(10 * time.Second)
+		case DeviceCadenceSensor:
+			devices, err = bluetooth.ScanCadenceSensors(10 * time.Second)
+		default:
+			devices, err = bluetooth.NewScanner().Scan(10 * time.Second)
+		}
 		if err != nil {
 			return ScanResultMsg{Error: err}
 		}
@@ -96,16 +189,30 @@ func (s *ScannerScreen) SelectDevice() *bluetooth.DeviceInfo {
 func (s *ScannerScreen) View() string {
 	var b strings.Builder
 
-	title := titleStyle.Render("Scan for Trainers")
+	title := titleStyle.Render("Scan for " + s.kind.Plural())
 	b.WriteString(title)
 	b.WriteString("\n\n")
 
 	if s.scanning {
-		b.WriteString("Scanning for FTMS trainers...\n\n")
+		if s.kind == DeviceTrainer {
+			b.WriteString("Scanning for FTMS trainers...\n\n")
+		} else {
+			b.WriteString(fmt.Sprintf("Scanning for %s...\n\n", strings.ToLower(s.kind.Plural())))
+		}
 		b.WriteString("Please wait (up to 10 seconds)\n")
 	} else if s.err != nil {
 		b.WriteString(fmt.Sprintf("Error: %v\n\n", s.err))
 		b.WriteString("Press any key to go back.\n")
+	} else if len(s.devices) == 0 && s.kind == DeviceHeartRate {
+		b.WriteString("No heart rate monitors found.\n\n")
+		b.WriteString("Make sure your monitor is:\n")
+		b.WriteString("  • Worn (most straps wake on skin contact)\n")
+		b.WriteString("  • Not connected to another device\n")
+	} else if len(s.devices) == 0 && s.kind != DeviceTrainer {
+		b.WriteString(fmt.Sprintf("No %s found.\n\n", strings.ToLower(s.kind.Plural())))
+		b.WriteString("Make sure your sensor is:\n")
+		b.WriteString("  • Awake (turn the cranks to wake it)\n")
+		b.WriteString("  • Not connected to another device\n")
 	} else if len(s.devices) == 0 {
 		b.WriteString("No trainers found.\n\n")
 		b.WriteString("Make sure your trainer is:\n")
@@ -113,7 +220,7 @@ func (s *ScannerScreen) View() string {
 		b.WriteString("  • In pairing mode\n")
 		b.WriteString("  • Not connected to another device\n")
 	} else {
-		b.WriteString(fmt.Sprintf("Found %d trainer(s):\n\n", len(s.devices)))
+		b.WriteString(fmt.Sprintf("Found %d %s:\n\n", len(s.devices), strings.ToLower(s.kind.Plural())))
 
 		for i, device := range s.devices {
 			cursor := "  "
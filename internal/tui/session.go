@@ -2,6 +2,11 @@ package tui
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -10,23 +15,50 @@ import (
 	"github.com/thiemotorres/goc/internal/data"
 	"github.com/thiemotorres/goc/internal/gpx"
 	"github.com/thiemotorres/goc/internal/simulation"
+	"github.com/thiemotorres/goc/internal/workout"
 )
 
+// workoutProfileWidth is the number of slices in the workout profile sent
+// to the ride screen
+const workoutProfileWidth = 120
+
+// stepNoticeDuration is how long a workout step change is announced
+const stepNoticeDuration = 5 * time.Second
+
 // RideSession manages the active ride state
 type RideSession struct {
 	// Components
-	engine    *simulation.Engine
-	btManager bluetooth.Manager
-	route     *gpx.Route
-	ride      *data.Ride
-	store     *data.Store
+	engine     *simulation.Engine
+	btManager  bluetooth.Manager
+	hrMonitor  bluetooth.HeartRateMonitor // nil without a heart rate monitor
+	powerSrc   bluetooth.Sensor           // nil when the trainer measures power
+	cadenceSrc bluetooth.Sensor           // nil when the trainer measures cadence
+	route      *gpx.Route
+	player     *workout.Player // nil outside workouts
+	ride       *data.Ride
+	store      *data.Store
+	journal    *data.Journal // the ride on disk until it is saved
 
 	// State
+	mu         sync.Mutex // guards the ride, journal and workout between the data loop and the UI
 	ctx        context.Context
 	cancel     context.CancelFunc
 	paused     bool
+	notice     string // trainer status shown until it changes
+	linkCh     chan bluetooth.ConnectionStatus
+	heartRate  bluetooth.HeartRateData
+	hrAt       time.Time // time of the last heart rate reading
+	power      float64   // last power from powerSrc
+	powerAt    time.Time
+	cadence    float64 // last cadence from cadenceSrc
+	cadenceAt  time.Time
+	gapStart   time.Time // start of the current dropout, if any
 	distance   float64
 	lastUpdate time.Time
+	resumedAt  time.Time // last point of a resumed ride, until data arrives
+	journalErr error     // last failure to record to the journal
+	stepNotice string    // last workout step change
+	stepAt     time.Time // when stepNotice was set
 
 	// Averages
 	totalPower   float64
@@ -50,6 +82,10 @@ type RideUpdateMsg struct {
 	Gear       string
 	Mode       string
 	Paused     bool
+	HeartRate  int
+	Warning    string          // last trainer command error, if any
+	Workout    *workout.Status // nil outside workouts
+	StepNotice string          // workout step change, for a few seconds
 }
 
 // RideConnectingMsg indicates connection in progress
@@ -58,7 +94,30 @@ type RideConnectingMsg struct {
 }
 
 // RideConnectedMsg indicates connection succeeded
-type RideConnectedMsg struct{}
+type RideConnectedMsg struct {
+	Capabilities bluetooth.TrainerCapabilities
+}
+
+// TrainerStatusMsg indicates the trainer reported a status event
+type TrainerStatusMsg struct {
+	Status bluetooth.MachineStatus
+}
+
+// HeartRateMsg indicates a heart rate monitor reading arrived
+type HeartRateMsg struct {
+	HeartRate int
+}
+
+// SensorMsg indicates a power meter or cadence sensor reading arrived
+type SensorMsg struct {
+	Data bluetooth.SensorData
+}
+
+// RideLinkMsg indicates the trainer connection dropped or came back
+type RideLinkMsg struct {
+	Status bluetooth.ConnectionStatus
+	Notice string
+}
 
 // RideErrorMsg indicates an error occurred
 type RideErrorMsg struct {
@@ -68,18 +127,52 @@ type RideErrorMsg struct {
 // RideFinishedMsg indicates ride is complete
 type RideFinishedMsg struct {
 	RideID string
+	Err    error // the ride could not be saved, its journal is kept
 }
 
 // NewRideSession creates a new ride session
-func NewRideSession(cfg *config.Config, rideType RideType, route *RouteInfo, mock bool) (*RideSession, error) {
+func NewRideSession(cfg *config.Config, rideType RideType, route *RouteInfo, workoutInfo *WorkoutInfo, mock bool) (*RideSession, error) {
+	return newRideSession(cfg, rideType, route, workoutInfo, mock, "")
+}
+
+// ResumeRideSession continues an unfinished ride from its journal, on the
+// same route or workout
+func ResumeRideSession(cfg *config.Config, u data.UnfinishedRide, mock bool) (*RideSession, *RouteInfo, error) {
+	rideType, route, workoutInfo, err := parseSessionInfo(u.Session, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	rs, err := newRideSession(cfg, rideType, route, workoutInfo, mock, u.Ride.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	rs.resume()
+	return rs, route, nil
+}
+
+func newRideSession(cfg *config.Config, rideType RideType, route *RouteInfo, workoutInfo *WorkoutInfo, mock bool, resumeID string) (*RideSession, error) {
+	speedModel, err := simulation.ParseSpeedModel(cfg.Bike.SpeedModel)
+	if err != nil {
+		return nil, err
+	}
+	physics, err := cfg.Bike.Physics()
+	if err != nil {
+		return nil, err
+	}
+
 	// Create simulation engine
 	engine := simulation.NewEngine(simulation.EngineConfig{
 		Chainrings:         cfg.Bike.Chainrings,
 		Cassette:           cfg.Bike.Cassette,
 		WheelCircumference: cfg.Bike.WheelCircumference,
-		RiderWeight:        cfg.Bike.RiderWeight,
+		RiderWeight:        cfg.Rider.Weight,
 		ResistanceScaling:  cfg.Bike.ResistanceScaling,
 		GradientSmoothing:  cfg.Bike.GradientSmoothing,
+		TrainerSimulation:  cfg.Trainer.NativeSimulation,
+		SpeedModel:         speedModel,
+		Physics:            physics,
+		SynchroShift:       cfg.Shifter.SynchroShift,
+		PreventCrossChain:  cfg.Shifter.PreventCrossChain,
 	})
 
 	// Set mode
@@ -93,6 +186,13 @@ func NewRideSession(cfg *config.Config, rideType RideType, route *RouteInfo, moc
 		engine.SetMode(simulation.ModeSIM)
 	}
 
+	// The workout player sets ERG targets as the workout runs
+	var player *workout.Player
+	if rideType == RideWorkout && workoutInfo != nil {
+		engine.SetMode(simulation.ModeERG)
+		player = workout.NewPlayer(workoutInfo.Workout, cfg.Rider.EffectiveFTP(), engine)
+	}
+
 	// Load route if provided
 	var gpxRoute *gpx.Route
 	if route != nil {
@@ -104,14 +204,20 @@ func NewRideSession(cfg *config.Config, rideType RideType, route *RouteInfo, moc
 	}
 
 	// Create Bluetooth manager
+	linkCh := make(chan bluetooth.ConnectionStatus, 10)
 	var btManager bluetooth.Manager
 	if mock {
 		btManager = bluetooth.NewMockManager()
 	} else {
 		btManager = bluetooth.NewFTMSManagerWithConfig(bluetooth.FTMSManagerConfig{
-			SavedAddress: cfg.Bluetooth.TrainerAddress,
+			SavedAddress:   cfg.Bluetooth.TrainerAddress,
+			SilenceTimeout: time.Duration(cfg.Trainer.SilenceTimeout * float64(time.Second)),
 			OnStatusChange: func(status bluetooth.ConnectionStatus) {
 				// Status updates are handled via the data loop
+				select {
+				case linkCh <- status:
+				default:
+				}
 			},
 			OnSaveDevice: func(address string) {
 				// Save the trainer address for next time
@@ -121,16 +227,49 @@ func NewRideSession(cfg *config.Config, rideType RideType, route *RouteInfo, moc
 		})
 	}
 
+	// Heart rate monitor is optional
+	var hrMonitor bluetooth.HeartRateMonitor
+	if mock {
+		hrMonitor = bluetooth.NewMockHeartRateMonitor()
+	} else if cfg.Bluetooth.HeartRateAddress != "" {
+		hrMonitor = bluetooth.NewHeartRateManager(cfg.Bluetooth.HeartRateAddress)
+	}
+
+	// Standalone sensors replace the trainer's power and cadence
+	powerSrc, cadenceSrc, err := bluetooth.NewSourceSensors(
+		cfg.Bluetooth.PowerSource, cfg.Bluetooth.CadenceSource,
+		cfg.Bluetooth.PowerMeterAddress, cfg.Bluetooth.CadenceSensorAddress, mock)
+	if err != nil {
+		return nil, err
+	}
+
 	// Create data store
 	store, err := data.NewStore(data.DefaultDataDir())
 	if err != nil {
 		return nil, err
 	}
 
-	// Create ride recording
-	ride := data.NewRide()
-	if gpxRoute != nil {
-		ride.GPXName = gpxRoute.Name
+	// Create ride recording, journaled until it is saved
+	var ride *data.Ride
+	var journal *data.Journal
+	if resumeID != "" {
+		var u data.UnfinishedRide
+		u, journal, err = store.ResumeJournal(resumeID)
+		ride = u.Ride
+	} else {
+		ride = data.NewRide()
+		ride.FTP = cfg.Rider.FTP
+		if gpxRoute != nil {
+			ride.GPXName = gpxRoute.Name
+		}
+		if player != nil {
+			ride.Metadata = map[string]string{"workout": workoutInfo.Name}
+		}
+		journal, err = store.StartJournal(ride, sessionInfo(rideType, route, workoutInfo))
+	}
+	if err != nil {
+		store.Close()
+		return nil, fmt.Errorf("ride journal: %w", err)
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
@@ -138,11 +277,17 @@ func NewRideSession(cfg *config.Config, rideType RideType, route *RouteInfo, moc
 	return &RideSession{
 		engine:     engine,
 		btManager:  btManager,
+		hrMonitor:  hrMonitor,
+		powerSrc:   powerSrc,
+		cadenceSrc: cadenceSrc,
 		route:      gpxRoute,
+		player:     player,
 		ride:       ride,
 		store:      store,
+		journal:    journal,
 		ctx:        ctx,
 		cancel:     cancel,
+		linkCh:     linkCh,
 		lastUpdate: time.Now(),
 	}, nil
 }
@@ -153,7 +298,20 @@ func (rs *RideSession) Connect() tea.Cmd {
 		if err := rs.btManager.Connect(); err != nil {
 			return RideErrorMsg{Error: err}
 		}
-		return RideConnectedMsg{}
+		for _, sensor := range rs.sensors() {
+			if err := sensor.Connect(); err != nil {
+				rs.btManager.Disconnect()
+				return RideErrorMsg{Error: err}
+			}
+		}
+		// A missing heart rate monitor doesn't stop the ride
+		if rs.hrMonitor != nil {
+			if err := rs.hrMonitor.Connect(); err != nil {
+				rs.notice = err.Error()
+				rs.hrMonitor = nil
+			}
+		}
+		return RideConnectedMsg{Capabilities: rs.btManager.Capabilities()}
 	}
 }
 
@@ -165,18 +323,41 @@ func (rs *RideSession) StartDataLoop() tea.Cmd {
 			return nil
 
 		case trainerData := <-rs.btManager.DataChannel():
+			if !rs.lock() {
+				return nil
+			}
+			defer rs.mu.Unlock()
+
 			now := time.Now()
 			dt := now.Sub(rs.lastUpdate).Seconds()
 			rs.lastUpdate = now
 
+			// The time away from a resumed ride is a gap
+			if !rs.resumedAt.IsZero() {
+				rs.markGap(rs.resumedAt, now)
+				rs.resumedAt = time.Time{}
+				dt = 0
+			}
+
 			// Get gradient from route
 			var gradient float64
 			if rs.route != nil {
 				gradient = rs.route.GradientAt(rs.distance)
 			}
 
+			// Move the workout on before the engine computes the target.
+			// The first update only starts it: dt includes connecting.
+			if rs.player != nil && !rs.paused {
+				if rs.pointCount == 0 {
+					rs.announce(rs.player.Start())
+				} else {
+					rs.announce(rs.player.Advance(time.Duration(dt * float64(time.Second))))
+				}
+			}
+
 			// Update simulation
-			state := rs.engine.Update(trainerData.Cadence, trainerData.Power, gradient)
+			power, cadence := rs.currentInputs(trainerData, now)
+			state := rs.engine.Update(cadence, power, gradient)
 
 			// Update position
 			if !rs.paused {
@@ -191,7 +372,21 @@ func (rs *RideSession) StartDataLoop() tea.Cmd {
 				ele = rs.route.ElevationAt(rs.distance)
 			}
 
-			rs.ride.AddPoint(data.RidePoint{
+			heartRate := rs.currentHeartRate(trainerData, now)
+
+			var step int
+			var workoutStatus *workout.Status
+			var stepNotice string
+			if rs.player != nil {
+				step = rs.player.Index()
+				status := rs.player.Status(workoutProfileWidth)
+				workoutStatus = &status
+				if now.Sub(rs.stepAt) < stepNoticeDuration {
+					stepNotice = rs.stepNotice
+				}
+			}
+
+			point := data.RidePoint{
 				Timestamp:  now,
 				Power:      state.Power,
 				Cadence:    state.Cadence,
@@ -201,8 +396,12 @@ func (rs *RideSession) StartDataLoop() tea.Cmd {
 				Elevation:  ele,
 				Distance:   rs.distance,
 				Gradient:   gradient,
+				HeartRate:  heartRate,
 				GearString: state.GearString,
-			})
+				Step:       step,
+			}
+			rs.ride.AddPoint(point)
+			rs.journalErr = rs.journal.AddPoint(point)
 
 			// Update averages
 			if !rs.paused {
@@ -213,10 +412,40 @@ func (rs *RideSession) StartDataLoop() tea.Cmd {
 			}
 
 			// Send resistance to trainer
-			if state.Mode == simulation.ModeSIM || state.Mode == simulation.ModeFREE {
-				rs.btManager.SetResistance(state.Resistance)
-			} else if state.Mode == simulation.ModeERG {
-				rs.btManager.SetTargetPower(state.TargetPower)
+			var err error
+			switch {
+			case state.TrainerSimulation:
+				err = rs.btManager.SetSimulationParameters(bluetooth.SimulationParameters{
+					Grade: state.EffectiveGrade,
+					Crr:   rs.engine.Physics().Crr,
+					CW:    rs.engine.Physics().CW(),
+				})
+				if errors.Is(err, bluetooth.ErrNotSupported) {
+					// Fall back to resistance levels for the rest of the ride
+					rs.engine.SetTrainerSimulation(false)
+					err = rs.btManager.SetResistance(state.Resistance)
+				}
+			case state.Mode == simulation.ModeSIM || state.Mode == simulation.ModeFREE:
+				err = rs.btManager.SetResistance(state.Resistance)
+			case state.Mode == simulation.ModeERG:
+				err = rs.btManager.SetTargetPower(state.TargetPower)
+				if errors.Is(err, bluetooth.ErrNotSupported) {
+					// Trainer has no ERG mode, ride on manual resistance
+					// for the rest of the ride
+					rs.engine.SetMode(simulation.ModeFREE)
+					if rs.player != nil {
+						rs.player.DisableERG()
+					}
+					rs.notice = "Trainer: no ERG mode, riding on manual resistance"
+					err = nil
+				}
+			}
+			warning := rs.notice
+			if rs.journalErr != nil {
+				warning = "Recording: " + rs.journalErr.Error()
+			}
+			if err != nil {
+				warning = "Trainer: " + err.Error()
 			}
 
 			var avgPower, avgCadence, avgSpeed float64
@@ -240,7 +469,40 @@ func (rs *RideSession) StartDataLoop() tea.Cmd {
 				Gear:       state.GearString,
 				Mode:       state.Mode.String(),
 				Paused:     rs.paused,
+				HeartRate:  heartRate,
+				Warning:    warning,
+				Workout:    workoutStatus,
+				StepNotice: stepNotice,
+			}
+
+		case hr := <-rs.heartRateChannel():
+			rs.heartRate = hr
+			rs.hrAt = time.Now()
+			return HeartRateMsg{HeartRate: hr.HeartRate}
+
+		case d := <-rs.sensorChannel(rs.powerSrc):
+			rs.handleSensor(rs.powerSrc, d)
+			return SensorMsg{Data: d}
+
+		case d := <-rs.sensorChannel(rs.cadenceSrc):
+			rs.handleSensor(rs.cadenceSrc, d)
+			return SensorMsg{Data: d}
+
+		case status := <-rs.linkCh:
+			if !rs.lock() {
+				return nil
+			}
+			defer rs.mu.Unlock()
+			rs.handleLink(status)
+			return RideLinkMsg{Status: status, Notice: rs.notice}
+
+		case status := <-rs.btManager.StatusChannel():
+			if !rs.lock() {
+				return nil
 			}
+			defer rs.mu.Unlock()
+			rs.handleStatus(status)
+			return TrainerStatusMsg{Status: status}
 
 		case event := <-rs.btManager.ShiftChannel():
 			switch event {
@@ -248,12 +510,130 @@ func (rs *RideSession) StartDataLoop() tea.Cmd {
 				rs.engine.ShiftUp()
 			case bluetooth.ShiftDown:
 				rs.engine.ShiftDown()
+			case bluetooth.FrontShiftUp:
+				rs.engine.FrontShiftUp()
+			case bluetooth.FrontShiftDown:
+				rs.engine.FrontShiftDown()
 			}
 			return nil
 		}
 	}
 }
 
+// heartRateChannel returns the monitor's readings, or nil without a monitor
+func (rs *RideSession) heartRateChannel() <-chan bluetooth.HeartRateData {
+	if rs.hrMonitor == nil {
+		return nil
+	}
+	return rs.hrMonitor.DataChannel()
+}
+
+// sensors returns the distinct standalone sensors in use
+func (rs *RideSession) sensors() []bluetooth.Sensor {
+	var sensors []bluetooth.Sensor
+	if rs.powerSrc != nil {
+		sensors = append(sensors, rs.powerSrc)
+	}
+	if rs.cadenceSrc != nil && rs.cadenceSrc != rs.powerSrc {
+		sensors = append(sensors, rs.cadenceSrc)
+	}
+	return sensors
+}
+
+// sensorChannel returns the sensor's readings, or nil without a sensor
+func (rs *RideSession) sensorChannel(sensor bluetooth.Sensor) <-chan bluetooth.SensorData {
+	if sensor == nil {
+		return nil
+	}
+	return sensor.DataChannel()
+}
+
+// handleSensor keeps the values the sensor is the source for
+func (rs *RideSession) handleSensor(sensor bluetooth.Sensor, d bluetooth.SensorData) {
+	now := time.Now()
+	if sensor == rs.powerSrc && d.Has(bluetooth.FieldPower) {
+		rs.power = d.Power
+		rs.powerAt = now
+	}
+	if sensor == rs.cadenceSrc && d.Has(bluetooth.FieldCadence) {
+		rs.cadence = d.Cadence
+		rs.cadenceAt = now
+	}
+}
+
+// currentInputs returns power and cadence from their configured sources.
+// A sensor that has gone quiet reads as zero rather than falling back to
+// the trainer.
+func (rs *RideSession) currentInputs(trainerData bluetooth.TrainerData, now time.Time) (power, cadence float64) {
+	const maxAge = 3 * time.Second
+	power, cadence = trainerData.Power, trainerData.Cadence
+	if rs.powerSrc != nil {
+		power = 0
+		if now.Sub(rs.powerAt) <= maxAge {
+			power = rs.power
+		}
+	}
+	if rs.cadenceSrc != nil {
+		cadence = 0
+		if now.Sub(rs.cadenceAt) <= maxAge {
+			cadence = rs.cadence
+		}
+	}
+	return power, cadence
+}
+
+// currentHeartRate prefers a recent reading from the heart rate monitor,
+// then the trainer's built-in HR receiver. Returns 0 when neither has one.
+func (rs *RideSession) currentHeartRate(trainerData bluetooth.TrainerData, now time.Time) int {
+	const maxAge = 5 * time.Second
+	if !rs.hrAt.IsZero() && now.Sub(rs.hrAt) <= maxAge && rs.heartRate.Contact {
+		return rs.heartRate.HeartRate
+	}
+	if trainerData.Has(bluetooth.FieldHeartRate) {
+		return trainerData.HeartRate
+	}
+	return 0
+}
+
+// handleLink keeps the ride going across a trainer dropout and marks
+// the missing period as a gap
+func (rs *RideSession) handleLink(status bluetooth.ConnectionStatus) {
+	now := time.Now()
+	switch status {
+	case bluetooth.StatusReconnecting:
+		if rs.gapStart.IsZero() {
+			rs.gapStart = now
+		}
+		rs.notice = "Trainer: connection lost, reconnecting..."
+	case bluetooth.StatusConnected:
+		if !rs.gapStart.IsZero() {
+			rs.markGap(rs.gapStart, now)
+			rs.gapStart = time.Time{}
+			rs.lastUpdate = now // don't integrate distance over the gap
+			rs.notice = ""
+		}
+	}
+}
+
+// handleStatus follows pauses from the trainer's own buttons and notes
+// when another app takes control
+func (rs *RideSession) handleStatus(status bluetooth.MachineStatus) {
+	switch status.Event {
+	case bluetooth.EventStoppedByUser, bluetooth.EventPausedByUser, bluetooth.EventStoppedBySafetyKey:
+		if !rs.paused {
+			rs.togglePause()
+		}
+		rs.notice = "Trainer: " + status.Event.String()
+	case bluetooth.EventStartedByUser:
+		if rs.paused {
+			rs.togglePause()
+		}
+		rs.notice = ""
+	case bluetooth.EventControlLost:
+		rs.notice = "Trainer: control taken by another app"
+	}
+}
+
 // ShiftUp shifts to a harder gear
 func (rs *RideSession) ShiftUp() {
 	rs.engine.ShiftUp()
@@ -264,6 +644,64 @@ func (rs *RideSession) ShiftDown() {
 	rs.engine.ShiftDown()
 }
 
+// FrontShiftUp shifts to a larger chainring
+func (rs *RideSession) FrontShiftUp() {
+	rs.engine.FrontShiftUp()
+}
+
+// FrontShiftDown shifts to a smaller chainring
+func (rs *RideSession) FrontShiftDown() {
+	rs.engine.FrontShiftDown()
+}
+
+// SkipStep moves the workout to its next step
+func (rs *RideSession) SkipStep() {
+	if !rs.lock() {
+		return
+	}
+	defer rs.mu.Unlock()
+	if rs.player != nil {
+		rs.announce(rs.player.Skip())
+	}
+}
+
+// announce shows the last workout event on the ride screen for a while
+func (rs *RideSession) announce(events []workout.Event) {
+	if len(events) == 0 {
+		return
+	}
+	switch e := events[len(events)-1]; e.Type {
+	case workout.EventStepStart:
+		rs.stepNotice = fmt.Sprintf("Step %d: %s for %s",
+			e.Interval.Index+1, describeStep(e.Interval), formatClock(e.Interval.Duration))
+	case workout.EventFinished:
+		rs.stepNotice = "Workout complete"
+	}
+	rs.stepAt = time.Now()
+}
+
+// ExtendStep lengthens the current workout step
+func (rs *RideSession) ExtendStep(d time.Duration) {
+	if !rs.lock() {
+		return
+	}
+	defer rs.mu.Unlock()
+	if rs.player != nil {
+		rs.player.Extend(d)
+	}
+}
+
+// AdjustIntensity scales the workout power targets by delta
+func (rs *RideSession) AdjustIntensity(delta float64) {
+	if !rs.lock() {
+		return
+	}
+	defer rs.mu.Unlock()
+	if rs.player != nil {
+		rs.player.AdjustIntensity(delta)
+	}
+}
+
 // AdjustResistance changes manual resistance
 func (rs *RideSession) AdjustResistance(delta float64) {
 	rs.engine.AdjustManualResistance(delta)
@@ -271,30 +709,184 @@ func (rs *RideSession) AdjustResistance(delta float64) {
 
 // TogglePause toggles pause state
 func (rs *RideSession) TogglePause() {
+	if !rs.lock() {
+		return
+	}
+	defer rs.mu.Unlock()
+	rs.togglePause()
+}
+
+func (rs *RideSession) togglePause() {
 	rs.paused = !rs.paused
+	var err error
 	if rs.paused {
 		rs.ride.Pause()
+		err = rs.journal.Pause()
 	} else {
 		rs.ride.Resume()
+		err = rs.journal.Resume()
+	}
+	if err != nil {
+		rs.journalErr = err
+	}
+}
+
+// lock takes the session lock unless the session is stopping, when the
+// ride and its journal are no longer to be changed
+func (rs *RideSession) lock() bool {
+	rs.mu.Lock()
+	if rs.ctx.Err() != nil {
+		rs.mu.Unlock()
+		return false
+	}
+	return true
+}
+
+// markGap records a period without trainer data
+func (rs *RideSession) markGap(start, end time.Time) {
+	rs.ride.MarkGap(start, end)
+	if err := rs.journal.MarkGap(start, end); err != nil {
+		rs.journalErr = err
 	}
 }
 
+// resume picks a journaled ride up where it stopped: the distance along
+// the route, the averages and the workout step
+func (rs *RideSession) resume() {
+	points := rs.ride.Points
+	if len(points) == 0 {
+		return
+	}
+	last := points[len(points)-1]
+	rs.resumedAt = last.Timestamp
+	rs.distance = last.Distance
+
+	for _, p := range points {
+		rs.totalPower += p.Power
+		rs.totalCadence += p.Cadence
+		rs.totalSpeed += p.Speed
+		rs.pointCount++
+	}
+
+	// Extensions and intensity changes made before are not journaled.
+	// Replaying the steps announces nothing.
+	if rs.player != nil {
+		stepStart := last.Timestamp
+		for i := len(points) - 1; i >= 0 && points[i].Step == last.Step; i-- {
+			stepStart = points[i].Timestamp
+		}
+		rs.player.Start()
+		for rs.player.Index() < last.Step && !rs.player.Done() {
+			rs.player.Skip()
+		}
+		rs.player.Advance(last.Timestamp.Sub(stepStart))
+	}
+}
+
+// Ride types as recorded in a ride journal
+var sessionTypes = map[RideType]string{
+	RideFree:    "free",
+	RideERG:     "erg",
+	RideRoute:   "route",
+	RideWorkout: "workout",
+}
+
+// sessionInfo records how to resume a ride in its journal
+func sessionInfo(rideType RideType, route *RouteInfo, workoutInfo *WorkoutInfo) map[string]string {
+	info := map[string]string{"type": sessionTypes[rideType]}
+	if route != nil {
+		info["route"] = route.Path
+	}
+	if workoutInfo != nil {
+		info["workout"] = workoutInfo.Path
+	}
+	return info
+}
+
+// parseSessionInfo loads the route and workout of a journaled ride
+func parseSessionInfo(info map[string]string, cfg *config.Config) (RideType, *RouteInfo, *WorkoutInfo, error) {
+	rideType := RideFree
+	for t, name := range sessionTypes {
+		if name == info["type"] {
+			rideType = t
+		}
+	}
+
+	var route *RouteInfo
+	if path := info["route"]; path != "" {
+		r, err := gpx.Load(path)
+		if err != nil {
+			return 0, nil, nil, fmt.Errorf("load route: %w", err)
+		}
+		name := r.Name
+		if name == "" {
+			name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		}
+		route = &RouteInfo{Path: path, Name: name, Distance: r.TotalDistance, Ascent: r.TotalAscent}
+		if r.TotalDistance > 0 {
+			route.AvgGrade = r.TotalAscent / r.TotalDistance * 100
+		}
+	}
+
+	var workoutInfo *WorkoutInfo
+	if path := info["workout"]; path != "" {
+		w, err := workout.Load(path)
+		if err != nil {
+			return 0, nil, nil, fmt.Errorf("load workout: %w", err)
+		}
+		ftp := cfg.Rider.EffectiveFTP()
+		workoutInfo = &WorkoutInfo{
+			Path:     path,
+			Name:     w.Name,
+			Duration: w.Duration(),
+			TSS:      w.TSS(ftp),
+			Profile:  w.Profile(workoutSparkWidth, ftp),
+			Workout:  w,
+		}
+	}
+
+	return rideType, route, workoutInfo, nil
+}
+
 // Stop ends the ride session
 func (rs *RideSession) Stop() tea.Cmd {
 	return func() tea.Msg {
+		// Wait for a data loop iteration in progress; later ones see the
+		// session stopped and leave the ride and journal alone
 		rs.cancel()
+		rs.mu.Lock()
+		rs.mu.Unlock()
+
 		rs.btManager.Disconnect()
+		if rs.hrMonitor != nil {
+			rs.hrMonitor.Disconnect()
+		}
+		for _, sensor := range rs.sensors() {
+			sensor.Disconnect()
+		}
 
-		// Save ride
+		// Save ride, keeping the journal to recover it if that fails
+		if !rs.gapStart.IsZero() {
+			rs.markGap(rs.gapStart, time.Now())
+		}
 		rs.ride.Finish()
 		var rideID string
-		if len(rs.ride.Points) > 0 {
-			rs.store.SaveRide(rs.ride)
-			rideID = rs.ride.ID
+		var err error
+		switch {
+		case len(rs.ride.Points) == 0:
+			rs.journal.Discard()
+		default:
+			if err = rs.store.SaveRide(rs.ride); err != nil {
+				rs.journal.Close()
+				err = fmt.Errorf("save ride: %w", err)
+			} else {
+				rs.journal.Discard()
+				rideID = rs.ride.ID
+			}
 		}
 
 		rs.store.Close()
 
-		return RideFinishedMsg{RideID: rideID}
+		return RideFinishedMsg{RideID: rideID, Err: err}
 	}
 }
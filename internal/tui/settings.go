@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/thiemotorres/goc/internal/bluetooth"
 	"github.com/thiemotorres/goc/internal/config"
 )
 
@@ -18,8 +19,15 @@ func NewSettingsMenu(cfg *config.Config) *SettingsMenu {
 	return &SettingsMenu{
 		items: []string{
 			"Trainer Connection",
+			"Heart Rate Monitor",
+			"Power Meter",
+			"Cadence Sensor",
+			"Power Source",
+			"Cadence Source",
 			"Bike Settings",
+			"Rider Profile",
 			"Routes Folder",
+			"Workouts Folder",
 			"← Back",
 		},
 		config: cfg,
@@ -60,16 +68,24 @@ func (m *SettingsMenu) View() string {
 		// Add current value for some items
 		extra := ""
 		switch i {
-		case 0: // Trainer
-			if m.config.Bluetooth.TrainerAddress != "" {
-				extra = fmt.Sprintf(" (%s)", truncate(m.config.Bluetooth.TrainerAddress, 17))
+		case 0, 1, 2, 3: // Trainer and sensors
+			if address := settingsDevices[i].Address(m.config); address != "" {
+				extra = fmt.Sprintf(" (%s)", truncate(address, 17))
 			} else {
 				extra = " (not set)"
 			}
-		case 1: // Bike Settings
+		case 4: // Power Source
+			extra = fmt.Sprintf(" (%s)", sourceName(m.config.Bluetooth.PowerSource))
+		case 5: // Cadence Source
+			extra = fmt.Sprintf(" (%s)", sourceName(m.config.Bluetooth.CadenceSource))
+		case 6: // Bike Settings
 			extra = fmt.Sprintf(" (%d chainrings, %d cogs)", len(m.config.Bike.Chainrings), len(m.config.Bike.Cassette))
-		case 2: // Routes
+		case 7: // Rider Profile
+			extra = fmt.Sprintf(" (FTP %.0f W)", m.config.Rider.EffectiveFTP())
+		case 8: // Routes
 			extra = fmt.Sprintf("\n      %s", truncate(m.config.Routes.Folder, 40))
+		case 9: // Workouts
+			extra = fmt.Sprintf("\n      %s", truncate(m.config.Workouts.Folder, 40))
 		}
 
 		b.WriteString(cursor + style.Render(item+extra) + "\n")
@@ -81,51 +97,84 @@ func (m *SettingsMenu) View() string {
 	return centerView(menuStyle.Render(b.String()))
 }
 
-// TrainerSettings shows trainer connection options
-type TrainerSettings struct {
+// settingsDevices are the devices in the first settings menu items
+var settingsDevices = []DeviceKind{DeviceTrainer, DeviceHeartRate, DevicePowerMeter, DeviceCadenceSensor}
+
+// Selectable power and cadence sources, in cycling order
+var (
+	powerSources   = []string{bluetooth.SourceTrainer, bluetooth.SourcePowerMeter}
+	cadenceSources = []string{bluetooth.SourceTrainer, bluetooth.SourcePowerMeter, bluetooth.SourceCadenceSensor}
+)
+
+// nextSource returns the source after current in sources
+func nextSource(sources []string, current string) string {
+	for i, s := range sources {
+		if s == current {
+			return sources[(i+1)%len(sources)]
+		}
+	}
+	return sources[0]
+}
+
+// sourceName returns a display name for a power or cadence source
+func sourceName(source string) string {
+	switch source {
+	case bluetooth.SourcePowerMeter:
+		return "power meter"
+	case bluetooth.SourceCadenceSensor:
+		return "cadence sensor"
+	default:
+		return "trainer"
+	}
+}
+
+// DeviceSettings shows connection options for a trainer or sensor
+type DeviceSettings struct {
+	kind     DeviceKind
 	items    []string
 	selected int
 	address  string
 }
 
-func NewTrainerSettings(address string) *TrainerSettings {
-	return &TrainerSettings{
+func NewDeviceSettings(kind DeviceKind, address string) *DeviceSettings {
+	return &DeviceSettings{
+		kind: kind,
 		items: []string{
-			"Scan for Trainers",
-			"Forget Saved Trainer",
+			"Scan for " + kind.Plural(),
+			"Forget Saved " + kind.Name(),
 			"← Back",
 		},
 		address: address,
 	}
 }
 
-func (m *TrainerSettings) MoveUp() {
+func (m *DeviceSettings) MoveUp() {
 	if m.selected > 0 {
 		m.selected--
 	}
 }
 
-func (m *TrainerSettings) MoveDown() {
+func (m *DeviceSettings) MoveDown() {
 	if m.selected < len(m.items)-1 {
 		m.selected++
 	}
 }
 
-func (m *TrainerSettings) Selected() int {
+func (m *DeviceSettings) Selected() int {
 	return m.selected
 }
 
-func (m *TrainerSettings) View() string {
+func (m *DeviceSettings) View() string {
 	var b strings.Builder
 
-	title := titleStyle.Render("Trainer Connection")
+	title := titleStyle.Render(m.kind.Title())
 	b.WriteString(title)
 	b.WriteString("\n\n")
 
 	if m.address != "" {
 		b.WriteString(fmt.Sprintf("Saved: %s\n\n", m.address))
 	} else {
-		b.WriteString("No trainer saved\n\n")
+		b.WriteString(fmt.Sprintf("No %s saved\n\n", strings.ToLower(m.kind.Name())))
 	}
 
 	for i, item := range m.items {
@@ -4,6 +4,7 @@ import (
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/thiemotorres/goc/internal/bluetooth"
 )
 
 // RideType represents the type of ride to start
@@ -13,12 +14,14 @@ const (
 	RideFree RideType = iota
 	RideERG
 	RideRoute
+	RideWorkout
 )
 
 // StartRideMenu is the start ride submenu
 type StartRideMenu struct {
 	items    []string
 	selected int
+	disabled map[int]bool
 }
 
 func NewStartRideMenu() *StartRideMenu {
@@ -27,6 +30,7 @@ func NewStartRideMenu() *StartRideMenu {
 			"Free Ride (no target)",
 			"ERG Mode (fixed power)",
 			"Ride a Route",
+			"Structured Workout",
 			"← Back",
 		},
 		selected: 0,
@@ -49,6 +53,20 @@ func (m *StartRideMenu) Selected() int {
 	return m.selected
 }
 
+// SetCapabilities greys out ride types the last connected trainer
+// does not support
+func (m *StartRideMenu) SetCapabilities(caps bluetooth.TrainerCapabilities) {
+	m.disabled = map[int]bool{
+		int(RideERG):     !caps.SupportsERG(),
+		int(RideWorkout): !caps.SupportsERG(),
+	}
+}
+
+// IsDisabled reports whether item i is greyed out
+func (m *StartRideMenu) IsDisabled(i int) bool {
+	return m.disabled[i]
+}
+
 func (m *StartRideMenu) View() string {
 	var b strings.Builder
 
@@ -63,6 +81,10 @@ func (m *StartRideMenu) View() string {
 			cursor = "> "
 			style = selectedStyle
 		}
+		if m.disabled[i] {
+			style = disabledStyle
+			item += " (not supported)"
+		}
 		b.WriteString(cursor + style.Render(item) + "\n")
 	}
 
@@ -1,6 +1,11 @@
 package tui
 
-import "github.com/charmbracelet/lipgloss"
+import (
+	"fmt"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/thiemotorres/goc/internal/zones"
+)
 
 var (
 	// Colors
@@ -26,7 +31,34 @@ var (
 	normalStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("252"))
 
+	disabledStyle = lipgloss.NewStyle().
+			Foreground(secondaryColor)
+
 	helpStyle = lipgloss.NewStyle().
 			Foreground(secondaryColor).
 			MarginTop(1)
 )
+
+// zoneLabel names zone i as Z1, Z2... followed by its name if it has one
+func zoneLabel(m zones.Model, i int) string {
+	label := fmt.Sprintf("Z%d", i+1)
+	if name := m.Zones[i].Name; name != label {
+		label += " " + name
+	}
+	return label
+}
+
+// zonePalette colours training zones from recovery to sprint
+var zonePalette = []lipgloss.Color{"245", "39", "42", "226", "214", "196", "129"}
+
+// zoneColor returns the colour of zone i out of n, spreading the palette
+// over models with fewer or more zones
+func zoneColor(i, n int) lipgloss.Color {
+	if i < 0 || n <= 0 {
+		return lipgloss.Color("252")
+	}
+	if n == 1 {
+		return zonePalette[0]
+	}
+	return zonePalette[i*(len(zonePalette)-1)/(n-1)]
+}
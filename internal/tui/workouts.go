@@ -0,0 +1,172 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/thiemotorres/goc/internal/workout"
+)
+
+// WorkoutInfo holds summary info for a workout file
+type WorkoutInfo struct {
+	Path     string
+	Name     string
+	Duration time.Duration
+	TSS      float64
+	Profile  []float64 // planned watts, for the sparkline
+	Workout  *workout.Workout
+}
+
+// workoutSparkWidth is the number of characters in the intensity sparkline
+const workoutSparkWidth = 24
+
+// WorkoutsBrowser displays available workout files
+type WorkoutsBrowser struct {
+	workouts []WorkoutInfo
+	selected int
+	folder   string
+	ftp      float64
+	skipped  int // files that failed to parse
+	err      error
+}
+
+func NewWorkoutsBrowser(folder string, ftp float64) *WorkoutsBrowser {
+	wb := &WorkoutsBrowser{folder: folder, ftp: ftp}
+	wb.loadWorkouts()
+	return wb
+}
+
+func (wb *WorkoutsBrowser) loadWorkouts() {
+	wb.workouts = nil
+	wb.skipped = 0
+	wb.err = nil
+
+	// Create folder if it doesn't exist
+	if err := os.MkdirAll(wb.folder, 0755); err != nil {
+		wb.err = err
+		return
+	}
+
+	entries, err := os.ReadDir(wb.folder)
+	if err != nil {
+		wb.err = err
+		return
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || !workout.IsWorkoutFile(entry.Name()) {
+			continue
+		}
+
+		path := filepath.Join(wb.folder, entry.Name())
+		w, err := workout.Load(path)
+		if err != nil {
+			wb.skipped++
+			continue
+		}
+
+		wb.workouts = append(wb.workouts, WorkoutInfo{
+			Path:     path,
+			Name:     w.Name,
+			Duration: w.Duration(),
+			TSS:      w.TSS(wb.ftp),
+			Profile:  w.Profile(workoutSparkWidth, wb.ftp),
+			Workout:  w,
+		})
+	}
+}
+
+func (wb *WorkoutsBrowser) MoveUp() {
+	if wb.selected > 0 {
+		wb.selected--
+	}
+}
+
+func (wb *WorkoutsBrowser) MoveDown() {
+	if wb.selected < len(wb.workouts) { // includes Back option
+		wb.selected++
+	}
+}
+
+func (wb *WorkoutsBrowser) Selected() int {
+	return wb.selected
+}
+
+func (wb *WorkoutsBrowser) SelectedWorkout() *WorkoutInfo {
+	if wb.selected < len(wb.workouts) {
+		return &wb.workouts[wb.selected]
+	}
+	return nil
+}
+
+func (wb *WorkoutsBrowser) View() string {
+	var b strings.Builder
+
+	title := titleStyle.Render("Browse Workouts")
+	b.WriteString(title)
+	b.WriteString("\n\n")
+
+	if wb.err != nil {
+		b.WriteString(fmt.Sprintf("Error: %v\n", wb.err))
+	} else if len(wb.workouts) == 0 {
+		b.WriteString(fmt.Sprintf("No workouts found in:\n%s\n\n", wb.folder))
+		b.WriteString("Add .zwo, .erg, .mrc or .json files to this folder.\n")
+	} else {
+		for i, w := range wb.workouts {
+			cursor := "  "
+			style := normalStyle
+			if i == wb.selected {
+				cursor = "> "
+				style = selectedStyle
+			}
+			line := fmt.Sprintf("%-20s %s  %3.0f TSS  %s",
+				truncate(w.Name, 20),
+				formatWorkoutDuration(w.Duration),
+				w.TSS,
+				intensitySparkline(w.Profile, wb.ftp),
+			)
+			b.WriteString(cursor + style.Render(line) + "\n")
+		}
+	}
+	if wb.skipped > 0 {
+		b.WriteString(helpStyle.Render(fmt.Sprintf("\n%d file(s) could not be read", wb.skipped)) + "\n")
+	}
+
+	// Back option
+	cursor := "  "
+	style := normalStyle
+	if wb.selected == len(wb.workouts) {
+		cursor = "> "
+		style = selectedStyle
+	}
+	b.WriteString("\n" + cursor + style.Render("← Back") + "\n")
+
+	help := helpStyle.Render(fmt.Sprintf("\nTSS at %.0fW FTP • ↑/↓: navigate • esc: back", wb.ftp))
+	b.WriteString(help)
+
+	return centerView(menuStyle.Render(b.String()))
+}
+
+// formatWorkoutDuration formats a duration as h:mm
+func formatWorkoutDuration(d time.Duration) string {
+	minutes := int(d.Round(time.Minute).Minutes())
+	return fmt.Sprintf("%d:%02d", minutes/60, minutes%60)
+}
+
+// intensitySparkline draws planned power relative to FTP. The full bar is
+// 150% of FTP so workouts can be compared with each other.
+func intensitySparkline(profile []float64, ftp float64) string {
+	chars := []rune{'▁', '▂', '▃', '▄', '▅', '▆', '▇', '█'}
+
+	var sb strings.Builder
+	for _, p := range profile {
+		normalized := p / (1.5 * ftp)
+		idx := int(normalized * float64(len(chars)-1))
+		idx = min(max(idx, 0), len(chars)-1)
+		sb.WriteRune(chars[idx])
+	}
+	return sb.String()
+}
@@ -0,0 +1,133 @@
+package tui
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/thiemotorres/goc/internal/workout"
+)
+
+// Workout controls on the ride screen
+const (
+	workoutExtendStep    = time.Minute
+	workoutIntensityStep = 0.05
+)
+
+// buildWorkoutView renders the workout panel: a step change notice, the
+// current and next step, target against actual power and the workout
+// profile with a progress marker
+func buildWorkoutView(status *workout.Status, notice string, power float64, width int) string {
+	var b strings.Builder
+
+	labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	stepStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("229"))
+
+	b.WriteString(stepStyle.Render(truncate(status.Name, max(width, 4))) + "\n")
+	if notice != "" {
+		noticeStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("42"))
+		b.WriteString(noticeStyle.Render("▶ "+notice) + "\n")
+	}
+	b.WriteString("\n")
+
+	if status.Done {
+		b.WriteString("Workout complete, free ride\n")
+	} else {
+		cur := status.Current
+		b.WriteString(fmt.Sprintf("%s %d/%d  %s\n", labelStyle.Render("Step"), cur.Index+1, status.Steps, stepStyle.Render(describeStep(cur))))
+		if cur.Text != "" {
+			b.WriteString(helpStyle.Render(cur.Text) + "\n")
+		}
+		b.WriteString(fmt.Sprintf("%s  %s\n", labelStyle.Render("Remaining"), formatClock(status.StepRemaining)))
+
+		if cur.Kind != workout.StepFreeRide {
+			b.WriteString(fmt.Sprintf("%s     %.0f W  %s %s\n",
+				labelStyle.Render("Target"), status.TargetPower,
+				labelStyle.Render("Actual"), powerStyle(power, status.TargetPower).Render(fmt.Sprintf("%.0f W", power))))
+		}
+		if status.TargetCadence > 0 {
+			b.WriteString(fmt.Sprintf("%s    %d rpm\n", labelStyle.Render("Cadence"), status.TargetCadence))
+		}
+
+		next := "finish"
+		if status.HasNext {
+			next = fmt.Sprintf("%s %s", describeStep(status.Next), formatClock(status.Next.Duration))
+		}
+		b.WriteString(fmt.Sprintf("%s       %s\n", labelStyle.Render("Next"), next))
+	}
+	if status.Intensity != 1 {
+		b.WriteString(fmt.Sprintf("%s  %.0f%%\n", labelStyle.Render("Intensity"), status.Intensity*100))
+	}
+
+	// Profile with progress marker
+	if width > 0 && len(status.Profile) > 0 {
+		profile := resample(status.Profile, width)
+		b.WriteString("\n" + intensitySparkline(profile, status.FTP) + "\n")
+
+		pos := 0
+		if status.Duration > 0 {
+			pos = int(float64(status.Elapsed) / float64(status.Duration) * float64(width))
+		}
+		pos = min(max(pos, 0), width-1)
+		b.WriteString(strings.Repeat(" ", pos) + "▲\n")
+	}
+	b.WriteString(fmt.Sprintf("%s / %s\n\n", formatClock(status.Elapsed), formatClock(status.Duration)))
+
+	b.WriteString(helpStyle.Render("[n] Skip  [e] +1 min  [+/-] Intensity"))
+
+	return b.String()
+}
+
+// describeStep names a step and its power target
+func describeStep(iv workout.Interval) string {
+	var s string
+	switch iv.Kind {
+	case workout.StepSteady:
+		s = iv.Power.String()
+	case workout.StepRamp:
+		s = fmt.Sprintf("%s → %s", iv.Power, iv.PowerEnd)
+	default:
+		s = iv.Kind.String()
+	}
+	if iv.Reps > 0 {
+		s += fmt.Sprintf(" (%d/%d)", iv.Rep, iv.Reps)
+	}
+	return s
+}
+
+// powerStyle colours actual power by how close it is to the target
+func powerStyle(power, target float64) lipgloss.Style {
+	color := "42" // within 5%
+	if target > 0 {
+		switch off := math.Abs(power-target) / target; {
+		case off > 0.15:
+			color = "196"
+		case off > 0.05:
+			color = "214"
+		}
+	}
+	return lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color(color))
+}
+
+// resample picks n evenly spaced values
+func resample(values []float64, n int) []float64 {
+	out := make([]float64, n)
+	for i := range out {
+		out[i] = values[i*len(values)/n]
+	}
+	return out
+}
+
+// formatClock formats a duration as m:ss, or h:mm:ss from an hour
+func formatClock(d time.Duration) string {
+	d = max(d, 0).Round(time.Second)
+	h := int(d.Hours())
+	m := int(d.Minutes()) % 60
+	s := int(d.Seconds()) % 60
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", h, m, s)
+	}
+	return fmt.Sprintf("%d:%02d", m, s)
+}
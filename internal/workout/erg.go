@@ -0,0 +1,114 @@
+package workout
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ergPoint is a course data point: power at a time in minutes
+type ergPoint struct {
+	minutes float64
+	value   float64
+}
+
+// ParseERG reads a TrainerRoad-style .erg (watts) or .mrc (percent of
+// FTP) workout. The unit comes from the MINUTES WATTS or MINUTES PERCENT
+// header line.
+func ParseERG(r io.Reader) (*Workout, error) {
+	w := &Workout{}
+	unit := UnitWatts
+	var points []ergPoint
+
+	section := ""
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			section = strings.ToUpper(line)
+			continue
+		}
+
+		switch section {
+		case "[COURSE HEADER]":
+			key, value, ok := strings.Cut(line, "=")
+			if !ok {
+				// Column header, e.g. "MINUTES WATTS"
+				if strings.Contains(strings.ToUpper(line), "PERCENT") {
+					unit = UnitFTP
+				}
+				continue
+			}
+			switch strings.ToUpper(strings.TrimSpace(key)) {
+			case "DESCRIPTION":
+				w.Description = strings.TrimSpace(value)
+			case "FILE NAME":
+				w.Name = strings.TrimSpace(value)
+			}
+
+		case "[COURSE DATA]":
+			fields := strings.Fields(line)
+			if len(fields) < 2 {
+				return nil, fmt.Errorf("line %d: expected minutes and power", lineNum)
+			}
+			minutes, err := strconv.ParseFloat(fields[0], 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: minutes: %w", lineNum, err)
+			}
+			value, err := strconv.ParseFloat(fields[1], 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: power: %w", lineNum, err)
+			}
+			if len(points) > 0 && minutes < points[len(points)-1].minutes {
+				return nil, fmt.Errorf("line %d: time goes backwards", lineNum)
+			}
+			points = append(points, ergPoint{minutes: minutes, value: value})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(points) < 2 {
+		return nil, errors.New("no course data")
+	}
+
+	w.Name = strings.TrimSuffix(w.Name, ".erg")
+	w.Name = strings.TrimSuffix(w.Name, ".mrc")
+	w.Steps = ergSteps(points, unit)
+
+	if err := w.Validate(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// ergSteps turns course data points into steps. Each pair of points is a
+// segment: flat segments are steady steps, sloped ones are ramps, and
+// points at the same time are step changes.
+func ergSteps(points []ergPoint, unit Unit) []Step {
+	var steps []Step
+	for i := 1; i < len(points); i++ {
+		from, to := points[i-1], points[i]
+		if to.minutes == from.minutes {
+			continue
+		}
+		d := time.Duration(math.Round((to.minutes-from.minutes)*60)) * time.Second
+		start := Target{Value: from.value, Unit: unit}
+		if from.value == to.value {
+			steps = append(steps, Steady(d, start))
+		} else {
+			steps = append(steps, Ramp(d, start, Target{Value: to.value, Unit: unit}))
+		}
+	}
+	return steps
+}
@@ -0,0 +1,45 @@
+package workout
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad_ERG(t *testing.T) {
+	w, err := Load("../../testdata/workout.erg")
+	require.NoError(t, err)
+
+	assert.Equal(t, "over-unders", w.Name)
+	assert.Equal(t, "Two over-unders", w.Description)
+	require.Len(t, w.Steps, 4)
+
+	assert.Equal(t, Ramp(5*time.Minute, Watts(100), Watts(180)), w.Steps[0])
+	assert.Equal(t, Steady(2*time.Minute, Watts(240)), w.Steps[1])
+	assert.Equal(t, Steady(time.Minute, Watts(280)), w.Steps[2])
+	assert.Equal(t, 10*time.Minute, w.Duration())
+}
+
+func TestLoad_MRC(t *testing.T) {
+	w, err := Load("../../testdata/workout.mrc")
+	require.NoError(t, err)
+
+	assert.Equal(t, "workout", w.Name, "named after the file without FILE NAME")
+	require.Len(t, w.Steps, 3)
+	assert.Equal(t, Steady(time.Minute, PercentFTP(60)), w.Steps[1])
+	assert.Equal(t, 30*time.Second, w.Steps[2].Duration)
+}
+
+func TestParseERG_Errors(t *testing.T) {
+	_, err := ParseERG(strings.NewReader("[COURSE DATA]\n0 100\n[END COURSE DATA]\n"))
+	assert.Error(t, err)
+
+	_, err = ParseERG(strings.NewReader("[COURSE DATA]\n0 100\n5 abc\n"))
+	assert.ErrorContains(t, err, "line 3")
+
+	_, err = ParseERG(strings.NewReader("[COURSE DATA]\n5 100\n2 100\n"))
+	assert.ErrorContains(t, err, "backwards")
+}
@@ -0,0 +1,86 @@
+package workout
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// jsonWorkout is the goc JSON workout format
+type jsonWorkout struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description,omitempty"`
+	Steps       []jsonStep `json:"steps"`
+}
+
+// jsonStep is one step. Durations are seconds; power is percent of FTP
+// unless unit is "watts".
+type jsonStep struct {
+	Type     string     `json:"type"` // steady, ramp, free, repeat
+	Duration float64    `json:"duration,omitempty"`
+	Power    float64    `json:"power,omitempty"`
+	PowerEnd float64    `json:"power_end,omitempty"`
+	Unit     string     `json:"unit,omitempty"` // "ftp" (default) or "watts"
+	Cadence  int        `json:"cadence,omitempty"`
+	Text     string     `json:"text,omitempty"`
+	Repeat   int        `json:"repeat,omitempty"`
+	Steps    []jsonStep `json:"steps,omitempty"`
+}
+
+// ParseJSON reads a workout in the goc JSON format
+func ParseJSON(r io.Reader) (*Workout, error) {
+	var f jsonWorkout
+	if err := json.NewDecoder(r).Decode(&f); err != nil {
+		return nil, fmt.Errorf("parse json: %w", err)
+	}
+
+	steps, err := fromJSONSteps(f.Steps)
+	if err != nil {
+		return nil, err
+	}
+	w := &Workout{Name: f.Name, Description: f.Description, Steps: steps}
+
+	if err := w.Validate(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func fromJSONSteps(in []jsonStep) ([]Step, error) {
+	steps := make([]Step, 0, len(in))
+	for _, s := range in {
+		var unit Unit
+		switch s.Unit {
+		case "", "ftp":
+			unit = UnitFTP
+		case "watts":
+			unit = UnitWatts
+		default:
+			return nil, fmt.Errorf("unknown unit %q", s.Unit)
+		}
+
+		d := seconds(s.Duration)
+		power := Target{Value: s.Power, Unit: unit}
+		var step Step
+		switch s.Type {
+		case "steady":
+			step = Steady(d, power)
+		case "ramp":
+			step = Ramp(d, power, Target{Value: s.PowerEnd, Unit: unit})
+		case "free":
+			step = FreeRide(d)
+		case "repeat":
+			inner, err := fromJSONSteps(s.Steps)
+			if err != nil {
+				return nil, err
+			}
+			step = Repeat(s.Repeat, inner...)
+		default:
+			return nil, fmt.Errorf("unknown step type %q", s.Type)
+		}
+		step.Cadence = s.Cadence
+		step.Text = s.Text
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
@@ -0,0 +1,46 @@
+package workout
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad_JSON(t *testing.T) {
+	w, err := Load("../../testdata/workout.json")
+	require.NoError(t, err)
+
+	assert.Equal(t, "Threshold 2x20", w.Name)
+	require.Len(t, w.Steps, 3)
+	assert.Equal(t, Ramp(10*time.Minute, PercentFTP(50), PercentFTP(75)), w.Steps[0])
+
+	block := w.Steps[1]
+	assert.Equal(t, 2, block.Repeat)
+	assert.Equal(t, Steady(20*time.Minute, PercentFTP(95)).WithCadence(90).WithText("Hold it"), block.Steps[0])
+	assert.Equal(t, Watts(120), block.Steps[1].Power)
+
+	assert.Equal(t, FreeRide(5*time.Minute), w.Steps[2])
+	assert.Equal(t, 65*time.Minute, w.Duration())
+}
+
+func TestParseJSON_Errors(t *testing.T) {
+	for name, input := range map[string]string{
+		"syntax": `{"name": `,
+		"type":   `{"steps": [{"type": "sprint", "duration": 10}]}`,
+		"unit":   `{"steps": [{"type": "steady", "duration": 10, "power": 100, "unit": "kw"}]}`,
+		"empty":  `{"name": "nothing", "steps": []}`,
+	} {
+		_, err := ParseJSON(strings.NewReader(input))
+		assert.Error(t, err, name)
+	}
+}
+
+func TestLoad_UnsupportedType(t *testing.T) {
+	_, err := Load("../../testdata/simple.gpx")
+	assert.ErrorContains(t, err, "unsupported")
+	assert.False(t, IsWorkoutFile("route.gpx"))
+	assert.True(t, IsWorkoutFile("Intervals.ZWO"))
+}
@@ -0,0 +1,52 @@
+package workout
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Extensions are the workout file types Load understands
+var Extensions = []string{".zwo", ".erg", ".mrc", ".json"}
+
+// IsWorkoutFile reports whether path has a workout file extension
+func IsWorkoutFile(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, e := range Extensions {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
+
+// Load reads a workout file, choosing the parser from the extension.
+// Workouts without a name are named after the file.
+func Load(path string) (*Workout, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var w *Workout
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".zwo":
+		w, err = ParseZWO(f)
+	case ".erg", ".mrc":
+		w, err = ParseERG(f)
+	case ".json":
+		w, err = ParseJSON(f)
+	default:
+		return nil, fmt.Errorf("unsupported workout file type %q", ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+
+	if w.Name == "" {
+		w.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	return w, nil
+}
@@ -0,0 +1,300 @@
+package workout
+
+import (
+	"time"
+
+	"github.com/thiemotorres/goc/internal/simulation"
+)
+
+// Controller is the part of the simulation engine a Player drives
+type Controller interface {
+	SetMode(m simulation.Mode)
+	SetTargetPower(watts float64)
+}
+
+// EventType identifies a workout event
+type EventType int
+
+const (
+	EventStepStart EventType = iota // A new step started
+	EventFinished                   // The last step ended
+)
+
+// Event reports a change of step
+type Event struct {
+	Type     EventType
+	Interval Interval // Step started, or the last step when finished
+}
+
+// Player runs a workout against the simulation engine. Power steps put the
+// engine in ERG mode with the step target; free ride steps, the end of the
+// workout and trainers without ERG mode switch it to FREE mode.
+type Player struct {
+	workout   *Workout
+	intervals []Interval
+	ftp       float64
+	engine    Controller
+
+	elapsed   time.Duration
+	index     int
+	intensity float64 // scales every power target, 1 = as planned
+	started   bool
+	done      bool
+	noERG     bool // targets are shown but not applied
+}
+
+// NewPlayer creates a player for w. ftp resolves percentage targets.
+func NewPlayer(w *Workout, ftp float64, engine Controller) *Player {
+	return &Player{
+		workout:   w,
+		intervals: w.Intervals(),
+		ftp:       ftp,
+		engine:    engine,
+		intensity: 1,
+	}
+}
+
+// Start applies the first step and returns its start event
+func (p *Player) Start() []Event {
+	if p.started {
+		return nil
+	}
+	p.started = true
+	if len(p.intervals) == 0 {
+		return p.finish()
+	}
+	p.apply()
+	return []Event{{Type: EventStepStart, Interval: p.intervals[0]}}
+}
+
+// Advance moves the workout forward by dt, updating the engine target and
+// returning an event for every step boundary crossed
+func (p *Player) Advance(dt time.Duration) []Event {
+	events := p.Start()
+	if p.done {
+		return events
+	}
+
+	p.elapsed += dt
+	for !p.done && p.elapsed >= p.intervals[p.index].End() {
+		events = append(events, p.next()...)
+	}
+	if !p.done {
+		p.apply()
+	}
+	return events
+}
+
+// Skip ends the current step early and moves to the next one
+func (p *Player) Skip() []Event {
+	events := p.Start()
+	if p.done {
+		return events
+	}
+
+	// Shift the clock so the next step starts now
+	p.elapsed = p.intervals[p.index].End()
+	events = append(events, p.next()...)
+	if !p.done {
+		p.apply()
+	}
+	return events
+}
+
+// next moves past the current step
+func (p *Player) next() []Event {
+	if p.index+1 >= len(p.intervals) {
+		return p.finish()
+	}
+	p.index++
+	return []Event{{Type: EventStepStart, Interval: p.intervals[p.index]}}
+}
+
+func (p *Player) finish() []Event {
+	p.done = true
+	p.index = len(p.intervals)
+	p.engine.SetMode(simulation.ModeFREE)
+
+	var last Interval
+	if len(p.intervals) > 0 {
+		last = p.intervals[len(p.intervals)-1]
+	}
+	return []Event{{Type: EventFinished, Interval: last}}
+}
+
+// DisableERG rides the rest of the workout on manual resistance, for
+// trainers without ERG mode. Targets are still reported.
+func (p *Player) DisableERG() {
+	p.noERG = true
+	p.engine.SetMode(simulation.ModeFREE)
+}
+
+// apply sets the engine mode and target for the current moment
+func (p *Player) apply() {
+	iv := p.intervals[p.index]
+	if iv.Kind == StepFreeRide || p.noERG {
+		p.engine.SetMode(simulation.ModeFREE)
+		return
+	}
+	p.engine.SetMode(simulation.ModeERG)
+	p.engine.SetTargetPower(p.TargetPower())
+}
+
+// Extend lengthens the current step by d, moving later steps back. A ramp
+// is stretched over its new length.
+func (p *Player) Extend(d time.Duration) {
+	if p.done || len(p.intervals) == 0 {
+		return
+	}
+	p.intervals[p.index].Duration += d
+	for i := p.index + 1; i < len(p.intervals); i++ {
+		p.intervals[i].Start += d
+	}
+}
+
+// Intensity limits for AdjustIntensity
+const (
+	minIntensity = 0.5
+	maxIntensity = 1.5
+)
+
+// AdjustIntensity scales all power targets by delta, 0.05 making the rest
+// of the workout 5% harder. The scale stays between 50% and 150%.
+func (p *Player) AdjustIntensity(delta float64) {
+	p.intensity = min(max(p.intensity+delta, minIntensity), maxIntensity)
+	if !p.done && p.started {
+		p.apply()
+	}
+}
+
+// Intensity returns the scale applied to power targets
+func (p *Player) Intensity() float64 {
+	return p.intensity
+}
+
+// Workout returns the workout being played
+func (p *Player) Workout() *Workout {
+	return p.workout
+}
+
+// Intervals returns the expanded workout steps
+func (p *Player) Intervals() []Interval {
+	return p.intervals
+}
+
+// Current returns the step being ridden, false once the workout is done
+func (p *Player) Current() (Interval, bool) {
+	if p.done || len(p.intervals) == 0 {
+		return Interval{}, false
+	}
+	return p.intervals[p.index], true
+}
+
+// Index returns the position of the current step, len(Intervals()) once
+// the workout is done
+func (p *Player) Index() int {
+	return p.index
+}
+
+// Elapsed returns the time ridden in the workout
+func (p *Player) Elapsed() time.Duration {
+	return p.elapsed
+}
+
+// StepRemaining returns the time left in the current step
+func (p *Player) StepRemaining() time.Duration {
+	iv, ok := p.Current()
+	if !ok {
+		return 0
+	}
+	return iv.End() - p.elapsed
+}
+
+// TargetPower returns the current power target in watts, 0 without one
+func (p *Player) TargetPower() float64 {
+	iv, ok := p.Current()
+	if !ok {
+		return 0
+	}
+	return iv.PowerAt(p.elapsed-iv.Start, p.ftp) * p.intensity
+}
+
+// Next returns the step after the current one, false if there is none
+func (p *Player) Next() (Interval, bool) {
+	if p.done || p.index+1 >= len(p.intervals) {
+		return Interval{}, false
+	}
+	return p.intervals[p.index+1], true
+}
+
+// Duration returns the workout duration including extensions
+func (p *Player) Duration() time.Duration {
+	if len(p.intervals) == 0 {
+		return 0
+	}
+	return p.intervals[len(p.intervals)-1].End()
+}
+
+// Profile returns the average planned power in n equal slices of the
+// workout, including extensions and the intensity scale
+func (p *Player) Profile(n int) []float64 {
+	profile := profile(p.intervals, n, p.ftp)
+	for i := range profile {
+		profile[i] *= p.intensity
+	}
+	return profile
+}
+
+// TargetCadence returns the current cadence target, 0 without one
+func (p *Player) TargetCadence() int {
+	iv, ok := p.Current()
+	if !ok {
+		return 0
+	}
+	return iv.Cadence
+}
+
+// Done reports whether the last step has ended
+func (p *Player) Done() bool {
+	return p.done
+}
+
+// Status is a snapshot of the player for display
+type Status struct {
+	Name          string
+	Current       Interval // zero once done
+	Next          Interval
+	HasNext       bool
+	Done          bool
+	Steps         int // number of expanded steps
+	Elapsed       time.Duration
+	Duration      time.Duration
+	StepRemaining time.Duration
+	TargetPower   float64
+	TargetCadence int
+	Intensity     float64
+	FTP           float64
+	Profile       []float64 // planned watts in profileWidth slices
+}
+
+// Status returns the player state with a profile of profileWidth slices
+func (p *Player) Status(profileWidth int) Status {
+	current, _ := p.Current()
+	next, hasNext := p.Next()
+	return Status{
+		Name:          p.workout.Name,
+		Current:       current,
+		Next:          next,
+		HasNext:       hasNext,
+		Done:          p.done,
+		Steps:         len(p.intervals),
+		Elapsed:       p.elapsed,
+		Duration:      p.Duration(),
+		StepRemaining: p.StepRemaining(),
+		TargetPower:   p.TargetPower(),
+		TargetCadence: p.TargetCadence(),
+		Intensity:     p.intensity,
+		FTP:           p.ftp,
+		Profile:       p.Profile(profileWidth),
+	}
+}
@@ -0,0 +1,181 @@
+package workout
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thiemotorres/goc/internal/simulation"
+)
+
+type fakeEngine struct {
+	mode   simulation.Mode
+	target float64
+}
+
+func (e *fakeEngine) SetMode(m simulation.Mode)    { e.mode = m }
+func (e *fakeEngine) SetTargetPower(watts float64) { e.target = watts }
+
+func TestPlayer_Run(t *testing.T) {
+	engine := &fakeEngine{mode: simulation.ModeSIM}
+	p := NewPlayer(testWorkout(), 300, engine)
+
+	events := p.Start()
+	require.Len(t, events, 1)
+	assert.Equal(t, EventStepStart, events[0].Type)
+	assert.Equal(t, simulation.ModeERG, engine.mode)
+	assert.Equal(t, 150.0, engine.target)
+
+	// Halfway up the ramp
+	assert.Empty(t, p.Advance(5*time.Minute))
+	assert.InDelta(t, 187.5, engine.target, 0.01)
+	assert.Equal(t, 5*time.Minute, p.StepRemaining())
+
+	// Into the first interval
+	events = p.Advance(5 * time.Minute)
+	require.Len(t, events, 1)
+	assert.Equal(t, 1, events[0].Interval.Rep)
+	assert.InDelta(t, 330, engine.target, 0.01)
+	assert.Equal(t, 95, p.TargetCadence())
+
+	// Recovery is a free ride
+	p.Advance(4 * time.Minute)
+	assert.Equal(t, simulation.ModeFREE, engine.mode)
+	assert.Equal(t, 0.0, p.TargetPower())
+
+	// A long step crosses several boundaries at once
+	events = p.Advance(7 * time.Minute)
+	require.Len(t, events, 2)
+	assert.Equal(t, 4, events[1].Interval.Index)
+	assert.Equal(t, simulation.ModeFREE, engine.mode)
+
+	events = p.Advance(time.Hour)
+	require.NotEmpty(t, events)
+	assert.Equal(t, EventFinished, events[len(events)-1].Type)
+	assert.True(t, p.Done())
+	assert.Equal(t, simulation.ModeFREE, engine.mode)
+
+	_, ok := p.Current()
+	assert.False(t, ok)
+	assert.Empty(t, p.Advance(time.Minute))
+}
+
+func TestPlayer_Skip(t *testing.T) {
+	engine := &fakeEngine{}
+	w := &Workout{Steps: []Step{
+		Steady(time.Minute, Watts(100)),
+		Steady(time.Minute, Watts(200)),
+	}}
+	p := NewPlayer(w, 250, engine)
+
+	events := p.Skip()
+	require.Len(t, events, 2)
+	assert.Equal(t, 1, events[1].Interval.Index)
+	assert.Equal(t, 200.0, engine.target)
+	assert.Equal(t, time.Minute, p.StepRemaining())
+
+	events = p.Skip()
+	require.Len(t, events, 1)
+	assert.Equal(t, EventFinished, events[0].Type)
+}
+
+func TestPlayer_DisableERG(t *testing.T) {
+	engine := &fakeEngine{}
+	p := NewPlayer(testWorkout(), 300, engine)
+	p.Start()
+	require.Equal(t, simulation.ModeERG, engine.mode)
+
+	p.DisableERG()
+	assert.Equal(t, simulation.ModeFREE, engine.mode)
+
+	// Later steps and intensity changes leave the engine alone
+	engine.target = 0
+	p.Advance(11 * time.Minute)
+	p.AdjustIntensity(0.05)
+	assert.Equal(t, simulation.ModeFREE, engine.mode)
+	assert.Equal(t, 0.0, engine.target)
+	assert.Greater(t, p.TargetPower(), 0.0)
+}
+
+func TestPlayer_DrivesEngine(t *testing.T) {
+	engine := simulation.NewEngine(simulation.EngineConfig{
+		Chainrings:         []int{50, 34},
+		Cassette:           []int{11, 13, 15, 17, 19},
+		WheelCircumference: 2.1,
+		RiderWeight:        75,
+	})
+	p := NewPlayer(&Workout{Steps: []Step{Steady(time.Minute, PercentFTP(80))}}, 250, engine)
+	p.Start()
+
+	state := engine.Update(90, 190, 0)
+	assert.Equal(t, simulation.ModeERG, state.Mode)
+	assert.Equal(t, 200.0, state.TargetPower)
+}
+
+func TestPlayer_Extend(t *testing.T) {
+	engine := &fakeEngine{}
+	w := &Workout{Steps: []Step{
+		Steady(time.Minute, Watts(100)),
+		Steady(time.Minute, Watts(200)),
+	}}
+	p := NewPlayer(w, 250, engine)
+	p.Start()
+
+	p.Advance(50 * time.Second)
+	p.Extend(time.Minute)
+	assert.Equal(t, 70*time.Second, p.StepRemaining())
+	assert.Equal(t, 3*time.Minute, p.Duration())
+
+	// Still in the first step past its planned end
+	assert.Empty(t, p.Advance(30*time.Second))
+	assert.Equal(t, 100.0, engine.target)
+
+	events := p.Advance(40 * time.Second)
+	require.Len(t, events, 1)
+	assert.Equal(t, 200.0, engine.target)
+
+	// The workout itself is unchanged
+	assert.Equal(t, 2*time.Minute, w.Duration())
+}
+
+func TestPlayer_AdjustIntensity(t *testing.T) {
+	engine := &fakeEngine{}
+	w := &Workout{Steps: []Step{Steady(time.Minute, PercentFTP(100))}}
+	p := NewPlayer(w, 200, engine)
+	p.Start()
+
+	p.AdjustIntensity(0.05)
+	assert.InDelta(t, 210, engine.target, 0.01)
+	assert.InDelta(t, 210, p.Status(4).Profile[0], 0.01)
+
+	p.AdjustIntensity(-0.10)
+	assert.InDelta(t, 190, engine.target, 0.01)
+
+	for i := 0; i < 20; i++ {
+		p.AdjustIntensity(-0.05)
+	}
+	assert.Equal(t, 0.5, p.Intensity())
+}
+
+func TestPlayer_Status(t *testing.T) {
+	p := NewPlayer(testWorkout(), 300, &fakeEngine{})
+	p.Start()
+	p.Advance(11 * time.Minute)
+
+	status := p.Status(10)
+	assert.Equal(t, "4x4", status.Name)
+	assert.Equal(t, 1, status.Current.Index)
+	assert.True(t, status.HasNext)
+	assert.Equal(t, StepFreeRide, status.Next.Kind)
+	assert.Equal(t, 3*time.Minute, status.StepRemaining)
+	assert.Equal(t, 39*time.Minute, status.Duration)
+	assert.Equal(t, 10, status.Steps)
+	assert.Len(t, status.Profile, 10)
+
+	p.Advance(time.Hour)
+	status = p.Status(10)
+	assert.True(t, status.Done)
+	assert.False(t, status.HasNext)
+	assert.Equal(t, 10, p.Index())
+}
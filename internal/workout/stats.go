@@ -0,0 +1,66 @@
+package workout
+
+import (
+	"time"
+
+	"github.com/thiemotorres/goc/internal/analytics"
+)
+
+// DefaultFTP is used to resolve targets when the rider's FTP is unknown
+const DefaultFTP = 200
+
+// freeRideIntensity is the fraction of FTP assumed for free ride steps
+// when estimating load
+const freeRideIntensity = 0.5
+
+// targetSeconds returns the planned power for every second of the intervals
+func targetSeconds(intervals []Interval, ftp float64) []float64 {
+	var watts []float64
+	for _, iv := range intervals {
+		for t := time.Duration(0); t < iv.Duration; t += time.Second {
+			p := iv.PowerAt(t, ftp)
+			if iv.Kind == StepFreeRide {
+				p = freeRideIntensity * ftp
+			}
+			watts = append(watts, p)
+		}
+	}
+	return watts
+}
+
+// TSS estimates the Training Stress Score of riding the workout as planned.
+// Free ride steps count as easy riding at half of FTP.
+func (w *Workout) TSS(ftp float64) float64 {
+	if ftp <= 0 {
+		return 0
+	}
+	watts := targetSeconds(w.Intervals(), ftp)
+	duration := time.Duration(len(watts)) * time.Second
+	return analytics.TSS(duration, analytics.NormalizedPower(watts), ftp)
+}
+
+// Profile returns the average planned power in n equal slices of the
+// workout, for charts
+func (w *Workout) Profile(n int, ftp float64) []float64 {
+	return profile(w.Intervals(), n, ftp)
+}
+
+func profile(intervals []Interval, n int, ftp float64) []float64 {
+	watts := targetSeconds(intervals, ftp)
+	if n <= 0 || len(watts) == 0 {
+		return nil
+	}
+
+	profile := make([]float64, n)
+	for i := range profile {
+		from := i * len(watts) / n
+		to := max((i+1)*len(watts)/n, from+1)
+		to = min(to, len(watts))
+		var sum float64
+		for _, p := range watts[from:to] {
+			sum += p
+		}
+		profile[i] = sum / float64(to-from)
+	}
+	return profile
+}
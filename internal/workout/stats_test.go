@@ -0,0 +1,42 @@
+package workout
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkout_TSS(t *testing.T) {
+	// One hour at FTP is 100 TSS by definition
+	w := &Workout{Steps: []Step{Steady(time.Hour, PercentFTP(100))}}
+	assert.InDelta(t, 100, w.TSS(250), 0.5)
+
+	// Absolute targets depend on FTP
+	w = &Workout{Steps: []Step{Steady(time.Hour, Watts(200))}}
+	assert.InDelta(t, 64, w.TSS(250), 0.5)
+	assert.InDelta(t, 100, w.TSS(200), 0.5)
+
+	// Intervals score higher than their average power ridden steady
+	intervals := &Workout{Steps: []Step{Repeat(6, Steady(5*time.Minute, PercentFTP(120)), Steady(5*time.Minute, PercentFTP(60)))}}
+	steady := &Workout{Steps: []Step{Steady(time.Hour, PercentFTP(90))}}
+	assert.Greater(t, intervals.TSS(250), steady.TSS(250))
+
+	assert.Equal(t, 0.0, w.TSS(0))
+}
+
+func TestWorkout_Profile(t *testing.T) {
+	w := &Workout{Steps: []Step{
+		Steady(time.Minute, Watts(100)),
+		Steady(time.Minute, Watts(300)),
+		FreeRide(time.Minute),
+	}}
+
+	profile := w.Profile(3, 200)
+	require.Len(t, profile, 3)
+	assert.Equal(t, []float64{100, 300, 100}, profile)
+
+	assert.Len(t, w.Profile(500, 200), 500)
+	assert.Nil(t, (&Workout{}).Profile(10, 200))
+}
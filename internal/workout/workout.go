@@ -0,0 +1,222 @@
+package workout
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Unit says how a power target is expressed
+type Unit int
+
+const (
+	UnitWatts Unit = iota // Absolute power
+	UnitFTP               // Percent of FTP
+)
+
+// Target is a power target in watts or percent of FTP
+type Target struct {
+	Value float64
+	Unit  Unit
+}
+
+// Watts returns an absolute power target
+func Watts(w float64) Target {
+	return Target{Value: w, Unit: UnitWatts}
+}
+
+// PercentFTP returns a target relative to FTP, 75 meaning 75%
+func PercentFTP(percent float64) Target {
+	return Target{Value: percent, Unit: UnitFTP}
+}
+
+// Resolve returns the target in watts for the given FTP
+func (t Target) Resolve(ftp float64) float64 {
+	if t.Unit == UnitFTP {
+		return t.Value / 100 * ftp
+	}
+	return t.Value
+}
+
+func (t Target) String() string {
+	if t.Unit == UnitFTP {
+		return fmt.Sprintf("%.0f%% FTP", t.Value)
+	}
+	return fmt.Sprintf("%.0fW", t.Value)
+}
+
+// StepKind is the type of a workout step
+type StepKind int
+
+const (
+	StepSteady   StepKind = iota // Constant power
+	StepRamp                     // Power changing linearly from Power to PowerEnd
+	StepFreeRide                 // No power target, the rider sets resistance
+	StepRepeat                   // Steps ridden Repeat times
+)
+
+func (k StepKind) String() string {
+	switch k {
+	case StepSteady:
+		return "Steady"
+	case StepRamp:
+		return "Ramp"
+	case StepFreeRide:
+		return "Free Ride"
+	case StepRepeat:
+		return "Repeat"
+	default:
+		return "Unknown"
+	}
+}
+
+// Step is one segment of a workout. Repeat steps hold their own steps and
+// have no duration of their own.
+type Step struct {
+	Kind     StepKind
+	Duration time.Duration
+	Power    Target // Steady power, or ramp start
+	PowerEnd Target // Ramp end
+	Cadence  int    // Target cadence in RPM, 0 = none
+	Text     string // Shown while the step runs
+
+	Repeat int
+	Steps  []Step
+}
+
+// Steady returns a constant power step
+func Steady(d time.Duration, power Target) Step {
+	return Step{Kind: StepSteady, Duration: d, Power: power}
+}
+
+// Ramp returns a step moving linearly from one power to another
+func Ramp(d time.Duration, from, to Target) Step {
+	return Step{Kind: StepRamp, Duration: d, Power: from, PowerEnd: to}
+}
+
+// FreeRide returns a step without a power target
+func FreeRide(d time.Duration) Step {
+	return Step{Kind: StepFreeRide, Duration: d}
+}
+
+// Repeat returns a block riding steps n times
+func Repeat(n int, steps ...Step) Step {
+	return Step{Kind: StepRepeat, Repeat: n, Steps: steps}
+}
+
+// WithCadence returns the step with a cadence target
+func (s Step) WithCadence(rpm int) Step {
+	s.Cadence = rpm
+	return s
+}
+
+// WithText returns the step with a message for the rider
+func (s Step) WithText(text string) Step {
+	s.Text = text
+	return s
+}
+
+// PowerAt returns the target power in watts at offset into the step,
+// 0 for free ride steps
+func (s Step) PowerAt(offset time.Duration, ftp float64) float64 {
+	switch s.Kind {
+	case StepSteady:
+		return s.Power.Resolve(ftp)
+	case StepRamp:
+		frac := 0.0
+		if s.Duration > 0 {
+			frac = min(max(float64(offset)/float64(s.Duration), 0), 1)
+		}
+		from := s.Power.Resolve(ftp)
+		return from + (s.PowerEnd.Resolve(ftp)-from)*frac
+	default:
+		return 0
+	}
+}
+
+// Workout is a named sequence of steps
+type Workout struct {
+	Name        string
+	Description string
+	Steps       []Step
+}
+
+// Interval is a step placed on the workout timeline, with repeats expanded
+type Interval struct {
+	Step
+	Index int           // Position in the expanded workout
+	Start time.Duration // Offset from the workout start
+	Rep   int           // Repetition number from 1, 0 outside repeats
+	Reps  int           // Repetitions of the enclosing repeat
+}
+
+// End returns the offset at which the interval ends
+func (iv Interval) End() time.Duration {
+	return iv.Start + iv.Duration
+}
+
+// Intervals returns the workout steps in riding order with repeats expanded
+func (w *Workout) Intervals() []Interval {
+	var out []Interval
+	var start time.Duration
+	var expand func(steps []Step, rep, reps int)
+	expand = func(steps []Step, rep, reps int) {
+		for _, s := range steps {
+			if s.Kind == StepRepeat {
+				for i := 1; i <= s.Repeat; i++ {
+					expand(s.Steps, i, s.Repeat)
+				}
+				continue
+			}
+			out = append(out, Interval{Step: s, Index: len(out), Start: start, Rep: rep, Reps: reps})
+			start += s.Duration
+		}
+	}
+	expand(w.Steps, 0, 0)
+	return out
+}
+
+// Duration returns the total workout duration
+func (w *Workout) Duration() time.Duration {
+	var d time.Duration
+	for _, iv := range w.Intervals() {
+		d += iv.Duration
+	}
+	return d
+}
+
+// ErrEmptyWorkout is returned by Validate for workouts without steps
+var ErrEmptyWorkout = errors.New("workout has no steps")
+
+// Validate checks that every step can be ridden
+func (w *Workout) Validate() error {
+	if len(w.Intervals()) == 0 {
+		return ErrEmptyWorkout
+	}
+	return validateSteps(w.Steps, "step ")
+}
+
+func validateSteps(steps []Step, prefix string) error {
+	for i, s := range steps {
+		name := fmt.Sprintf("%s%d", prefix, i+1)
+		if s.Kind == StepRepeat {
+			if s.Repeat < 1 {
+				return fmt.Errorf("%s: repeat count %d must be at least 1", name, s.Repeat)
+			}
+			if err := validateSteps(s.Steps, name+"."); err != nil {
+				return err
+			}
+			continue
+		}
+		if s.Duration <= 0 {
+			return fmt.Errorf("%s: duration must be positive", name)
+		}
+		if s.Power.Value < 0 || s.PowerEnd.Value < 0 {
+			return fmt.Errorf("%s: negative power target", name)
+		}
+		if s.Cadence < 0 {
+			return fmt.Errorf("%s: negative cadence target", name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package workout
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testWorkout() *Workout {
+	return &Workout{
+		Name: "4x4",
+		Steps: []Step{
+			Ramp(10*time.Minute, PercentFTP(50), PercentFTP(75)),
+			Repeat(4,
+				Steady(4*time.Minute, PercentFTP(110)).WithCadence(95),
+				FreeRide(2*time.Minute),
+			),
+			Steady(5*time.Minute, Watts(120)).WithText("Cool down"),
+		},
+	}
+}
+
+func TestTarget_Resolve(t *testing.T) {
+	assert.Equal(t, 200.0, Watts(200).Resolve(300))
+	assert.Equal(t, 225.0, PercentFTP(75).Resolve(300))
+	assert.Equal(t, "75% FTP", PercentFTP(75).String())
+	assert.Equal(t, "200W", Watts(200).String())
+}
+
+func TestStep_PowerAt(t *testing.T) {
+	ramp := Ramp(10*time.Minute, Watts(100), Watts(200))
+	assert.Equal(t, 100.0, ramp.PowerAt(0, 250))
+	assert.Equal(t, 150.0, ramp.PowerAt(5*time.Minute, 250))
+	assert.Equal(t, 200.0, ramp.PowerAt(20*time.Minute, 250))
+
+	assert.Equal(t, 0.0, FreeRide(time.Minute).PowerAt(0, 250))
+}
+
+func TestWorkout_Intervals(t *testing.T) {
+	w := testWorkout()
+	intervals := w.Intervals()
+	require.Len(t, intervals, 10)
+
+	assert.Equal(t, StepRamp, intervals[0].Kind)
+	assert.Equal(t, 0, intervals[0].Rep)
+
+	assert.Equal(t, 10*time.Minute, intervals[1].Start)
+	assert.Equal(t, 1, intervals[1].Rep)
+	assert.Equal(t, 4, intervals[1].Reps)
+	assert.Equal(t, 95, intervals[1].Cadence)
+
+	assert.Equal(t, 4, intervals[7].Rep)
+	assert.Equal(t, StepFreeRide, intervals[8].Kind)
+	assert.Equal(t, "Cool down", intervals[9].Text)
+	assert.Equal(t, 9, intervals[9].Index)
+
+	assert.Equal(t, 39*time.Minute, w.Duration())
+}
+
+func TestWorkout_Validate(t *testing.T) {
+	assert.NoError(t, testWorkout().Validate())
+	assert.ErrorIs(t, (&Workout{}).Validate(), ErrEmptyWorkout)
+
+	w := &Workout{Steps: []Step{Repeat(2, Steady(0, Watts(100)))}}
+	assert.ErrorContains(t, w.Validate(), "step 1.1")
+
+	w = &Workout{Steps: []Step{Steady(time.Minute, Watts(100)), Repeat(0, Steady(time.Minute, Watts(100)))}}
+	assert.ErrorContains(t, w.Validate(), "repeat count")
+}
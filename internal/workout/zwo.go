@@ -0,0 +1,106 @@
+package workout
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+)
+
+// zwoFile is the root of a Zwift workout file
+type zwoFile struct {
+	Name        string `xml:"name"`
+	Description string `xml:"description"`
+	Workout     struct {
+		Steps []zwoStep `xml:",any"`
+	} `xml:"workout"`
+}
+
+// zwoStep is any element inside <workout>. Power values are fractions of
+// FTP and durations are seconds.
+type zwoStep struct {
+	XMLName        xml.Name
+	Duration       float64      `xml:"Duration,attr"`
+	Power          float64      `xml:"Power,attr"`
+	PowerLow       float64      `xml:"PowerLow,attr"`
+	PowerHigh      float64      `xml:"PowerHigh,attr"`
+	Cadence        int          `xml:"Cadence,attr"`
+	CadenceResting int          `xml:"CadenceResting,attr"`
+	Repeat         int          `xml:"Repeat,attr"`
+	OnDuration     float64      `xml:"OnDuration,attr"`
+	OffDuration    float64      `xml:"OffDuration,attr"`
+	OnPower        float64      `xml:"OnPower,attr"`
+	OffPower       float64      `xml:"OffPower,attr"`
+	Texts          []zwoMessage `xml:"textevent"`
+}
+
+type zwoMessage struct {
+	Message string `xml:"message,attr"`
+}
+
+// ParseZWO reads a Zwift .zwo workout
+func ParseZWO(r io.Reader) (*Workout, error) {
+	var f zwoFile
+	if err := xml.NewDecoder(r).Decode(&f); err != nil {
+		return nil, fmt.Errorf("parse zwo: %w", err)
+	}
+
+	w := &Workout{
+		Name:        strings.TrimSpace(f.Name),
+		Description: strings.TrimSpace(f.Description),
+	}
+	for _, s := range f.Workout.Steps {
+		step, ok := s.step()
+		if !ok {
+			continue // unknown elements are skipped
+		}
+		w.Steps = append(w.Steps, step)
+	}
+
+	if err := w.Validate(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// step converts a ZWO element, false for elements that are not steps
+func (s zwoStep) step() (Step, bool) {
+	var step Step
+	switch strings.ToLower(s.XMLName.Local) {
+	case "steadystate":
+		step = Steady(seconds(s.Duration), zwoPower(s.Power))
+	case "warmup", "cooldown", "ramp":
+		low, high := s.PowerLow, s.PowerHigh
+		if low == 0 && high == 0 {
+			low, high = s.Power, s.Power
+		}
+		step = Ramp(seconds(s.Duration), zwoPower(low), zwoPower(high))
+	case "freeride", "maxeffort":
+		step = FreeRide(seconds(s.Duration))
+	case "intervalst":
+		on := Steady(seconds(s.OnDuration), zwoPower(s.OnPower)).WithCadence(s.Cadence)
+		off := Steady(seconds(s.OffDuration), zwoPower(s.OffPower)).WithCadence(s.CadenceResting)
+		if len(s.Texts) > 0 {
+			on.Text = s.Texts[0].Message
+		}
+		return Repeat(max(s.Repeat, 1), on, off), true
+	default:
+		return Step{}, false
+	}
+
+	step.Cadence = s.Cadence
+	if len(s.Texts) > 0 {
+		step.Text = s.Texts[0].Message
+	}
+	return step, true
+}
+
+func zwoPower(fraction float64) Target {
+	return PercentFTP(math.Round(fraction*1000) / 10)
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
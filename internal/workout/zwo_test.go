@@ -0,0 +1,54 @@
+package workout
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad_ZWO(t *testing.T) {
+	w, err := Load("../../testdata/workout.zwo")
+	require.NoError(t, err)
+
+	assert.Equal(t, "Sweet Spot 3x8", w.Name)
+	assert.Contains(t, w.Description, "sweet spot")
+	require.Len(t, w.Steps, 5)
+
+	warmup := w.Steps[0]
+	assert.Equal(t, StepRamp, warmup.Kind)
+	assert.Equal(t, 10*time.Minute, warmup.Duration)
+	assert.Equal(t, PercentFTP(40), warmup.Power)
+	assert.Equal(t, PercentFTP(75), warmup.PowerEnd)
+
+	steady := w.Steps[1]
+	assert.Equal(t, PercentFTP(88), steady.Power)
+	assert.Equal(t, 90, steady.Cadence)
+	assert.Equal(t, "Settle in", steady.Text)
+
+	intervals := w.Steps[2]
+	assert.Equal(t, StepRepeat, intervals.Kind)
+	assert.Equal(t, 3, intervals.Repeat)
+	require.Len(t, intervals.Steps, 2)
+	assert.Equal(t, 8*time.Minute, intervals.Steps[0].Duration)
+	assert.Equal(t, 95, intervals.Steps[0].Cadence)
+	assert.Equal(t, PercentFTP(55), intervals.Steps[1].Power)
+
+	assert.Equal(t, StepFreeRide, w.Steps[3].Kind)
+
+	cooldown := w.Steps[4]
+	assert.Equal(t, PercentFTP(65), cooldown.Power)
+	assert.Equal(t, PercentFTP(35), cooldown.PowerEnd)
+
+	assert.Equal(t, 52*time.Minute, w.Duration())
+}
+
+func TestParseZWO_Errors(t *testing.T) {
+	_, err := ParseZWO(strings.NewReader("not xml"))
+	assert.Error(t, err)
+
+	_, err = ParseZWO(strings.NewReader("<workout_file><workout></workout></workout_file>"))
+	assert.ErrorIs(t, err, ErrEmptyWorkout)
+}
@@ -0,0 +1,105 @@
+// Package zones maps power to training zones relative to FTP
+package zones
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// Zone is a band of power as fractions of FTP. Max is 0 for the open
+// ended top zone.
+type Zone struct {
+	Name string
+	Min  float64
+	Max  float64
+}
+
+// Model is a set of consecutive zones starting at zero
+type Model struct {
+	Name  string
+	Zones []Zone
+}
+
+// Coggan is the 7-zone power model from Training and Racing with a Power
+// Meter
+var Coggan = Model{
+	Name: "coggan",
+	Zones: []Zone{
+		{"Active Recovery", 0, 0.55},
+		{"Endurance", 0.55, 0.75},
+		{"Tempo", 0.75, 0.90},
+		{"Threshold", 0.90, 1.05},
+		{"VO2max", 1.05, 1.20},
+		{"Anaerobic", 1.20, 1.50},
+		{"Neuromuscular", 1.50, 0},
+	},
+}
+
+// Model names accepted by Lookup, in cycling order
+const (
+	ModelCoggan = "coggan"
+	ModelCustom = "custom"
+)
+
+// Models lists the model names
+var Models = []string{ModelCoggan, ModelCustom}
+
+// ErrUnknownModel is returned by Lookup for names not in Models
+var ErrUnknownModel = errors.New("unknown zone model")
+
+// Custom builds a model from the upper bounds of each zone in percent of
+// FTP, e.g. [55 75 90 105 120] gives six zones named Z1 to Z6
+func Custom(bounds []float64) (Model, error) {
+	if len(bounds) == 0 {
+		return Model{}, errors.New("custom zones: at least one bound required")
+	}
+	if !sort.Float64sAreSorted(bounds) || bounds[0] <= 0 {
+		return Model{}, errors.New("custom zones: bounds must be positive and ascending")
+	}
+
+	m := Model{Name: ModelCustom}
+	lo := 0.0
+	for i, b := range bounds {
+		if b/100 == lo {
+			return Model{}, fmt.Errorf("custom zones: bound %.0f repeated", b)
+		}
+		m.Zones = append(m.Zones, Zone{Name: fmt.Sprintf("Z%d", i+1), Min: lo, Max: b / 100})
+		lo = b / 100
+	}
+	m.Zones = append(m.Zones, Zone{Name: fmt.Sprintf("Z%d", len(bounds)+1), Min: lo})
+	return m, nil
+}
+
+// Lookup returns the named model. custom holds the bounds for the custom
+// model.
+func Lookup(name string, custom []float64) (Model, error) {
+	switch name {
+	case "", ModelCoggan:
+		return Coggan, nil
+	case ModelCustom:
+		return Custom(custom)
+	}
+	return Model{}, fmt.Errorf("%w %q", ErrUnknownModel, name)
+}
+
+// Zone returns the index of the zone power falls in, -1 without an FTP
+func (m Model) Zone(power, ftp float64) int {
+	if ftp <= 0 || len(m.Zones) == 0 {
+		return -1
+	}
+	ratio := power / ftp
+	for i, z := range m.Zones {
+		if z.Max == 0 || ratio < z.Max {
+			return i
+		}
+	}
+	return len(m.Zones) - 1
+}
+
+// Watts returns the power range of zone i. The top zone has no upper
+// limit and returns 0 for hi.
+func (m Model) Watts(i int, ftp float64) (lo, hi float64) {
+	z := m.Zones[i]
+	return z.Min * ftp, z.Max * ftp
+}
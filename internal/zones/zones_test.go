@@ -0,0 +1,62 @@
+package zones
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCoggan_Zone(t *testing.T) {
+	tests := []struct {
+		power float64
+		want  int
+	}{
+		{0, 0},
+		{109, 0},
+		{110, 1},
+		{179, 2},
+		{200, 3},
+		{230, 4},
+		{299, 5},
+		{300, 6},
+		{1200, 6},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, Coggan.Zone(tt.power, 200), "%.0f W", tt.power)
+	}
+	assert.Equal(t, -1, Coggan.Zone(200, 0))
+}
+
+func TestCustom(t *testing.T) {
+	m, err := Custom([]float64{60, 80, 100})
+	require.NoError(t, err)
+	require.Len(t, m.Zones, 4)
+	assert.Equal(t, "Z1", m.Zones[0].Name)
+	assert.Equal(t, "Z4", m.Zones[3].Name)
+
+	assert.Equal(t, 1, m.Zone(150, 250))
+	assert.Equal(t, 3, m.Zone(260, 250))
+
+	lo, hi := m.Watts(1, 250)
+	assert.Equal(t, 150.0, lo)
+	assert.Equal(t, 200.0, hi)
+
+	for _, bounds := range [][]float64{nil, {80, 60}, {0, 60}, {60, 60}} {
+		_, err := Custom(bounds)
+		assert.Error(t, err, "%v", bounds)
+	}
+}
+
+func TestLookup(t *testing.T) {
+	m, err := Lookup("", nil)
+	require.NoError(t, err)
+	assert.Equal(t, ModelCoggan, m.Name)
+
+	m, err = Lookup(ModelCustom, []float64{50, 100})
+	require.NoError(t, err)
+	assert.Len(t, m.Zones, 3)
+
+	_, err = Lookup("polarized", nil)
+	assert.ErrorIs(t, err, ErrUnknownModel)
+}
@@ -4,6 +4,7 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/thiemotorres/goc/cmd"
 	"github.com/thiemotorres/goc/internal/tui"
@@ -25,12 +26,17 @@ func main() {
 		gpxPath := rideCmd.String("gpx", "", "GPX file for route simulation")
 		ergWatts := rideCmd.Int("erg", 0, "ERG mode target watts")
 		mock := rideCmd.Bool("mock", false, "Use mock Bluetooth (for development)")
+		powerSource := rideCmd.String("power", "", "Power source: trainer or power_meter")
+		cadenceSource := rideCmd.String("cadence", "", "Cadence source: trainer, power_meter or cadence_sensor")
 		rideCmd.Parse(os.Args[2:])
 
 		opts := cmd.RideOptions{
 			GPXPath:  *gpxPath,
 			ERGWatts: *ergWatts,
 			Mock:     *mock,
+
+			PowerSource:   *powerSource,
+			CadenceSource: *cadenceSource,
 		}
 
 		if err := cmd.Ride(opts); err != nil {
@@ -39,6 +45,17 @@ func main() {
 		}
 
 	case "history":
+		if len(os.Args) > 2 {
+			switch os.Args[2] {
+			case "show", "rm", "rename":
+				if err := historySubcommand(os.Args[2], os.Args[3:]); err != nil {
+					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+					os.Exit(1)
+				}
+				return
+			}
+		}
+
 		historyCmd := flag.NewFlagSet("history", flag.ExitOnError)
 		limit := historyCmd.Int("n", 20, "Number of rides to show")
 		historyCmd.Parse(os.Args[2:])
@@ -52,6 +69,35 @@ func main() {
 			os.Exit(1)
 		}
 
+	case "load":
+		loadCmd := flag.NewFlagSet("load", flag.ExitOnError)
+		days := loadCmd.Int("days", 42, "Number of days to show")
+		chart := loadCmd.Bool("chart", false, "Show an ASCII chart instead of a table")
+		loadCmd.Parse(os.Args[2:])
+
+		opts := cmd.LoadOptions{
+			Days:  *days,
+			Chart: *chart,
+		}
+
+		if err := cmd.Load(opts); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+	case "import":
+		importCmd := flag.NewFlagSet("import", flag.ExitOnError)
+		importCmd.Parse(os.Args[2:])
+
+		opts := cmd.ImportOptions{
+			Paths: importCmd.Args(),
+		}
+
+		if err := cmd.Import(opts); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
 	case "help", "-h", "--help":
 		printUsage()
 
@@ -62,6 +108,27 @@ func main() {
 	}
 }
 
+// historySubcommand runs goc history show, rm or rename
+func historySubcommand(name string, args []string) error {
+	subCmd := flag.NewFlagSet("history "+name, flag.ExitOnError)
+	force := subCmd.Bool("f", false, "Delete without asking")
+	subCmd.Parse(args)
+
+	if subCmd.NArg() < 1 {
+		return fmt.Errorf("usage: goc history %s <id>", name)
+	}
+	id := subCmd.Arg(0)
+
+	switch name {
+	case "show":
+		return cmd.HistoryShow(id)
+	case "rm":
+		return cmd.HistoryRemove(id, *force)
+	default: // rename
+		return cmd.HistoryRename(id, strings.Join(subCmd.Args()[1:], " "))
+	}
+}
+
 func printUsage() {
 	fmt.Println("goc - Indoor Cycling Trainer")
 	fmt.Println()
@@ -71,13 +138,29 @@ func printUsage() {
 	fmt.Println("Commands:")
 	fmt.Println("  ride      Start a cycling session")
 	fmt.Println("  history   View past rides")
+	fmt.Println("  load      Show fitness, fatigue and form")
+	fmt.Println("  import    Import FIT activity files into history")
 	fmt.Println("  help      Show this help")
 	fmt.Println()
 	fmt.Println("Ride options:")
 	fmt.Println("  -gpx <file>   Load GPX route for simulation mode")
 	fmt.Println("  -erg <watts>  ERG mode with fixed target power")
 	fmt.Println("  -mock         Use mock Bluetooth (for testing)")
+	fmt.Println("  -power <src>  Power source: trainer, power_meter")
+	fmt.Println("  -cadence <src> Cadence source: trainer, power_meter, cadence_sensor")
 	fmt.Println()
 	fmt.Println("History options:")
 	fmt.Println("  -n <count>    Number of rides to show (default: 20)")
+	fmt.Println()
+	fmt.Println("History subcommands:")
+	fmt.Println("  goc history show <id>           Show a ride in detail")
+	fmt.Println("  goc history rm [-f] <id>        Delete a ride and its files")
+	fmt.Println("  goc history rename <id> <name>  Name a ride, an empty name clears it")
+	fmt.Println()
+	fmt.Println("Load options:")
+	fmt.Println("  -days <count> Number of days to show (default: 42)")
+	fmt.Println("  -chart        Show an ASCII chart instead of a table")
+	fmt.Println()
+	fmt.Println("Import usage:")
+	fmt.Println("  goc import <file.fit> [file.fit ...]")
 }